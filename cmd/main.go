@@ -1,21 +1,32 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"os"
+	"path/filepath"
+	"runtime"
 	"time"
 
-	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	zone "github.com/lrstanley/bubblezone"
+	"github.com/nooooaaaaah/photoboard/internal/config"
+	"github.com/nooooaaaaah/photoboard/internal/dupes"
 	"github.com/nooooaaaaah/photoboard/internal/explorer"
 	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+	"github.com/nooooaaaaah/photoboard/internal/termcap"
+	"github.com/nooooaaaaah/photoboard/internal/thumbs"
 	"github.com/nooooaaaaah/photoboard/internal/ui"
 	"github.com/nooooaaaaah/photoboard/internal/utils"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
 )
 
 type ModelWrapper struct {
@@ -59,33 +70,93 @@ func (m ModelWrapper) View() string {
 }
 
 func main() {
+	doctor := flag.Bool("doctor", false, "print detected terminal capabilities and exit")
+	findDupes := flag.Bool("find-duplicates", false, "list duplicate images under the current directory and exit")
+	listThemes := flag.Bool("list-themes", false, "list builtin and user themes and exit")
+	pruneThumbs := flag.Bool("prune-thumbnails", false, "remove stale thumbnails, shrink the cache to its size limit and exit")
+	flag.Parse()
+
 	log.SetReportCaller(true)
 	log.SetTimeFormat(time.Kitchen)
 
+	// Probe the terminal before bubbletea takes it over
+	report := termcap.Detect(200 * time.Millisecond)
+	report.Apply()
+	if *doctor {
+		fmt.Print(report)
+		return
+	}
+
 	// Initialize global zone manager
 	zone.NewGlobal()
 	defer zone.Close()
 
 	dir := utils.GetRootPath()
 
-	styler := ui.NewDefaultStyler()
-	nav := explorer.Navigator{}
-	prev := explorer.Previewer{}
-	uiHandler := ui.NewWindowHandler(styler)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Warn("Failed to load config, using defaults", "error", err)
+	}
 
-	// Create model with all dependencies
-	m := model.NewModel(dir, styler, nav, prev, uiHandler)
+	if *listThemes {
+		for _, name := range ui.ThemeNames() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	cache := thumbs.NewCache(int64(cfg.ThumbnailCacheMB) << 20)
+	if *pruneThumbs {
+		stats, err := cache.Prune()
+		if err != nil {
+			fmt.Printf("Error pruning thumbnails: %v\n", err)
+			return
+		}
+		fmt.Printf("Scanned %d thumbnails in %s: removed %d stale and %d over the size limit, freed %d KiB, %d KiB of our thumbnails left\n",
+			stats.Scanned, cache.Root, stats.Stale, stats.Evicted, stats.Freed>>10, stats.Size>>10)
+		return
+	}
+	go cache.Trim()
 
-	// Configure initial delegate for the first column
-	delegate := list.NewDefaultDelegate()
-	delegate.SetSpacing(0)
-	delegate.ShowDescription = false
-	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.
-		Foreground(lipgloss.Color("205"))
+	dupeOpts := dupes.Options{Hash: cfg.DuplicateHash, Threshold: cfg.DuplicateThreshold}
+	if *findDupes {
+		printDupes(dir, dupeOpts, cfg.ShowHidden)
+		return
+	}
+
+	workers := cfg.ThumbnailWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	pool := thumbs.NewPool(cache, thumbs.Normal, workers)
+	defer pool.Close()
+
+	theme, err := ui.ResolveTheme(cfg.Theme, ui.DetectBackground(cfg.Background))
+	if err != nil {
+		log.Warn("Failed to load theme, using default", "error", err)
+	}
+
+	styler := ui.NewDefaultStyler(theme, cfg.Icons)
+	caps := report.Capabilities()
+	caps.Assume(cfg.ImageBackend)
+	prev := explorer.NewPreviewer(render.NewChain(caps, cfg.ImageBackend), pool)
+
+	// Create model with all dependencies
+	m := model.NewModel(dir, cfg, styler, model.Handlers{
+		Navigator: explorer.NewNavigator(pool),
+		Previewer: prev,
+		Gallery:   explorer.NewGallery(pool),
+		Slideshow: explorer.NewSlideshow(prev, time.Duration(cfg.SlideshowInterval*float64(time.Second))),
+		Cull:      explorer.NewCull(prev),
+		Dupes:     explorer.NewDupes(dupeOpts),
+		Compare:   explorer.NewCompare(prev),
+		Board:     explorer.NewBoard(),
+		UI:        ui.NewWindowHandler(styler),
+	})
 
 	// Initialize the first column with proper width
 	initialWidth := 30 // This will be adjusted by window resize
-	err := m.AddColumn(dir, initialWidth)
+	err = m.AddColumn(dir, initialWidth)
 	if err != nil {
 		log.Error("Failed to create initial column", "error", err)
 		return
@@ -98,6 +169,7 @@ func main() {
 
 	p := tea.NewProgram(
 		&wrapper,
+		tea.WithOutput(render.Output),
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
@@ -107,3 +179,37 @@ func main() {
 		return
 	}
 }
+
+// printDupes lists each group of duplicates, the copy worth keeping first.
+// A failed scan exits non-zero so scripts notice.
+func printDupes(root string, opts dupes.Options, showHidden bool) {
+	opts.ShowHidden = showHidden
+	opts.Progress = func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\rHashing %d/%d", done, total)
+	}
+	groups, err := dupes.Scan(context.Background(), root, opts)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding duplicates: %v\n", err)
+		os.Exit(1)
+	}
+	if len(groups) == 0 {
+		fmt.Println("No duplicates found")
+		return
+	}
+
+	for i, g := range groups {
+		kind := fmt.Sprintf("near, up to %d bits from the best", g.Distance)
+		if g.Exact {
+			kind = "identical"
+		}
+		fmt.Printf("Group %d (%s):\n", i+1, kind)
+		for _, img := range g.Images {
+			name := img.Path
+			if rel, err := filepath.Rel(root, img.Path); err == nil {
+				name = rel
+			}
+			fmt.Printf("  %s  %dx%d  %d KiB\n", name, img.Width, img.Height, img.Size>>10)
+		}
+	}
+}
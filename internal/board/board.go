@@ -0,0 +1,188 @@
+// Package board keeps moodboards: named lists of images with a layout,
+// saved as JSON and composed into a single collage
+package board
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/config"
+)
+
+const (
+	LayoutGrid    = "grid"
+	LayoutMasonry = "masonry"
+)
+
+// Board is one moodboard as it's saved
+type Board struct {
+	Name   string `json:"name"`
+	Layout string `json:"layout"` // grid or masonry
+	// Columns is how many images go across
+	Columns int `json:"columns"`
+	// Spacing is the gap between images and around the edge, in pixels of
+	// the export
+	Spacing    int    `json:"spacing"`
+	Background string `json:"background"` // hex, like "#ffffff"
+	// Width is the export's width in pixels; the height follows from the
+	// layout
+	Width  int      `json:"width"`
+	Images []string `json:"images"`
+}
+
+// New is an empty board with the defaults
+func New(name string) Board {
+	return Board{
+		Name:       name,
+		Layout:     LayoutGrid,
+		Columns:    3,
+		Spacing:    16,
+		Background: "#ffffff",
+		Width:      2400,
+	}
+}
+
+// Dir is where boards live, one JSON file per board
+func Dir() string {
+	return filepath.Join(config.Dir(), "boards")
+}
+
+// CheckName rejects names that can't be a file name
+func CheckName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid board name %q", name)
+	}
+	return nil
+}
+
+// Load reads the board called name. A board that doesn't exist yet comes
+// back new, along with an error matching fs.ErrNotExist.
+func Load(name string) (Board, error) {
+	if err := CheckName(name); err != nil {
+		return Board{}, err
+	}
+	data, err := os.ReadFile(filepath.Join(Dir(), name+".json"))
+	if err != nil {
+		return New(name), err
+	}
+
+	b := New(name)
+	if err := json.Unmarshal(data, &b); err != nil {
+		return New(name), fmt.Errorf("board %q: %w", name, err)
+	}
+	b.Name = name
+	return b, nil
+}
+
+// Save writes the board, replacing the old file in one go
+func (b Board) Save() error {
+	if err := CheckName(b.Name); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(Dir(), "."+b.Name+"-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(append(data, '\n')); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(Dir(), b.Name+".json"))
+}
+
+// Names lists the saved boards
+func Names() ([]string, error) {
+	entries, err := os.ReadDir(Dir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && !strings.HasPrefix(name, ".") && filepath.Ext(name) == ".json" {
+			names = append(names, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Add appends the paths that aren't on the board yet and reports how many
+// that was
+func (b *Board) Add(paths ...string) int {
+	on := make(map[string]bool, len(b.Images))
+	for _, path := range b.Images {
+		on[path] = true
+	}
+	added := 0
+	for _, path := range paths {
+		if !on[path] {
+			on[path] = true
+			b.Images = append(b.Images, path)
+			added++
+		}
+	}
+	return added
+}
+
+// Remove takes the i'th image off the board
+func (b *Board) Remove(i int) {
+	if i >= 0 && i < len(b.Images) {
+		b.Images = append(b.Images[:i], b.Images[i+1:]...)
+	}
+}
+
+// Move swaps the i'th image with its neighbour delta away
+func (b *Board) Move(i, delta int) bool {
+	j := i + delta
+	if i < 0 || j < 0 || i >= len(b.Images) || j >= len(b.Images) {
+		return false
+	}
+	b.Images[i], b.Images[j] = b.Images[j], b.Images[i]
+	return true
+}
+
+// BackgroundColor parses Background, which can be "#rgb" or "#rrggbb"
+func (b Board) BackgroundColor() (color.RGBA, error) {
+	return ParseColor(b.Background)
+}
+
+// ParseColor parses a "#rgb" or "#rrggbb" hex color
+func ParseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
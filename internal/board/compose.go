@@ -0,0 +1,181 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+)
+
+// Loader decodes an image for composing. The preview hands in one that
+// keeps small copies around.
+type Loader func(path string) (image.Image, error)
+
+// Place works out where each image goes on a board width pixels wide,
+// given the images' sizes, and how tall the board comes out.
+//
+// A grid has rows of equal cells shaped like the average image, with each
+// image fitted and centred in its cell. Masonry keeps every image's shape,
+// dropping each one into the shortest column.
+func (b Board) Place(sizes []image.Point, width int) ([]image.Rectangle, int) {
+	cols := max(min(b.Columns, len(sizes)), 1)
+	gap := max(b.Spacing*width/max(b.Width, 1), 0)
+	cellW := max((width-gap*(cols+1))/cols, 1)
+
+	rects := make([]image.Rectangle, len(sizes))
+	if len(sizes) == 0 {
+		return rects, gap * 2
+	}
+
+	if b.Layout == LayoutMasonry {
+		heights := make([]int, cols)
+		for i := range heights {
+			heights[i] = gap
+		}
+		for i, size := range sizes {
+			col := 0
+			for c := range heights {
+				if heights[c] < heights[col] {
+					col = c
+				}
+			}
+			h := cellW
+			if size.X > 0 {
+				h = max(cellW*size.Y/size.X, 1)
+			}
+			x := gap + col*(cellW+gap)
+			rects[i] = image.Rect(x, heights[col], x+cellW, heights[col]+h)
+			heights[col] += h + gap
+		}
+		tallest := 0
+		for _, h := range heights {
+			tallest = max(tallest, h)
+		}
+		return rects, tallest
+	}
+
+	aspect, n := 0.0, 0
+	for _, size := range sizes {
+		if size.X > 0 && size.Y > 0 {
+			aspect += float64(size.Y) / float64(size.X)
+			n++
+		}
+	}
+	cellH := cellW
+	if n > 0 {
+		cellH = max(int(float64(cellW)*aspect/float64(n)+0.5), 1)
+	}
+
+	for i, size := range sizes {
+		x := gap + (i%cols)*(cellW+gap)
+		y := gap + (i/cols)*(cellH+gap)
+		w, h := imaging.Fit(size.X, size.Y, cellW, cellH, 1)
+		x += (cellW - w) / 2
+		y += (cellH - h) / 2
+		rects[i] = image.Rect(x, y, x+w, y+h)
+	}
+	rows := (len(sizes) + cols - 1) / cols
+	return rects, gap + rows*(cellH+gap)
+}
+
+// Compose draws the board width pixels wide. Images that can't be loaded
+// are left out and listed in missing.
+func (b Board) Compose(width int, load Loader) (img *image.RGBA, missing []string, err error) {
+	bg, err := b.BackgroundColor()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var images []image.Image
+	var sizes []image.Point
+	for _, path := range b.Images {
+		src, err := load(path)
+		if err != nil {
+			missing = append(missing, path)
+			continue
+		}
+		images = append(images, src)
+		sizes = append(sizes, src.Bounds().Size())
+	}
+
+	rects, height := b.Place(sizes, width)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+	for i, src := range images {
+		scaled := imaging.Resize(src, rects[i].Dx(), rects[i].Dy())
+		draw.Draw(dst, rects[i], scaled, image.Point{}, draw.Over)
+	}
+	return dst, missing, nil
+}
+
+// Export composes the board at full size and writes it to path as a PNG
+// or JPEG, going by the extension. The layout comes from the images'
+// headers, then each one is decoded only as big as its spot on the board
+// and dropped once drawn. An existing file is never overwritten.
+func (b Board) Export(path string) ([]string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+		return nil, fmt.Errorf("can't export to %q, use .png or .jpg", filepath.Base(path))
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists", filepath.Base(path))
+	}
+	bg, err := b.BackgroundColor()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths, missing []string
+	var sizes []image.Point
+	for _, p := range b.Images {
+		w, h, err := imaging.Size(p)
+		if err != nil {
+			missing = append(missing, p)
+			continue
+		}
+		paths = append(paths, p)
+		sizes = append(sizes, image.Pt(w, h))
+	}
+
+	rects, height := b.Place(sizes, b.Width)
+	dst := image.NewRGBA(image.Rect(0, 0, b.Width, height))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+	for i, p := range paths {
+		r := rects[i]
+		src, err := imaging.LoadSize(p, r.Dx(), r.Dy())
+		if err != nil {
+			missing = append(missing, p)
+			continue
+		}
+		draw.Draw(dst, r, imaging.Resize(src, r.Dx(), r.Dy()), image.Point{}, draw.Over)
+	}
+
+	// O_EXCL in case something turned up at path while composing
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return missing, fmt.Errorf("%s already exists", filepath.Base(path))
+	}
+	if err != nil {
+		return missing, err
+	}
+	if ext == ".png" {
+		err = png.Encode(f, dst)
+	} else {
+		err = jpeg.Encode(f, dst, &jpeg.Options{Quality: 92})
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return missing, err
+}
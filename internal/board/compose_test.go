@@ -0,0 +1,66 @@
+package board
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	b := New("test")
+	b.Width = 300
+	b.Columns = 2
+	b.Spacing = 10
+	b.Images = []string{filepath.Join(dir, "a.png"), filepath.Join(dir, "gone.png"), filepath.Join(dir, "b.png")}
+	writePNG(t, b.Images[0], 400, 300)
+	writePNG(t, b.Images[2], 300, 400)
+
+	out := filepath.Join(dir, "board.png")
+	missing, err := b.Export(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0] != b.Images[1] {
+		t.Errorf("got missing %v, want just gone.png", missing)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 300 {
+		t.Errorf("got width %d, want 300", img.Bounds().Dx())
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("got %v in the gap, want the white background", got)
+	}
+
+	if _, err := b.Export(out); err == nil {
+		t.Error("exporting over an existing file succeeded")
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type Config struct {
+	ShowHidden         bool     `json:"show_hidden"`
+	IgnorePatterns     []string `json:"ignore_patterns"`
+	RespectIgnoreFiles bool     `json:"respect_ignore_files"`
+	Icons              string   `json:"icons"` // nerd, ascii or none
+	Theme              string   `json:"theme"`
+	Background         string   `json:"background"`          // auto, dark or light
+	ImageBackend       string   `json:"image_backend"`       // auto, halfblock, kitty, sixel or iterm2
+	ThumbnailCacheMB   int      `json:"thumbnail_cache_mb"`  // 0 means unlimited
+	ThumbnailWorkers   int      `json:"thumbnail_workers"`   // 0 means one per CPU
+	SlideshowInterval  float64  `json:"slideshow_interval"`  // seconds per slide
+	DuplicateHash      string   `json:"duplicate_hash"`      // ahash, dhash or phash
+	DuplicateThreshold int      `json:"duplicate_threshold"` // bits two near duplicates can differ by
+}
+
+var DefaultConfig = Config{
+	ShowHidden:         false,
+	IgnorePatterns:     []string{},
+	RespectIgnoreFiles: true,
+	Icons:              "none",
+	Theme:              "dark",
+	Background:         "auto",
+	ImageBackend:       "auto",
+	ThumbnailCacheMB:   512,
+	SlideshowInterval:  5,
+	DuplicateHash:      "phash",
+	DuplicateThreshold: 10,
+}
+
+// Dir returns the photoboard config directory, usually ~/.config/photoboard
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".photoboard"
+	}
+	return filepath.Join(dir, "photoboard")
+}
+
+// Load reads config.json from the config directory. A missing file is not
+// an error and yields DefaultConfig.
+func Load() (Config, error) {
+	cfg := DefaultConfig
+
+	data, err := os.ReadFile(filepath.Join(Dir(), "config.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultConfig, err
+	}
+	return cfg, nil
+}
@@ -1,12 +1,62 @@
 package defs
 
-import "strings"
+import (
+	"io/fs"
+	"strings"
+)
+
+type GitStatus int
+
+// Ordered by precedence, so a directory can take the highest status of its
+// children.
+const (
+	GitClean GitStatus = iota
+	GitIgnored
+	GitUntracked
+	GitStaged
+	GitModified
+)
+
+func (s GitStatus) String() string {
+	switch s {
+	case GitIgnored:
+		return "ignored"
+	case GitUntracked:
+		return "untracked"
+	case GitStaged:
+		return "staged"
+	case GitModified:
+		return "modified"
+	}
+	return ""
+}
+
+// Flag is the pick/reject mark made while culling
+type Flag int
+
+const (
+	FlagNone Flag = iota
+	FlagPick
+	FlagReject
+)
+
+// Tags is what's been noted about a photo, kept in its XMP sidecar
+type Tags struct {
+	Rating   int    // 0 to 5 stars, 0 meaning unrated
+	Label    string // color label, e.g. "Red"
+	Flag     Flag
+	Keywords []string
+}
 
 type FileItem struct {
-	Filename string
-	Path     string
-	Modified string
-	IsDir    bool
+	Filename  string
+	Path      string
+	Modified  string
+	IsDir     bool
+	Mode      fs.FileMode
+	Orphan    bool // symlink whose target is missing
+	GitStatus GitStatus
+	Tags      Tags
 }
 
 func (f FileItem) Title() string {
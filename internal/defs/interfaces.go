@@ -5,7 +5,21 @@ import "github.com/charmbracelet/lipgloss"
 type Styler interface {
 	ColumnStyle() lipgloss.Style
 	ActiveColumnStyle() lipgloss.Style
+	HeaderStyle() lipgloss.Style
+	ItemStyle(item FileItem) lipgloss.Style
+	SelectedItemStyle() lipgloss.Style
+	NameStyle(item FileItem) lipgloss.Style
+	ItemIcon(item FileItem) string
+	GitStatusStyle(status GitStatus) lipgloss.Style
+	RatingStyle() lipgloss.Style
+	LabelStyle(label string) lipgloss.Style
+	FlagStyle(flag Flag) lipgloss.Style
+	StatusBarStyle() lipgloss.Style
+	MutedStyle() lipgloss.Style
 	FilePreviewStyle() lipgloss.Style
 	ImagePreviewStyle() lipgloss.Style
+	MetadataPanelStyle() lipgloss.Style
+	PreviewTitleStyle() lipgloss.Style
+	ChromaStyle() string
 	GetFrameSize() (width, height int)
 }
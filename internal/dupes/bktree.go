@@ -0,0 +1,61 @@
+package dupes
+
+import "github.com/nooooaaaaah/photoboard/internal/phash"
+
+// bkTree looks up hashes within a Hamming distance without comparing
+// against every one, so scanning a big library doesn't go quadratic. Each
+// child sits under the distance it has from its parent, and the triangle
+// inequality rules out whole branches.
+type bkTree struct {
+	root *bkNode
+}
+
+type bkNode struct {
+	hash     uint64
+	id       int
+	children map[int]*bkNode
+}
+
+func (t *bkTree) add(hash uint64, id int) {
+	if t.root == nil {
+		t.root = &bkNode{hash: hash, id: id}
+		return
+	}
+	node := t.root
+	for {
+		d := phash.Distance(hash, node.hash)
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{hash: hash, id: id}
+			return
+		}
+		node = child
+	}
+}
+
+// nearest finds the closest hash at most limit bits from hash, preferring
+// the one added first on a tie
+func (t *bkTree) nearest(hash uint64, limit int) (id, dist int, ok bool) {
+	if t.root == nil {
+		return 0, 0, false
+	}
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := phash.Distance(hash, node.hash)
+		if d <= limit && (!ok || d < dist || d == dist && node.id < id) {
+			id, dist, ok = node.id, d, true
+		}
+		for cd, child := range node.children {
+			if cd >= d-limit && cd <= d+limit {
+				stack = append(stack, child)
+			}
+		}
+	}
+	return id, dist, ok
+}
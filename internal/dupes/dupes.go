@@ -0,0 +1,277 @@
+// Package dupes finds exact and near duplicate images in a directory tree
+// from byte hashes and perceptual hashes
+package dupes
+
+import (
+	"context"
+	"crypto/sha256"
+	"image"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+	"github.com/nooooaaaaah/photoboard/internal/phash"
+)
+
+// hashSize is what images are decoded at for hashing. It's small enough
+// for an embedded preview to cover, which makes scans of raws quick.
+const hashSize = 256
+
+type Options struct {
+	// Hash is "ahash", "dhash" or "phash"
+	Hash string
+	// Threshold is the most bits two hashes can differ by and still count
+	// as near duplicates
+	Threshold int
+	Workers   int
+	// ShowHidden also scans dot directories
+	ShowHidden bool
+	// Progress, if set, is called with the count of hashed images
+	Progress func(done, total int)
+}
+
+// Image is one scanned file
+type Image struct {
+	Path          string
+	Size          int64
+	Width, Height int
+	Sum           [sha256.Size]byte
+	Hashes        phash.Hashes
+	// Perceptual is false for images that couldn't be decoded, which only
+	// match exact copies
+	Perceptual bool
+}
+
+// Pixels is the image's area, for ranking copies
+func (img Image) Pixels() int {
+	return img.Width * img.Height
+}
+
+// Group is a set of images that are copies of each other, best first
+type Group struct {
+	Images []Image
+	// Exact means every file has the same bytes
+	Exact bool
+	// Distance is the furthest any image's hash is from the first one's
+	Distance int
+}
+
+// Scan hashes every image under root and groups the duplicates, exact
+// copies first and then near ones, biggest groups first
+func Scan(ctx context.Context, root string, opts Options) ([]Group, error) {
+	paths, err := imagesUnder(ctx, root, opts.ShowHidden)
+	if err != nil {
+		return nil, err
+	}
+	images, err := hashAll(ctx, paths, opts)
+	if err != nil {
+		return nil, err
+	}
+	return group(images, opts), nil
+}
+
+func imagesUnder(ctx context.Context, root string, showHidden bool) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped, not fatal
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path != root && !showHidden && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && imaging.IsImage(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func hashAll(ctx context.Context, paths []string, opts Options) ([]Image, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	images := make([]Image, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				images[i] = hash(paths[i])
+				if opts.Progress != nil {
+					mu.Lock()
+					done++
+					opts.Progress(done, len(paths))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range paths {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Files that vanished or couldn't be read drop out
+	out := images[:0]
+	for _, img := range images {
+		if img.Path != "" {
+			out = append(out, img)
+		}
+	}
+	return out, nil
+}
+
+func hash(path string) Image {
+	f, err := os.Open(path)
+	if err != nil {
+		return Image{}
+	}
+	defer f.Close()
+
+	img := Image{Path: path}
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return Image{}
+	}
+	img.Size = n
+	copy(img.Sum[:], h.Sum(nil))
+
+	if _, err := f.Seek(0, io.SeekStart); err == nil {
+		if cfg, _, err := image.DecodeConfig(f); err == nil {
+			img.Width, img.Height = cfg.Width, cfg.Height
+		}
+	}
+	if decoded, err := imaging.LoadSize(path, hashSize, hashSize); err == nil {
+		img.Hashes = phash.Compute(decoded)
+		img.Perceptual = true
+		if img.Width == 0 {
+			b := decoded.Bounds()
+			img.Width, img.Height = b.Dx(), b.Dy()
+		}
+	}
+	return img
+}
+
+// group puts exact copies together, and near duplicates with the best
+// image that's within the threshold of them. Matching against that one
+// image, rather than chaining, keeps a run of small edits from pulling
+// unrelated pictures into one group.
+func group(images []Image, opts Options) []Group {
+	// Best first, so every group forms around the copy worth keeping
+	sort.Slice(images, func(i, j int) bool {
+		return better(images[i], images[j])
+	})
+
+	// The best of each set of exact copies stands in for the rest
+	first := make(map[[sha256.Size]byte]int)
+	for i, img := range images {
+		if _, ok := first[img.Sum]; !ok {
+			first[img.Sum] = i
+		}
+	}
+
+	rep := make(map[int]int)
+	var tree bkTree
+	for i, img := range images {
+		if first[img.Sum] != i {
+			continue
+		}
+		rep[i] = i
+		if !img.Perceptual {
+			continue
+		}
+		hash := img.Hashes.Get(opts.Hash)
+		if r, _, ok := tree.nearest(hash, opts.Threshold); ok {
+			rep[i] = r
+		} else {
+			tree.add(hash, i)
+		}
+	}
+
+	members := make(map[int][]Image)
+	var reps []int
+	for _, img := range images {
+		r := rep[first[img.Sum]]
+		if _, ok := members[r]; !ok {
+			reps = append(reps, r)
+		}
+		members[r] = append(members[r], img)
+	}
+
+	var groups []Group
+	for _, r := range reps {
+		imgs := members[r]
+		if len(imgs) < 2 {
+			continue
+		}
+		g := Group{Images: imgs, Exact: true}
+		best := images[r]
+		for _, img := range imgs[1:] {
+			if img.Sum != best.Sum {
+				g.Exact = false
+			}
+			if img.Perceptual && best.Perceptual {
+				g.Distance = max(g.Distance, phash.Distance(img.Hashes.Get(opts.Hash), best.Hashes.Get(opts.Hash)))
+			}
+		}
+		groups = append(groups, g)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Exact != groups[j].Exact {
+			return groups[i].Exact
+		}
+		if len(groups[i].Images) != len(groups[j].Images) {
+			return len(groups[i].Images) > len(groups[j].Images)
+		}
+		return groups[i].Images[0].Path < groups[j].Images[0].Path
+	})
+	return groups
+}
+
+// better ranks copies: the biggest, then the largest file, is the one to
+// keep
+func better(a, b Image) bool {
+	if a.Pixels() != b.Pixels() {
+		return a.Pixels() > b.Pixels()
+	}
+	if a.Size != b.Size {
+		return a.Size > b.Size
+	}
+	return a.Path < b.Path
+}
@@ -0,0 +1,100 @@
+package dupes
+
+import (
+	"crypto/sha256"
+	"math/rand"
+	"testing"
+
+	"github.com/nooooaaaaah/photoboard/internal/phash"
+)
+
+func img(path string, pixels int, hash uint64) Image {
+	return Image{
+		Path:       path,
+		Width:      pixels,
+		Height:     1,
+		Sum:        sha256.Sum256([]byte(path)),
+		Hashes:     phash.Hashes{P: hash},
+		Perceptual: true,
+	}
+}
+
+func paths(g Group) []string {
+	var out []string
+	for _, img := range g.Images {
+		out = append(out, img.Path)
+	}
+	return out
+}
+
+func TestGroupDoesNotChain(t *testing.T) {
+	// b is 4 bits from a and c is 4 from b, but c is 8 from a
+	a := img("a", 300, 0)
+	b := img("b", 200, 0xF)
+	c := img("c", 100, 0xFF)
+
+	groups := group([]Image{c, b, a}, Options{Hash: "phash", Threshold: 5})
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	if got := paths(groups[0]); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("got group %v, want [a b]", got)
+	}
+	if groups[0].Distance != 4 || groups[0].Exact {
+		t.Errorf("got distance %d exact %v, want 4 false", groups[0].Distance, groups[0].Exact)
+	}
+}
+
+func TestGroupExactCopies(t *testing.T) {
+	a := img("a", 100, 0)
+	copyA := a
+	copyA.Path = "copy"
+	undecodable := Image{Path: "raw", Sum: sha256.Sum256([]byte("raw"))}
+	undecodableCopy := undecodable
+	undecodableCopy.Path = "raw copy"
+	other := img("other", 100, ^uint64(0))
+
+	groups := group([]Image{a, undecodable, other, copyA, undecodableCopy}, Options{Hash: "phash", Threshold: 10})
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	for _, g := range groups {
+		if !g.Exact || len(g.Images) != 2 {
+			t.Errorf("got %v exact %v, want a pair of exact copies", paths(g), g.Exact)
+		}
+	}
+}
+
+func TestGroupBestFirst(t *testing.T) {
+	small := img("small", 100, 1)
+	big := img("big", 400, 0)
+	groups := group([]Image{small, big}, Options{Hash: "phash", Threshold: 2})
+	if len(groups) != 1 || groups[0].Images[0].Path != "big" {
+		t.Fatalf("got %v, want big first", groups)
+	}
+}
+
+func TestBKTreeMatchesLinearSearch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var hashes []uint64
+	var tree bkTree
+	for i := 0; i < 500; i++ {
+		h := r.Uint64()
+		hashes = append(hashes, h)
+		tree.add(h, i)
+	}
+
+	for q := 0; q < 200; q++ {
+		query := hashes[r.Intn(len(hashes))] ^ (1 << uint(r.Intn(64))) ^ (1 << uint(r.Intn(64)))
+		wantID, wantDist, wantOK := 0, 0, false
+		for i, h := range hashes {
+			if d := phash.Distance(query, h); d <= 12 && (!wantOK || d < wantDist) {
+				wantID, wantDist, wantOK = i, d, true
+			}
+		}
+		id, dist, ok := tree.nearest(query, 12)
+		if ok != wantOK || dist != wantDist || id != wantID {
+			t.Fatalf("query %x: got %d %d %v, want %d %d %v", query, id, dist, ok, wantID, wantDist, wantOK)
+		}
+	}
+}
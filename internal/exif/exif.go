@@ -0,0 +1,352 @@
+// Package exif reads the TIFF structure inside JPEG APP1 segments, PNG eXIf
+// chunks and TIFF-based camera raws, plus the few HEIF boxes that play the
+// same role. It only pulls out what photoboard needs and never loads pixel
+// data it doesn't have to.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrNoExif = errors.New("no exif data")
+
+// Tags photoboard looks at
+const (
+	TagMake               = 0x010f
+	TagModel              = 0x0110
+	TagOrientation        = 0x0112
+	TagSoftware           = 0x0131
+	TagDateTime           = 0x0132
+	TagXMP                = 0x02bc
+	TagExposureTime       = 0x829a
+	TagFNumber            = 0x829d
+	TagISO                = 0x8827
+	TagDateTimeOriginal   = 0x9003
+	TagOffsetTimeOriginal = 0x9011
+	TagFocalLength        = 0x920a
+	TagFocalLength35mm    = 0xa405
+	TagLensMake           = 0xa433
+	TagLensModel          = 0xa434
+	TagGPSLatitudeRef     = 0x0001
+	TagGPSLatitude        = 0x0002
+	TagGPSLongitudeRef    = 0x0003
+	TagGPSLongitude       = 0x0004
+	TagGPSAltitudeRef     = 0x0005
+	TagGPSAltitude        = 0x0006
+
+	TagCompression      = 0x0103
+	TagStripOffsets     = 0x0111
+	TagStripByteCounts  = 0x0117
+	TagSubIFDs          = 0x014a
+	TagJPEGOffset       = 0x0201
+	TagJPEGLength       = 0x0202
+	TagExifIFD          = 0x8769
+	TagGPSIFD           = 0x8825
+	TagPixelXDimension  = 0xa002
+	TagPixelYDimension  = 0xa003
+	compressionJPEG     = 6
+	compressionJPEGNew  = 7
+	maxValueSize        = 1 << 20
+	maxIFDs             = 64
+	typeByte, typeASCII = 1, 2
+	typeShort, typeLong = 3, 4
+	typeRational        = 5
+	typeSRational       = 10
+)
+
+var typeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4,
+}
+
+// Entry is one IFD field with its value bytes still in file byte order
+type Entry struct {
+	Tag   uint16
+	Type  uint16
+	Count uint32
+	Value []byte
+	order binary.ByteOrder
+}
+
+// Uint returns the i-th value of an integer field
+func (e Entry) Uint(i int) (uint32, bool) {
+	if i < 0 || uint32(i) >= e.Count {
+		return 0, false
+	}
+	switch e.Type {
+	case typeByte, 7:
+		return uint32(e.Value[i]), true
+	case typeShort:
+		return uint32(e.order.Uint16(e.Value[i*2:])), true
+	case typeLong, 13:
+		return e.order.Uint32(e.Value[i*4:]), true
+	}
+	return 0, false
+}
+
+// Rational returns the i-th value of a RATIONAL or SRATIONAL field
+func (e Entry) Rational(i int) (num, den int64, ok bool) {
+	if i < 0 || uint32(i) >= e.Count {
+		return 0, 0, false
+	}
+	switch e.Type {
+	case typeRational:
+		return int64(e.order.Uint32(e.Value[i*8:])), int64(e.order.Uint32(e.Value[i*8+4:])), true
+	case typeSRational:
+		return int64(int32(e.order.Uint32(e.Value[i*8:]))), int64(int32(e.order.Uint32(e.Value[i*8+4:]))), true
+	}
+	return 0, 0, false
+}
+
+// Float returns the i-th value of a numeric field as a float
+func (e Entry) Float(i int) (float64, bool) {
+	if num, den, ok := e.Rational(i); ok {
+		if den == 0 {
+			return 0, false
+		}
+		return float64(num) / float64(den), true
+	}
+	v, ok := e.Uint(i)
+	return float64(v), ok
+}
+
+// String returns an ASCII field up to its terminating NUL
+func (e Entry) String() string {
+	v := e.Value
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		v = v[:i]
+	}
+	return string(bytes.TrimRight(v, " "))
+}
+
+// IFD is one image file directory
+type IFD map[uint16]Entry
+
+// Data is the parsed TIFF structure. IFDs is the main chain (IFD0, IFD1
+// and, in some raws, more); SubIFDs holds the ones hanging off IFD0.
+type Data struct {
+	IFDs    []IFD
+	SubIFDs []IFD
+	Exif    IFD
+	GPS     IFD
+
+	r     io.ReaderAt
+	base  int64
+	order binary.ByteOrder
+	seen  map[uint32]bool
+}
+
+// Decode finds and parses EXIF in a JPEG, PNG, HEIF or TIFF-based file
+func Decode(r io.ReaderAt) (*Data, error) {
+	var head [16]byte
+	if _, err := r.ReadAt(head[:], 0); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case head[0] == 0xff && head[1] == 0xd8:
+		seg, err := findJPEGSegment(r, 0xe1, []byte("Exif\x00\x00"))
+		if err != nil {
+			return nil, err
+		}
+		return parse(r, seg.offset+6)
+	case isTIFF(head[:4]):
+		return parse(r, 0)
+	case string(head[4:8]) == "ftyp":
+		off, ok := heifExif(r)
+		if !ok {
+			return nil, ErrNoExif
+		}
+		return parse(r, off)
+	case bytes.HasPrefix(head[:], pngSignature):
+		off, err := findPNGChunk(r, "eXIf")
+		if err != nil {
+			return nil, err
+		}
+		return parse(r, off)
+	}
+	return nil, ErrNoExif
+}
+
+// isTIFF accepts plain TIFF plus the Olympus and Panasonic variants, which
+// only differ in the magic number
+func isTIFF(b []byte) bool {
+	switch string(b) {
+	case "II*\x00", "MM\x00*", "IIRO", "IIRS", "IIU\x00":
+		return true
+	}
+	return false
+}
+
+func parse(r io.ReaderAt, base int64) (*Data, error) {
+	var hdr [8]byte
+	if _, err := r.ReadAt(hdr[:], base); err != nil {
+		return nil, ErrNoExif
+	}
+
+	d := &Data{r: r, base: base, seen: make(map[uint32]bool)}
+	switch string(hdr[:2]) {
+	case "II":
+		d.order = binary.LittleEndian
+	case "MM":
+		d.order = binary.BigEndian
+	default:
+		return nil, ErrNoExif
+	}
+
+	next := d.order.Uint32(hdr[4:])
+	for next != 0 && len(d.IFDs) < maxIFDs {
+		ifd, n, err := d.readIFD(next)
+		if err != nil {
+			break
+		}
+		d.IFDs = append(d.IFDs, ifd)
+		next = n
+	}
+	if len(d.IFDs) == 0 {
+		return nil, ErrNoExif
+	}
+
+	ifd0 := d.IFDs[0]
+	if e, ok := ifd0[TagSubIFDs]; ok {
+		for i := 0; i < int(e.Count) && len(d.SubIFDs) < maxIFDs; i++ {
+			off, _ := e.Uint(i)
+			if sub, _, err := d.readIFD(off); err == nil {
+				d.SubIFDs = append(d.SubIFDs, sub)
+			}
+		}
+	}
+	d.Exif = d.pointer(ifd0, TagExifIFD)
+	d.GPS = d.pointer(ifd0, TagGPSIFD)
+	return d, nil
+}
+
+func (d *Data) pointer(ifd IFD, tag uint16) IFD {
+	e, ok := ifd[tag]
+	if !ok {
+		return nil
+	}
+	off, _ := e.Uint(0)
+	sub, _, err := d.readIFD(off)
+	if err != nil {
+		return nil
+	}
+	return sub
+}
+
+// readIFD reads the directory at off (relative to the TIFF header) and
+// returns it with the offset of the next one. Offsets that were already
+// visited are rejected so broken files can't loop.
+func (d *Data) readIFD(off uint32) (IFD, uint32, error) {
+	if off == 0 || d.seen[off] {
+		return nil, 0, ErrNoExif
+	}
+	d.seen[off] = true
+
+	var cnt [2]byte
+	if _, err := d.r.ReadAt(cnt[:], d.base+int64(off)); err != nil {
+		return nil, 0, err
+	}
+	n := int(d.order.Uint16(cnt[:]))
+
+	buf := make([]byte, n*12+4)
+	if _, err := d.r.ReadAt(buf, d.base+int64(off)+2); err != nil {
+		return nil, 0, err
+	}
+
+	ifd := make(IFD, n)
+	for i := 0; i < n; i++ {
+		raw := buf[i*12 : i*12+12]
+		e := Entry{
+			Tag:   d.order.Uint16(raw),
+			Type:  d.order.Uint16(raw[2:]),
+			Count: d.order.Uint32(raw[4:]),
+			order: d.order,
+		}
+		size, ok := typeSizes[e.Type]
+		if !ok || uint64(size)*uint64(e.Count) > maxValueSize {
+			continue
+		}
+		total := size * e.Count
+		if total <= 4 {
+			e.Value = append([]byte(nil), raw[8:8+total]...)
+		} else {
+			e.Value = make([]byte, total)
+			if _, err := d.r.ReadAt(e.Value, d.base+int64(d.order.Uint32(raw[8:]))); err != nil {
+				continue
+			}
+		}
+		ifd[e.Tag] = e
+	}
+	return ifd, d.order.Uint32(buf[n*12:]), nil
+}
+
+// Section is a byte range of the file holding an embedded image
+type Section struct {
+	Offset int64
+	Length int64
+}
+
+// Reader returns a reader over the section
+func (s Section) Reader(r io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(r, s.Offset, s.Length)
+}
+
+// Previews lists every embedded JPEG: the IFD1 thumbnail of a JPEG, and the
+// preview and full-size JPEGs raws keep in IFD0 and their SubIFDs. They
+// aren't decoded, so the caller can pick by size with image.DecodeConfig.
+func (d *Data) Previews() []Section {
+	var out []Section
+	for _, ifd := range append(append([]IFD{}, d.IFDs...), d.SubIFDs...) {
+		if s, ok := d.preview(ifd); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (d *Data) preview(ifd IFD) (Section, bool) {
+	off, okOff := ifd[TagJPEGOffset].Uint(0)
+	n, okLen := ifd[TagJPEGLength].Uint(0)
+	if !okOff || !okLen {
+		comp, _ := ifd[TagCompression].Uint(0)
+		if comp != compressionJPEG && comp != compressionJPEGNew || ifd[TagStripOffsets].Count != 1 {
+			return Section{}, false
+		}
+		off, okOff = ifd[TagStripOffsets].Uint(0)
+		n, okLen = ifd[TagStripByteCounts].Uint(0)
+		if !okOff || !okLen {
+			return Section{}, false
+		}
+	}
+	if n == 0 {
+		return Section{}, false
+	}
+	return Section{Offset: d.base + int64(off), Length: int64(n)}, true
+}
+
+// Orientation returns the Orientation tag of IFD0
+func (d *Data) Orientation() (int, bool) {
+	v, ok := d.IFDs[0][TagOrientation].Uint(0)
+	if !ok || v < 1 || v > 8 {
+		return 1, false
+	}
+	return int(v), true
+}
+
+// XMP returns the XMP packet TIFF files keep in IFD0, if there is one
+func (d *Data) XMP() []byte {
+	return d.IFDs[0][TagXMP].Value
+}
+
+// Dimensions returns the pixel size recorded in the Exif IFD, if any
+func (d *Data) Dimensions() (int, int, bool) {
+	w, okW := d.Exif[TagPixelXDimension].Uint(0)
+	h, okH := d.Exif[TagPixelYDimension].Uint(0)
+	if !okW || !okH || w == 0 || h == 0 {
+		return 0, 0, false
+	}
+	return int(w), int(h), true
+}
@@ -0,0 +1,333 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// field is an IFD entry for buildTIFF. ifd, when set, makes it a LONG
+// pointing at that IFD (1-based).
+type field struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+	ifd   int
+}
+
+func short(order binary.ByteOrder, v uint16) field {
+	b := make([]byte, 2)
+	order.PutUint16(b, v)
+	return field{typ: typeShort, count: 1, value: b}
+}
+
+func long(order binary.ByteOrder, v uint32) field {
+	b := make([]byte, 4)
+	order.PutUint32(b, v)
+	return field{typ: typeLong, count: 1, value: b}
+}
+
+func ascii(s string) field {
+	return field{typ: typeASCII, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func rational(order binary.ByteOrder, num, den uint32) field {
+	b := make([]byte, 8)
+	order.PutUint32(b, num)
+	order.PutUint32(b[4:], den)
+	return field{typ: typeRational, count: 1, value: b}
+}
+
+func tagged(tag uint16, f field) field {
+	f.tag = tag
+	return f
+}
+
+// buildTIFF lays out a TIFF header and ifds one after another, each with
+// its out-of-line values after it. The first chained IFDs form the main
+// chain; the rest are only reachable through pointer fields.
+func buildTIFF(order binary.ByteOrder, chained int, ifds ...[]field) []byte {
+	offsets := make([]uint32, len(ifds))
+	off := uint32(8)
+	for i, fields := range ifds {
+		offsets[i] = off
+		off += 2 + 12*uint32(len(fields)) + 4
+		for _, f := range fields {
+			if len(f.value) > 4 {
+				off += uint32(len(f.value)+1) &^ 1
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II*\x00")
+	} else {
+		buf.WriteString("MM\x00*")
+	}
+	binary.Write(&buf, order, offsets[0])
+
+	for i, fields := range ifds {
+		var values bytes.Buffer
+		valuesAt := offsets[i] + 2 + 12*uint32(len(fields)) + 4
+		binary.Write(&buf, order, uint16(len(fields)))
+		for _, f := range fields {
+			if f.ifd > 0 {
+				f = tagged(f.tag, long(order, offsets[f.ifd-1]))
+			}
+			binary.Write(&buf, order, f.tag)
+			binary.Write(&buf, order, f.typ)
+			binary.Write(&buf, order, f.count)
+			if len(f.value) <= 4 {
+				var inline [4]byte
+				copy(inline[:], f.value)
+				buf.Write(inline[:])
+				continue
+			}
+			binary.Write(&buf, order, valuesAt+uint32(values.Len()))
+			values.Write(f.value)
+			if values.Len()%2 == 1 {
+				values.WriteByte(0)
+			}
+		}
+		next := uint32(0)
+		if i+1 < chained {
+			next = offsets[i+1]
+		}
+		binary.Write(&buf, order, next)
+		buf.Write(values.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// wrapJPEG puts a TIFF structure in the APP1 segment of a bare JPEG
+func wrapJPEG(tiff []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	// An APP0 first, so the segment walk has something to skip
+	buf.Write([]byte{0xff, 0xe0, 0, 7})
+	buf.WriteString("JFIF\x00")
+	buf.Write([]byte{0xff, 0xe1})
+	binary.Write(&buf, binary.BigEndian, uint16(2+6+len(tiff)))
+	buf.WriteString("Exif\x00\x00")
+	buf.Write(tiff)
+	buf.Write([]byte{0xff, 0xda, 0, 2, 0xff, 0xd9})
+	return buf.Bytes()
+}
+
+func camera(order binary.ByteOrder) []byte {
+	return buildTIFF(order, 2,
+		[]field{
+			tagged(TagMake, ascii("Fujifilm")),
+			tagged(TagOrientation, short(order, 6)),
+			{tag: TagExifIFD, ifd: 3},
+		},
+		[]field{
+			tagged(TagCompression, short(order, compressionJPEG)),
+			tagged(TagJPEGOffset, long(order, 1000)),
+			tagged(TagJPEGLength, long(order, 200)),
+		},
+		[]field{
+			tagged(TagExposureTime, rational(order, 1, 250)),
+			tagged(TagPixelXDimension, long(order, 6000)),
+			tagged(TagPixelYDimension, short(order, 4000)),
+		},
+	)
+}
+
+func TestDecode(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		tiff := camera(order)
+		files := map[string]struct {
+			data []byte
+			base int64
+		}{
+			"tiff": {tiff, 0},
+			"jpeg": {wrapJPEG(tiff), 2 + 9 + 4 + 6},
+		}
+		for kind, file := range files {
+			name := order.String() + " " + kind
+			d, err := Decode(bytes.NewReader(file.data))
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if len(d.IFDs) != 2 {
+				t.Errorf("%s: got %d IFDs, want 2", name, len(d.IFDs))
+			}
+			if got := d.IFDs[0][TagMake].String(); got != "Fujifilm" {
+				t.Errorf("%s: make %q", name, got)
+			}
+			if o, ok := d.Orientation(); o != 6 || !ok {
+				t.Errorf("%s: orientation %d %v, want 6", name, o, ok)
+			}
+			if num, den, _ := d.Exif[TagExposureTime].Rational(0); num != 1 || den != 250 {
+				t.Errorf("%s: exposure %d/%d, want 1/250", name, num, den)
+			}
+			if w, h, ok := d.Dimensions(); w != 6000 || h != 4000 || !ok {
+				t.Errorf("%s: dimensions %dx%d %v", name, w, h, ok)
+			}
+			previews := d.Previews()
+			if want := (Section{Offset: file.base + 1000, Length: 200}); len(previews) != 1 || previews[0] != want {
+				t.Errorf("%s: previews %v, want %v", name, previews, want)
+			}
+		}
+	}
+}
+
+func TestDecodeBrokenFiles(t *testing.T) {
+	le := binary.LittleEndian
+	good := buildTIFF(le, 1, []field{tagged(TagOrientation, short(le, 3))})
+
+	// IFD0 pointing past the end of the file
+	farIFD := append([]byte(nil), good...)
+	le.PutUint32(farIFD[4:], 1<<30)
+
+	// An entry whose value lies past the end of the file
+	farValue := buildTIFF(le, 1, []field{
+		tagged(TagOrientation, short(le, 8)),
+		tagged(TagMake, ascii("a long make string")),
+	})
+	le.PutUint32(farValue[8+2+12+8:], 1<<30)
+
+	// IFD0 claims more entries than there are bytes
+	truncated := append([]byte(nil), good[:8+2+6]...)
+
+	// IFD0 says the next IFD is itself
+	loop := append([]byte(nil), good...)
+	le.PutUint32(loop[8+2+12:], 8)
+
+	// A count that would need gigabytes
+	huge := buildTIFF(le, 1, []field{tagged(TagOrientation, short(le, 2)), {tag: TagMake, typ: typeASCII, count: 1 << 31, value: make([]byte, 4)}})
+
+	tests := []struct {
+		name        string
+		data        []byte
+		ok          bool
+		orientation int
+		fields      int
+	}{
+		{"empty", nil, false, 0, 0},
+		{"header only", good[:8], false, 0, 0},
+		{"bad byte order", append([]byte("XX"), good[2:]...), false, 0, 0},
+		{"IFD out of range", farIFD, false, 0, 0},
+		{"value out of range", farValue, true, 8, 1},
+		{"truncated IFD", truncated, false, 0, 0},
+		{"truncated JPEG", wrapJPEG(good)[:30], false, 0, 0},
+		{"IFD loop", loop, true, 3, 1},
+		{"huge count", huge, true, 2, 1},
+	}
+	for _, tt := range tests {
+		d, err := Decode(bytes.NewReader(tt.data))
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("%s: decoded without an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(d.IFDs) != 1 || len(d.IFDs[0]) != tt.fields {
+			t.Errorf("%s: got %d IFDs, IFD0 with %d fields, want 1 with %d", tt.name, len(d.IFDs), len(d.IFDs[0]), tt.fields)
+		}
+		if o, _ := d.Orientation(); o != tt.orientation {
+			t.Errorf("%s: orientation %d, want %d", tt.name, o, tt.orientation)
+		}
+	}
+}
+
+func TestDecodePNG(t *testing.T) {
+	le := binary.LittleEndian
+	tiff := buildTIFF(le, 1, []field{tagged(TagOrientation, short(le, 5))})
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	for _, chunk := range []struct {
+		typ  string
+		data []byte
+	}{{"IHDR", make([]byte, 13)}, {"eXIf", tiff}, {"IEND", nil}} {
+		binary.Write(&buf, binary.BigEndian, uint32(len(chunk.data)))
+		buf.WriteString(chunk.typ)
+		buf.Write(chunk.data)
+		buf.Write(make([]byte, 4))
+	}
+
+	d, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, _ := d.Orientation(); o != 5 {
+		t.Errorf("orientation %d, want 5", o)
+	}
+}
+
+func TestJFIFThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8, 0xff, 0xe0, 0, 2 + 6 + 10})
+	buf.WriteString("JFXX\x00\x10")
+	buf.Write(make([]byte, 10))
+	buf.Write([]byte{0xff, 0xda})
+
+	s, ok := JFIFThumbnail(bytes.NewReader(buf.Bytes()))
+	if want := (Section{Offset: 12, Length: 10}); !ok || s != want {
+		t.Errorf("got %v %v, want %v", s, ok, want)
+	}
+	if _, ok := JFIFThumbnail(bytes.NewReader(wrapJPEG(camera(binary.BigEndian)))); ok {
+		t.Error("found a JFXX thumbnail in a file without one")
+	}
+}
+
+func TestRAFPreview(t *testing.T) {
+	data := make([]byte, 100)
+	copy(data, "FUJIFILMCCD-RAW 0201")
+	binary.BigEndian.PutUint32(data[84:], 148)
+	binary.BigEndian.PutUint32(data[88:], 5000)
+
+	s, ok := RAFPreview(bytes.NewReader(data))
+	if want := (Section{Offset: 148, Length: 5000}); !ok || s != want {
+		t.Errorf("got %v %v, want %v", s, ok, want)
+	}
+	if _, ok := RAFPreview(bytes.NewReader(data[:50])); ok {
+		t.Error("found a preview in a truncated header")
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		f.Add(camera(order))
+		f.Add(wrapJPEG(camera(order)))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			if d != nil {
+				t.Error("got data with an error")
+			}
+			return
+		}
+		if len(d.IFDs) == 0 {
+			t.Fatal("no error but no IFDs")
+		}
+		d.Orientation()
+		d.Dimensions()
+		d.XMP()
+		for _, ifd := range append(append([]IFD{d.Exif, d.GPS}, d.IFDs...), d.SubIFDs...) {
+			for _, e := range ifd {
+				for i := 0; i <= int(min(e.Count, 4)); i++ {
+					e.Uint(i)
+					e.Float(i)
+				}
+				_ = e.String()
+			}
+		}
+		for _, s := range d.Previews() {
+			if s.Length <= 0 {
+				t.Errorf("empty preview %v", s)
+			}
+		}
+		JFIFThumbnail(bytes.NewReader(data))
+		RAFPreview(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,322 @@
+package exif
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// heifHeadSize bounds how much of a HEIF file is read looking for the meta
+// box, which sits right after ftyp
+const heifHeadSize = 1 << 20
+
+// HEIFOrientation turns the irot and imir properties of a HEIF/AVIF
+// primary image into the equivalent EXIF orientation. HEIF applies them
+// in that order: rotate anti-clockwise, then mirror.
+func HEIFOrientation(r io.ReaderAt) (int, bool) {
+	var ftyp [8]byte
+	if _, err := r.ReadAt(ftyp[:], 0); err != nil || string(ftyp[4:]) != "ftyp" {
+		return 1, false
+	}
+
+	head := make([]byte, heifHeadSize)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	meta, ok := findBox(head, "meta")
+	if !ok || len(meta) < 4 {
+		return 1, false
+	}
+	// meta is a full box: version and flags come before its children
+	meta = meta[4:]
+
+	primary := uint32(0)
+	if pitm, ok := findBox(meta, "pitm"); ok && len(pitm) >= 6 {
+		if pitm[0] == 0 {
+			primary = uint32(binary.BigEndian.Uint16(pitm[4:]))
+		} else if len(pitm) >= 8 {
+			primary = binary.BigEndian.Uint32(pitm[4:])
+		}
+	}
+
+	iprp, ok := findBox(meta, "iprp")
+	if !ok {
+		return 1, false
+	}
+	ipco, ok := findBox(iprp, "ipco")
+	if !ok {
+		return 1, false
+	}
+	ipma, _ := findBox(iprp, "ipma")
+
+	props := childBoxes(ipco)
+	rotation, mirror := 0, -1
+	for _, index := range associations(ipma, primary) {
+		if index < 1 || index > len(props) {
+			continue
+		}
+		p := props[index-1]
+		switch {
+		case p.typ == "irot" && len(p.data) >= 1:
+			rotation = int(p.data[0] & 3)
+		case p.typ == "imir" && len(p.data) >= 1:
+			mirror = int(p.data[0] & 1)
+		}
+	}
+	if rotation == 0 && mirror < 0 {
+		return 1, false
+	}
+
+	// Orientation after rotating 0, 90, 180 and 270 degrees anti-clockwise,
+	// then the same followed by a left-right or top-bottom mirror
+	rotated := [4]int{1, 8, 3, 6}
+	flippedLR := [4]int{2, 7, 4, 5}
+	flippedTB := [4]int{4, 5, 2, 7}
+	switch mirror {
+	case 0:
+		return flippedLR[rotation], true
+	case 1:
+		return flippedTB[rotation], true
+	}
+	return rotated[rotation], true
+}
+
+// heifExif finds the TIFF header of the Exif item in a HEIF file
+func heifExif(r io.ReaderAt) (int64, bool) {
+	var ftyp [8]byte
+	if _, err := r.ReadAt(ftyp[:], 0); err != nil || string(ftyp[4:]) != "ftyp" {
+		return 0, false
+	}
+	head := make([]byte, heifHeadSize)
+	n, _ := r.ReadAt(head, 0)
+
+	meta, ok := findBox(head[:n], "meta")
+	if !ok || len(meta) < 4 {
+		return 0, false
+	}
+	meta = meta[4:]
+	iinf, okInf := findBox(meta, "iinf")
+	iloc, okLoc := findBox(meta, "iloc")
+	if !okInf || !okLoc {
+		return 0, false
+	}
+
+	id, ok := exifItem(iinf)
+	if !ok {
+		return 0, false
+	}
+	off, ok := itemOffset(iloc, id)
+	if !ok {
+		return 0, false
+	}
+
+	// The item starts with the offset from its payload to the TIFF header
+	var skip [4]byte
+	if _, err := r.ReadAt(skip[:], off); err != nil {
+		return 0, false
+	}
+	return off + 4 + int64(binary.BigEndian.Uint32(skip[:])), true
+}
+
+// exifItem returns the id of the item of type "Exif" listed in iinf
+func exifItem(iinf []byte) (uint32, bool) {
+	if len(iinf) < 6 {
+		return 0, false
+	}
+	entries := iinf[6:]
+	if iinf[0] != 0 {
+		if len(iinf) < 8 {
+			return 0, false
+		}
+		entries = iinf[8:]
+	}
+	for _, b := range childBoxes(entries) {
+		if b.typ != "infe" || len(b.data) < 4 {
+			continue
+		}
+		version, d := b.data[0], b.data[4:]
+		switch {
+		case version == 2 && len(d) >= 8 && string(d[4:8]) == "Exif":
+			return uint32(binary.BigEndian.Uint16(d)), true
+		case version == 3 && len(d) >= 10 && string(d[6:10]) == "Exif":
+			return binary.BigEndian.Uint32(d), true
+		}
+	}
+	return 0, false
+}
+
+// itemOffset returns where the first extent of item starts, for items
+// stored directly in the file
+func itemOffset(iloc []byte, item uint32) (int64, bool) {
+	if len(iloc) < 8 {
+		return 0, false
+	}
+	version := iloc[0]
+	offsetSize, lengthSize := int(iloc[4]>>4), int(iloc[4]&15)
+	baseSize, indexSize := int(iloc[5]>>4), int(iloc[5]&15)
+	if version == 0 {
+		indexSize = 0
+	}
+	d := iloc[6:]
+
+	read := func(n int) (uint64, bool) {
+		if n > len(d) {
+			return 0, false
+		}
+		var v uint64
+		for _, c := range d[:n] {
+			v = v<<8 | uint64(c)
+		}
+		d = d[n:]
+		return v, true
+	}
+
+	idSize := 2
+	if version >= 2 {
+		idSize = 4
+	}
+	count, ok := read(idSize)
+	if !ok {
+		return 0, false
+	}
+	for i := uint64(0); i < count; i++ {
+		id, ok := read(idSize)
+		if !ok {
+			return 0, false
+		}
+		method := uint64(0)
+		if version == 1 || version == 2 {
+			if method, ok = read(2); !ok {
+				return 0, false
+			}
+			method &= 15
+		}
+		if _, ok = read(2); !ok {
+			return 0, false
+		}
+		base, ok := read(baseSize)
+		if !ok {
+			return 0, false
+		}
+		extents, ok := read(2)
+		if !ok {
+			return 0, false
+		}
+		// Extents of no bytes at all are all the same, and there could
+		// be 65535 of them for every item
+		if indexSize+offsetSize+lengthSize == 0 {
+			extents = min(extents, 1)
+		}
+
+		first := int64(-1)
+		for j := uint64(0); j < extents; j++ {
+			if _, ok = read(indexSize); !ok {
+				return 0, false
+			}
+			off, ok := read(offsetSize)
+			if !ok {
+				return 0, false
+			}
+			if _, ok = read(lengthSize); !ok {
+				return 0, false
+			}
+			if j == 0 {
+				first = int64(base + off)
+			}
+		}
+		// Only construction method 0, file offsets, is worth following
+		if uint32(id) == item && method == 0 && first >= 0 {
+			return first, true
+		}
+	}
+	return 0, false
+}
+
+type box struct {
+	typ  string
+	data []byte
+}
+
+// childBoxes splits data into ISO BMFF boxes
+func childBoxes(data []byte) []box {
+	var out []box
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data))
+		hdr := 8
+		switch size {
+		case 0:
+			size = len(data)
+		case 1:
+			if len(data) < 16 {
+				return out
+			}
+			size = int(binary.BigEndian.Uint64(data[8:]))
+			hdr = 16
+		}
+		if size < hdr || size > len(data) {
+			return out
+		}
+		out = append(out, box{typ: string(data[4:8]), data: data[hdr:size]})
+		data = data[size:]
+	}
+	return out
+}
+
+func findBox(data []byte, typ string) ([]byte, bool) {
+	for _, b := range childBoxes(data) {
+		if b.typ == typ {
+			return b.data, true
+		}
+	}
+	return nil, false
+}
+
+// associations returns the 1-based ipco indexes of the properties of item.
+// Without a primary item id every association is returned.
+func associations(ipma []byte, item uint32) []int {
+	if len(ipma) < 8 {
+		return nil
+	}
+	version, flags := ipma[0], ipma[3]
+	count := binary.BigEndian.Uint32(ipma[4:])
+	data := ipma[8:]
+
+	var out []int
+	for i := uint32(0); i < count; i++ {
+		var id uint32
+		if version < 1 {
+			if len(data) < 2 {
+				return out
+			}
+			id, data = uint32(binary.BigEndian.Uint16(data)), data[2:]
+		} else {
+			if len(data) < 4 {
+				return out
+			}
+			id, data = binary.BigEndian.Uint32(data), data[4:]
+		}
+		if len(data) < 1 {
+			return out
+		}
+		n := int(data[0])
+		data = data[1:]
+
+		for j := 0; j < n; j++ {
+			var index int
+			if flags&1 != 0 {
+				if len(data) < 2 {
+					return out
+				}
+				index, data = int(binary.BigEndian.Uint16(data)&0x7fff), data[2:]
+			} else {
+				if len(data) < 1 {
+					return out
+				}
+				index, data = int(data[0]&0x7f), data[1:]
+			}
+			if item == 0 || id == item {
+				out = append(out, index)
+			}
+		}
+	}
+	return out
+}
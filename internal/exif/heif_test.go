@@ -0,0 +1,87 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func heifBox(typ string, data ...[]byte) []byte {
+	body := bytes.Join(data, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, typ...), body...)
+}
+
+// heifFile builds an ftyp and a meta box with primary item 1. props are the
+// ipco children; assoc maps item ids to the 1-based indexes of their props.
+func heifFile(flags byte, props [][]byte, assoc map[uint16][]int) []byte {
+	ipma := []byte{0, 0, 0, flags}
+	ipma = binary.BigEndian.AppendUint32(ipma, uint32(len(assoc)))
+	for _, id := range []uint16{1, 2} {
+		indexes, ok := assoc[id]
+		if !ok {
+			continue
+		}
+		ipma = binary.BigEndian.AppendUint16(ipma, id)
+		ipma = append(ipma, byte(len(indexes)))
+		for _, i := range indexes {
+			if flags&1 != 0 {
+				ipma = binary.BigEndian.AppendUint16(ipma, uint16(i)|0x8000)
+			} else {
+				ipma = append(ipma, byte(i)|0x80)
+			}
+		}
+	}
+	meta := heifBox("meta",
+		[]byte{0, 0, 0, 0},
+		heifBox("pitm", []byte{0, 0, 0, 0, 0, 1}),
+		heifBox("iprp", heifBox("ipco", props...), heifBox("ipma", ipma)),
+	)
+	return append(heifBox("ftyp", []byte("heic\x00\x00\x00\x00mif1heic")), meta...)
+}
+
+func irot(angle byte) []byte { return heifBox("irot", []byte{angle}) }
+func imir(axis byte) []byte  { return heifBox("imir", []byte{axis}) }
+
+func TestHEIFOrientation(t *testing.T) {
+	ispe := heifBox("ispe", make([]byte, 12))
+	tests := []struct {
+		name  string
+		flags byte
+		props [][]byte
+		assoc map[uint16][]int
+		want  int
+		ok    bool
+	}{
+		{"no transform", 0, [][]byte{ispe}, map[uint16][]int{1: {1}}, 1, false},
+		{"rotate 0", 0, [][]byte{ispe, irot(0)}, map[uint16][]int{1: {1, 2}}, 1, false},
+		{"rotate 90", 0, [][]byte{ispe, irot(1)}, map[uint16][]int{1: {1, 2}}, 8, true},
+		{"rotate 180", 0, [][]byte{ispe, irot(2)}, map[uint16][]int{1: {1, 2}}, 3, true},
+		{"rotate 270", 0, [][]byte{ispe, irot(3)}, map[uint16][]int{1: {1, 2}}, 6, true},
+		{"mirror left-right", 0, [][]byte{imir(0)}, map[uint16][]int{1: {1}}, 2, true},
+		{"mirror top-bottom", 0, [][]byte{imir(1)}, map[uint16][]int{1: {1}}, 4, true},
+		{"rotate 90 mirror left-right", 0, [][]byte{irot(1), imir(0)}, map[uint16][]int{1: {1, 2}}, 7, true},
+		{"rotate 90 mirror top-bottom", 0, [][]byte{irot(1), imir(1)}, map[uint16][]int{1: {1, 2}}, 5, true},
+		{"rotate 180 mirror left-right", 0, [][]byte{irot(2), imir(0)}, map[uint16][]int{1: {1, 2}}, 4, true},
+		{"rotate 180 mirror top-bottom", 0, [][]byte{irot(2), imir(1)}, map[uint16][]int{1: {1, 2}}, 2, true},
+		{"rotate 270 mirror left-right", 0, [][]byte{irot(3), imir(0)}, map[uint16][]int{1: {1, 2}}, 5, true},
+		{"rotate 270 mirror top-bottom", 0, [][]byte{irot(3), imir(1)}, map[uint16][]int{1: {1, 2}}, 7, true},
+		{"wide indexes", 1, [][]byte{ispe, irot(1)}, map[uint16][]int{1: {1, 2}}, 8, true},
+		{"other item's rotation", 0, [][]byte{ispe, irot(1)}, map[uint16][]int{1: {1}, 2: {2}}, 1, false},
+		{"index out of range", 0, [][]byte{irot(1)}, map[uint16][]int{1: {5}}, 1, false},
+	}
+	for _, tt := range tests {
+		got, ok := HEIFOrientation(bytes.NewReader(heifFile(tt.flags, tt.props, tt.assoc)))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHEIFOrientationNotHEIF(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("\x00\x00\x00\x08free"), heifBox("ftyp", []byte("heic"))} {
+		if got, ok := HEIFOrientation(bytes.NewReader(data)); got != 1 || ok {
+			t.Errorf("%q: got %d, %v, want 1, false", data, got, ok)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+type segment struct {
+	marker byte
+	offset int64 // start of the payload, after the length
+	length int64
+}
+
+// findJPEGSegment walks the markers before the scan data and returns the
+// first one of kind marker whose payload starts with prefix
+func findJPEGSegment(r io.ReaderAt, marker byte, prefix []byte) (segment, error) {
+	off := int64(2)
+	var hdr [4]byte
+	for {
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			return segment{}, ErrNoExif
+		}
+		if hdr[0] != 0xff {
+			return segment{}, ErrNoExif
+		}
+		// Fill bytes
+		if hdr[1] == 0xff {
+			off++
+			continue
+		}
+		// Start of scan or end of image; metadata is always before these
+		if hdr[1] == 0xda || hdr[1] == 0xd9 {
+			return segment{}, ErrNoExif
+		}
+
+		n := int64(binary.BigEndian.Uint16(hdr[2:])) - 2
+		seg := segment{marker: hdr[1], offset: off + 4, length: n}
+		if hdr[1] == marker {
+			got := make([]byte, len(prefix))
+			if _, err := r.ReadAt(got, seg.offset); err == nil && bytes.Equal(got, prefix) {
+				return seg, nil
+			}
+		}
+		off = seg.offset + n
+	}
+}
+
+// JFIFThumbnail returns the JPEG thumbnail from a JFXX APP0 extension. Few
+// files have one, but it costs nothing to look.
+func JFIFThumbnail(r io.ReaderAt) (Section, bool) {
+	seg, err := findJPEGSegment(r, 0xe0, []byte("JFXX\x00\x10"))
+	if err != nil || seg.length <= 6 {
+		return Section{}, false
+	}
+	return Section{Offset: seg.offset + 6, Length: seg.length - 6}, true
+}
+
+// RAFPreview returns the JPEG preview at the front of a Fujifilm raw, which
+// isn't TIFF and so isn't found by Decode
+func RAFPreview(r io.ReaderAt) (Section, bool) {
+	var hdr [92]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil || !bytes.HasPrefix(hdr[:], []byte("FUJIFILMCCD-RAW")) {
+		return Section{}, false
+	}
+	off := binary.BigEndian.Uint32(hdr[84:])
+	n := binary.BigEndian.Uint32(hdr[88:])
+	if n == 0 {
+		return Section{}, false
+	}
+	return Section{Offset: int64(off), Length: int64(n)}, true
+}
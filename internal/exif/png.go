@@ -0,0 +1,30 @@
+package exif
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// findPNGChunk returns the offset of the data of the first chunk of kind
+// typ. Metadata chunks come before the image data, so the search stops at
+// IDAT.
+func findPNGChunk(r io.ReaderAt, typ string) (int64, error) {
+	off := int64(len(pngSignature))
+	var hdr [8]byte
+	for {
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			return 0, ErrNoExif
+		}
+		n := int64(binary.BigEndian.Uint32(hdr[:4]))
+		switch string(hdr[4:]) {
+		case typ:
+			return off + 8, nil
+		case "IDAT", "IEND":
+			return 0, ErrNoExif
+		}
+		// length, type, data and CRC
+		off += 8 + n + 4
+	}
+}
@@ -0,0 +1,258 @@
+package explorer
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nooooaaaaah/photoboard/internal/board"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+	"github.com/nooooaaaaah/photoboard/internal/utils"
+)
+
+// boardPreviewSize is what board images are decoded at for the preview,
+// plenty for a collage squeezed into a terminal
+const boardPreviewSize = 400
+
+// boardCacheSize is how many small copies are kept, a few dozen megabytes
+// at most
+const boardCacheSize = 64
+
+// Board edits moodboards. Small copies of the images are kept between
+// previews so rearranging a board doesn't decode everything again.
+type Board struct {
+	cache *imageCache
+}
+
+func NewBoard() Board {
+	return Board{cache: &imageCache{images: make(map[string]image.Image)}}
+}
+
+// imageCache holds the most recently used small copies, dropping the
+// oldest past boardCacheSize
+type imageCache struct {
+	mu     sync.Mutex
+	images map[string]image.Image
+	order  []string
+}
+
+func (c *imageCache) load(path string) (image.Image, error) {
+	c.mu.Lock()
+	img, ok := c.images[path]
+	if ok {
+		c.touch(path)
+	}
+	c.mu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	img, err := imaging.LoadSize(path, boardPreviewSize, boardPreviewSize)
+	if err != nil {
+		return nil, err
+	}
+	// LoadSize only promises at least this big
+	b := img.Bounds()
+	if w, h := imaging.Fit(b.Dx(), b.Dy(), boardPreviewSize, boardPreviewSize, 1); w < b.Dx() {
+		img = imaging.Resize(img, w, h)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.images[path]; !ok {
+		c.order = append(c.order, path)
+	}
+	c.images[path] = img
+	c.touch(path)
+	for len(c.order) > boardCacheSize {
+		delete(c.images, c.order[0])
+		c.order = c.order[1:]
+	}
+	return img, nil
+}
+
+// touch moves path to the back of the eviction order
+func (c *imageCache) touch(path string) {
+	if i := slices.Index(c.order, path); i >= 0 {
+		c.order = append(slices.Delete(c.order, i, i+1), path)
+	}
+}
+
+func (b Board) OpenBoard(m model.Model, name string) (tea.Model, tea.Cmd) {
+	bd, err := board.Load(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		m.StatusMsg = err.Error()
+		return m, nil
+	}
+
+	if added := bd.Add(boardCandidates(m)...); added > 0 || errors.Is(err, fs.ErrNotExist) {
+		if err := bd.Save(); err != nil {
+			m.StatusMsg = "Couldn't save board: " + err.Error()
+			return m, nil
+		}
+		m.StatusMsg = ""
+		if added > 0 {
+			m.StatusMsg = fmt.Sprintf("Added %d images to %s", added, name)
+		}
+	}
+
+	m.Board = model.BoardState{Active: true, Board: bd, Version: m.Board.Version}
+	return b.refresh(m)
+}
+
+// boardCandidates is what B adds: the selected images only, so opening a
+// board with nothing selected leaves it as it was
+func boardCandidates(m model.Model) []string {
+	var paths []string
+	for path := range m.Selection {
+		if utils.IsImageFile(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (b Board) HandleBoardUpdate(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := &m.Board
+	bd := &s.Board
+	switch msg.String() {
+	case "esc", "q":
+		m.Board = model.BoardState{Version: s.Version}
+		m.StatusMsg = ""
+		return m, nil
+	case "down", "j":
+		s.Cursor = min(s.Cursor+1, max(len(bd.Images)-1, 0))
+		return m, nil
+	case "up", "k":
+		s.Cursor = max(s.Cursor-1, 0)
+		return m, nil
+	case "J":
+		if !bd.Move(s.Cursor, 1) {
+			return m, nil
+		}
+		s.Cursor++
+	case "K":
+		if !bd.Move(s.Cursor, -1) {
+			return m, nil
+		}
+		s.Cursor--
+	case "d", "x":
+		if len(bd.Images) == 0 {
+			return m, nil
+		}
+		bd.Remove(s.Cursor)
+		s.Cursor = max(min(s.Cursor, len(bd.Images)-1), 0)
+	case "L":
+		if bd.Layout == board.LayoutMasonry {
+			bd.Layout = board.LayoutGrid
+		} else {
+			bd.Layout = board.LayoutMasonry
+		}
+	case "+":
+		bd.Columns = min(bd.Columns+1, 12)
+	case "-":
+		bd.Columns = max(bd.Columns-1, 1)
+	case "]":
+		bd.Spacing += 8
+	case "[":
+		bd.Spacing = max(bd.Spacing-8, 0)
+	case "c":
+		return m, m.OpenPrompt("board-color", "")
+	case "e":
+		return m, m.OpenPrompt("board-export", "")
+	default:
+		return m, nil
+	}
+	return b.save(m)
+}
+
+func (b Board) HandleBoardPrompt(m model.Model, kind, value string) (tea.Model, tea.Cmd) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case "board":
+		if err := board.CheckName(value); err != nil {
+			m.StatusMsg = err.Error()
+			return m, nil
+		}
+		return b.OpenBoard(m, value)
+	case "board-color":
+		if _, err := board.ParseColor(value); err != nil {
+			m.StatusMsg = err.Error()
+			return m, nil
+		}
+		m.Board.Board.Background = value
+		return b.save(m)
+	case "board-export":
+		path := expandHome(value)
+		bd := m.Board.Board
+		bd.Images = slices.Clone(bd.Images)
+		m.StatusMsg = "Exporting..."
+		return m, func() tea.Msg {
+			missing, err := bd.Export(path)
+			return model.BoardExportedMsg{Path: path, Missing: len(missing), Err: err}
+		}
+	}
+	return m, nil
+}
+
+// Resize composes the preview again for the new pane size
+func (b Board) Resize(m model.Model) (tea.Model, tea.Cmd) {
+	return b.refresh(m)
+}
+
+// save writes the board after a change and redraws the preview
+func (b Board) save(m model.Model) (tea.Model, tea.Cmd) {
+	if err := m.Board.Board.Save(); err != nil {
+		m.StatusMsg = "Couldn't save board: " + err.Error()
+	} else {
+		m.StatusMsg = ""
+	}
+	return b.refresh(m)
+}
+
+// refresh composes the board small, off the UI goroutine, and renders it
+// in half blocks for the preview pane
+func (b Board) refresh(m model.Model) (tea.Model, tea.Cmd) {
+	m.Board.Version++
+	if len(m.Board.Board.Images) == 0 {
+		m.Board.Preview = ""
+		m.Board.Missing = 0
+		return m, nil
+	}
+
+	// Reordering swaps images in place, so compose from a copy
+	version := m.Board.Version
+	bd := m.Board.Board
+	bd.Images = slices.Clone(bd.Images)
+	cols, rows := m.BoardPane()
+	return m, func() tea.Msg {
+		// Half blocks show two pixels per cell, one above the other
+		img, missing, err := bd.Compose(cols*2, b.cache.load)
+		if err != nil {
+			return model.BoardPreviewMsg{Version: version, Err: err}
+		}
+		out, err := render.NewHalfBlock(lipgloss.ColorProfile()).Render(img, cols, rows)
+		return model.BoardPreviewMsg{Version: version, Text: out.Text, Missing: len(missing), Err: err}
+	}
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,212 @@
+package explorer
+
+import (
+	"path/filepath"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+	"github.com/nooooaaaaah/photoboard/internal/metadata"
+	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+	"github.com/nooooaaaaah/photoboard/internal/utils"
+)
+
+// Compare shows two images side by side through the previewer's renderer,
+// zoomed and panned together
+type Compare struct {
+	Previewer Previewer
+}
+
+func NewCompare(prev Previewer) Compare {
+	return Compare{Previewer: prev}
+}
+
+// StartCompare opens the two selected images, or the one selected image
+// and the highlighted one
+func (c Compare) StartCompare(m model.Model) (tea.Model, tea.Cmd) {
+	paths := comparePair(m)
+	if len(paths) != 2 {
+		m.StatusMsg = "Select two images to compare"
+		return m, nil
+	}
+
+	cols, rows := m.ComparePane()
+	pw, ph := render.PixelBudget(c.Previewer.renderer(), cols, rows)
+	var sides [2]model.CompareSide
+	for i, path := range paths {
+		img, reduced, err := imaging.LoadSizeReduced(path, pw, ph)
+		if err != nil {
+			m.StatusMsg = "Couldn't load " + filepath.Base(path)
+			return m, nil
+		}
+		info, err := metadata.Read(path)
+		if err != nil {
+			log.Warn("Failed to read metadata", "path", path, "error", err)
+		}
+		sides[i] = model.CompareSide{Path: path, View: model.NewImageView(img, reduced), Meta: info.Fields}
+	}
+
+	m.StatusMsg = ""
+	m.Compare = model.NewCompare(sides[0], sides[1])
+	return c.redraw(m)
+}
+
+// comparePair picks the images to compare: exactly two selected, or one
+// selected plus the one under the cursor
+func comparePair(m model.Model) []string {
+	var paths []string
+	for path := range m.Selection {
+		if utils.IsImageFile(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	if len(paths) == 1 && m.ActiveColumn < len(m.Columns) {
+		if item, ok := m.Columns[m.ActiveColumn].List.SelectedItem().(defs.FileItem); ok &&
+			!item.IsDir && item.Path != paths[0] && utils.IsImageFile(item.Path) {
+			paths = append(paths, item.Path)
+		}
+	}
+	return paths
+}
+
+func (c Compare) HandleCompareUpdate(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cols, rows := m.ComparePane()
+	pw, ph := render.PixelBudget(c.Previewer.renderer(), cols, rows)
+	cmp := &m.Compare
+
+	const step = 0.2
+	switch msg.String() {
+	case "up", "k", "down", "j", "left", "h", "right", "l":
+		if cmp.Zoom == 0 {
+			return m, nil
+		}
+	}
+	switch msg.String() {
+	case "esc", "q":
+		return m, c.close(&m)
+	case "+":
+		cmp.ZoomBy(1, pw, ph)
+	case "-":
+		cmp.ZoomBy(-1, pw, ph)
+	case "=":
+		cmp.ZoomActual(pw, ph)
+	case "w":
+		cmp.Zoom = 0
+		cmp.Actual = false
+	case "up", "k":
+		cmp.Pan(0, -step, pw, ph)
+	case "down", "j":
+		cmp.Pan(0, step, pw, ph)
+	case "left", "h":
+		cmp.Pan(-step, 0, pw, ph)
+	case "right", "l":
+		cmp.Pan(step, 0, pw, ph)
+	case "i":
+		cmp.ShowInfo = !cmp.ShowInfo
+	case "x":
+		cmp.Swap()
+	default:
+		return m, nil
+	}
+
+	// Embedded previews run out of detail past fit, so load the full
+	// images in the background, once
+	var loads []tea.Cmd
+	if cmp.Zoom != 0 {
+		for i := range cmp.Sides {
+			side := &cmp.Sides[i]
+			if !side.View.Reduced || side.View.Loading {
+				continue
+			}
+			side.View.Loading = true
+			path := side.Path
+			loads = append(loads, func() tea.Msg {
+				img, err := imaging.Load(path)
+				return model.FullImageMsg{Path: path, Image: img, Err: err}
+			})
+		}
+	}
+	updated, cmd := c.redraw(m)
+	return updated, tea.Batch(append(loads, cmd)...)
+}
+
+// FullImageLoaded swaps a full image in for the side showing its embedded
+// preview. The zoom is relative to fit, so the view stays put.
+func (c Compare) FullImageLoaded(m model.Model, msg model.FullImageMsg) (tea.Model, tea.Cmd) {
+	for i := range m.Compare.Sides {
+		side := &m.Compare.Sides[i]
+		if side.Path != msg.Path || !side.View.Reduced {
+			continue
+		}
+		side.View.Reduced, side.View.Loading = false, false
+		if msg.Err != nil {
+			log.Warn("Failed to load full image", "path", msg.Path, "error", msg.Err)
+		} else if msg.Image.Bounds().Dx() > side.View.Source.Bounds().Dx() {
+			side.View.Source = msg.Image
+		}
+	}
+	return c.redraw(m)
+}
+
+// Resize fits both sides to a new window size
+func (c Compare) Resize(m model.Model) (tea.Model, tea.Cmd) {
+	return c.redraw(m)
+}
+
+// redraw renders both sides at the shared zoom and swaps them in for
+// whatever was on screen
+func (c Compare) redraw(m model.Model) (tea.Model, tea.Cmd) {
+	cols, rows := m.ComparePane()
+	pw, ph := render.PixelBudget(c.Previewer.renderer(), cols, rows)
+	m.Compare.Sync(pw, ph)
+
+	var teardown, emit []tea.Cmd
+	overlay := false
+	for i := range m.Compare.Sides {
+		side := &m.Compare.Sides[i]
+		rendered, err := c.Previewer.renderView(&side.View, cols, rows)
+		if err != nil {
+			log.Error("Failed to render image", "path", side.Path, "error", err)
+			continue
+		}
+
+		old := side.Image
+		side.Image = rendered
+		teardown = append(teardown, render.Emit(old.Teardown))
+		overlay = overlay || old.Overlay
+		if rendered.Overlay {
+			x, y := m.CompareOrigin(i)
+			emit = append(emit, render.EmitAt(rendered.Setup, x, y))
+		} else {
+			emit = append(emit, render.Emit(rendered.Setup))
+		}
+	}
+	if overlay {
+		// Wipe the old overlays before drawing the new ones over them
+		render.Output.ClearOverlays()
+		teardown = append(teardown, tea.ClearScreen)
+	}
+	return m, tea.Sequence(tea.Batch(teardown...), tea.Batch(emit...))
+}
+
+// close leaves the comparison, removing both images from the terminal
+func (c Compare) close(m *model.Model) tea.Cmd {
+	var cmds []tea.Cmd
+	overlay := false
+	for _, side := range m.Compare.Sides {
+		cmds = append(cmds, render.Emit(side.Image.Teardown))
+		overlay = overlay || side.Image.Overlay
+	}
+	if overlay {
+		render.Output.ClearOverlays()
+		cmds = append(cmds, tea.ClearScreen)
+	}
+	m.Compare = model.Compare{}
+	m.StatusMsg = ""
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,244 @@
+package explorer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/sidecar"
+	"github.com/nooooaaaaah/photoboard/internal/trash"
+	"github.com/nooooaaaaah/photoboard/internal/utils"
+)
+
+// RejectsDir is where culling moves rejects, next to the images
+const RejectsDir = "_rejects"
+
+// Cull runs culling passes on top of the previewer: one key keeps or
+// rejects the image and moves on to the next
+type Cull struct {
+	Previewer Previewer
+}
+
+func NewCull(prev Previewer) Cull {
+	return Cull{Previewer: prev}
+}
+
+// StartCull goes through the same images a slideshow would, remembering
+// picks and rejects from earlier passes
+func (c Cull) StartCull(m model.Model) (tea.Model, tea.Cmd) {
+	paths, start := imageList(m)
+	if len(paths) == 0 {
+		m.StatusMsg = "No images to cull"
+		return m, nil
+	}
+
+	known := make(map[string]defs.Tags)
+	if m.ActiveColumn < len(m.Columns) {
+		for _, item := range m.Columns[m.ActiveColumn].List.Items() {
+			if fileItem, ok := item.(defs.FileItem); ok {
+				known[fileItem.Path] = fileItem.Tags
+			}
+		}
+	}
+	decisions := make(map[string]defs.Flag, len(paths))
+	for _, path := range paths {
+		tags, ok := known[path]
+		if !ok {
+			tags, _ = sidecar.Read(path)
+		}
+		decisions[path] = tags.Flag
+	}
+
+	m.Cull = model.Cull{Active: true, Paths: paths, Index: start, Decisions: decisions}
+	return c.show(m)
+}
+
+func (c Cull) HandleCullUpdate(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.Cull.Done {
+		return c.handleSummary(m, msg)
+	}
+
+	switch msg.String() {
+	case "p", "P", "enter":
+		return c.decide(m, defs.FlagPick)
+	case "x", "X":
+		return c.decide(m, defs.FlagReject)
+	case "u":
+		c.mark(&m, defs.FlagNone)
+		return m, nil
+	case "right", "n":
+		return c.step(m, 1)
+	case "left", "b":
+		return c.step(m, -1)
+	case "esc", "q":
+		return c.finish(m)
+	}
+
+	// Ratings, zoom and the info panel work as in the preview. A rating
+	// can undo a reject, so pick the flag up again afterwards.
+	updated, cmd := c.Previewer.HandlePreviewUpdate(m, msg)
+	m = updated.(model.Model)
+	if tags, err := sidecar.Read(m.Cull.Path()); err == nil {
+		m.Cull.Decisions[m.Cull.Path()] = tags.Flag
+	}
+	return m, cmd
+}
+
+func (c Cull) handleSummary(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rejects := m.Cull.Rejects()
+	switch msg.String() {
+	case "m", "t", "b":
+		// Don't start a second move, or go back to files on their way out
+		if m.Cull.Moving {
+			return m, nil
+		}
+	}
+	switch msg.String() {
+	case "m":
+		if len(rejects) > 0 {
+			m.Cull.Moving = true
+			m.StatusMsg = "Moving rejects..."
+			return m, moveRejects(rejects, false)
+		}
+	case "t":
+		if len(rejects) > 0 {
+			m.Cull.Moving = true
+			m.StatusMsg = "Moving rejects..."
+			return m, moveRejects(rejects, true)
+		}
+	case "b":
+		m.Cull.Done = false
+		m.StatusMsg = ""
+		return c.show(m)
+	case "q", "esc", "enter":
+		m.Cull = model.Cull{}
+		m.StatusMsg = ""
+		return m, nil
+	}
+	return m, nil
+}
+
+// decide marks the current image and moves on, ending with the summary
+func (c Cull) decide(m model.Model, flag defs.Flag) (tea.Model, tea.Cmd) {
+	if !c.mark(&m, flag) {
+		return m, nil
+	}
+	if m.Cull.Index == len(m.Cull.Paths)-1 {
+		return c.finish(m)
+	}
+	return c.step(m, 1)
+}
+
+// mark saves flag to the current image's sidecar
+func (c Cull) mark(m *model.Model, flag defs.Flag) bool {
+	path := m.Cull.Path()
+	if err := m.TagFile(path, func(t *defs.Tags) { t.Flag = flag }); err != nil {
+		m.StatusMsg = "Couldn't save: " + err.Error()
+		return false
+	}
+	m.Cull.Decisions[path] = flag
+	return true
+}
+
+func (c Cull) step(m model.Model, delta int) (tea.Model, tea.Cmd) {
+	next := m.Cull.Index + delta
+	if next < 0 || next >= len(m.Cull.Paths) {
+		return m, nil
+	}
+	m.Cull.Index = next
+	return c.show(m)
+}
+
+func (c Cull) finish(m model.Model) (tea.Model, tea.Cmd) {
+	m.Cull.Done = true
+	return m, m.ClosePreview()
+}
+
+func (c Cull) show(m model.Model) (tea.Model, tea.Cmd) {
+	return c.Previewer.showPath(m, m.Cull.Path())
+}
+
+// moveRejects moves each reject, and the sidecar only it uses, into a
+// _rejects folder next to it or to the trash
+func moveRejects(paths []string, toTrash bool) tea.Cmd {
+	return func() tea.Msg {
+		dest := RejectsDir
+		if toTrash {
+			dest = "the trash"
+		}
+		rejected := make(map[string]bool, len(paths))
+		for _, path := range paths {
+			rejected[path] = true
+		}
+
+		moved := 0
+		for _, path := range paths {
+			side := ownSidecar(path, rejected)
+			var err error
+			if toTrash {
+				err = trash.Move(path)
+				if err == nil && side != "" {
+					err = trash.Move(side)
+				}
+			} else {
+				err = moveToRejects(path, side)
+			}
+			if err != nil {
+				return model.CullMovedMsg{Moved: moved, Dest: dest, Err: err}
+			}
+			moved++
+		}
+		return model.CullMovedMsg{Moved: moved, Dest: dest}
+	}
+}
+
+func moveToRejects(path, side string) error {
+	dir := filepath.Join(filepath.Dir(path), RejectsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	target := utils.FreeName(filepath.Join(dir, filepath.Base(path)))
+	if err := utils.MoveFile(path, target); err != nil {
+		return err
+	}
+	if side == "" {
+		return nil
+	}
+
+	// Keep the sidecar's name matched up with the image's, in whichever
+	// style it was
+	sideTarget := strings.TrimSuffix(target, filepath.Ext(target)) + ".xmp"
+	if filepath.Base(side) == filepath.Base(path)+".xmp" {
+		sideTarget = target + ".xmp"
+	}
+	return utils.MoveFile(side, utils.FreeName(sideTarget))
+}
+
+// ownSidecar is path's sidecar, unless a file that's staying, like the
+// JPEG of a rejected raw someone kept, shares it
+func ownSidecar(path string, rejected map[string]bool) string {
+	side := sidecar.Path(path)
+	if _, err := os.Stat(side); err != nil {
+		return ""
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return ""
+	}
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, entry := range entries {
+		name := entry.Name()
+		other := filepath.Join(filepath.Dir(path), name)
+		if entry.IsDir() || other == path || other == side || rejected[other] {
+			continue
+		}
+		if strings.TrimSuffix(name, filepath.Ext(name)) == stem && sidecar.Path(other) == side {
+			return ""
+		}
+	}
+	return side
+}
@@ -0,0 +1,212 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nooooaaaaah/photoboard/internal/dupes"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+	"github.com/nooooaaaaah/photoboard/internal/trash"
+)
+
+// Dupes scans the active directory for duplicate images and reviews them a
+// group at a time
+type Dupes struct {
+	Options dupes.Options
+}
+
+func NewDupes(opts dupes.Options) Dupes {
+	return Dupes{Options: opts}
+}
+
+// StartDupes scans in the background, sending progress and then the groups
+// down the same channel
+func (d Dupes) StartDupes(m model.Model) (tea.Model, tea.Cmd) {
+	if m.ActiveColumn >= len(m.Columns) {
+		return m, nil
+	}
+	root := m.Columns[m.ActiveColumn].Path
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg, 1)
+	opts := d.Options
+	opts.ShowHidden = m.Config.ShowHidden
+	opts.Progress = func(done, total int) {
+		// Progress is only for show, so drop it rather than hold up a worker
+		select {
+		case updates <- model.DupesProgressMsg{Root: root, Done: done, Total: total}:
+		default:
+		}
+	}
+	go func() {
+		groups, err := dupes.Scan(ctx, root, opts)
+		select {
+		case updates <- model.DupesScannedMsg{Root: root, Groups: groups, Err: err}:
+		case <-ctx.Done():
+		}
+	}()
+
+	m.ShowDupes = true
+	m.StatusMsg = ""
+	m.Dupes = model.DupesState{
+		Root:     root,
+		Scanning: true,
+		Thumbs:   make(map[string]string),
+		Updates:  updates,
+		Cancel:   cancel,
+	}
+	return m, m.Dupes.Listen()
+}
+
+func (d Dupes) Scanned(m model.Model, msg model.DupesScannedMsg) (tea.Model, tea.Cmd) {
+	m.Dupes.Scanning = false
+	m.Dupes.Progress = ""
+	if msg.Err != nil {
+		m.ShowDupes = false
+		m.StatusMsg = "Duplicate scan failed: " + msg.Err.Error()
+		return m, nil
+	}
+	m.Dupes.Groups = msg.Groups
+	m.Dupes.Marks = make([]map[string]bool, len(msg.Groups))
+	for i := range m.Dupes.Marks {
+		m.Dupes.Marks[i] = make(map[string]bool)
+	}
+	return d.LoadGroup(m)
+}
+
+func (d Dupes) HandleDupesUpdate(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Anything but y backs out of trashing, esc included
+	if m.Dupes.Confirm {
+		m.Dupes.Confirm = false
+		if msg.String() != "y" {
+			m.StatusMsg = "Nothing trashed"
+			return m, nil
+		}
+		marked := m.Dupes.Marked()
+		paths := make([]string, 0, len(marked))
+		for path := range marked {
+			paths = append(paths, path)
+		}
+		m.StatusMsg = "Moving to the trash..."
+		return m, trashDupes(paths)
+	}
+
+	if msg.String() == "esc" || msg.String() == "q" {
+		if m.Dupes.Cancel != nil {
+			m.Dupes.Cancel()
+		}
+		m.ShowDupes = false
+		m.Dupes = model.DupesState{}
+		m.StatusMsg = ""
+		return m, nil
+	}
+
+	state := &m.Dupes
+	if state.Scanning || len(state.Groups) == 0 {
+		return m, nil
+	}
+	g := state.Groups[state.Group]
+	marked := state.Marked()
+
+	switch msg.String() {
+	case "right", "l":
+		state.Cursor = min(state.Cursor+1, len(g.Images)-1)
+	case "left", "h":
+		state.Cursor = max(state.Cursor-1, 0)
+	case "n", "down":
+		if state.Group < len(state.Groups)-1 {
+			state.Group++
+			state.Cursor = 0
+			return d.LoadGroup(m)
+		}
+	case "b", "up":
+		if state.Group > 0 {
+			state.Group--
+			state.Cursor = 0
+			return d.LoadGroup(m)
+		}
+	case "d", " ":
+		path := g.Images[state.Cursor].Path
+		if marked[path] {
+			delete(marked, path)
+		} else {
+			marked[path] = true
+		}
+	case "k":
+		for i, img := range g.Images {
+			if i == state.Cursor {
+				delete(marked, img.Path)
+			} else {
+				marked[img.Path] = true
+			}
+		}
+	case "enter":
+		if len(marked) == 0 {
+			m.StatusMsg = "Nothing marked"
+			return m, nil
+		}
+		state.Confirm = true
+	}
+	return m, nil
+}
+
+// LoadGroup renders previews for the current group's images that don't
+// have one yet
+func (d Dupes) LoadGroup(m model.Model) (tea.Model, tea.Cmd) {
+	if len(m.Dupes.Groups) == 0 {
+		return m, nil
+	}
+	_, w, h := m.DupeCards()
+	var cmds []tea.Cmd
+	for _, img := range m.Dupes.Groups[m.Dupes.Group].Images {
+		if _, ok := m.Dupes.Thumbs[img.Path]; !ok {
+			cmds = append(cmds, dupeThumb(img.Path, w, h))
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func dupeThumb(path string, w, h int) tea.Cmd {
+	return func() tea.Msg {
+		// Half blocks fit two pixel rows in a cell
+		img, err := imaging.LoadSize(path, w, h*2)
+		if err != nil {
+			return model.DupeThumbMsg{Path: path, Text: "Couldn't load this image"}
+		}
+		thumb, err := render.NewHalfBlock(lipgloss.ColorProfile()).Render(img, w, h)
+		if err != nil {
+			return model.DupeThumbMsg{Path: path, Text: "Couldn't load this image"}
+		}
+		return model.DupeThumbMsg{Path: path, Text: thumb.Text}
+	}
+}
+
+// trashDupes moves the marked files, and the sidecars only they use, to
+// the trash
+func trashDupes(paths []string) tea.Cmd {
+	return func() tea.Msg {
+		marked := make(map[string]bool, len(paths))
+		for _, path := range paths {
+			marked[path] = true
+		}
+
+		var trashed []string
+		for _, path := range paths {
+			side := ownSidecar(path, marked)
+			if err := trash.Move(path); err != nil {
+				return model.DupesTrashedMsg{Trashed: trashed, Err: fmt.Errorf("%s: %w", path, err)}
+			}
+			trashed = append(trashed, path)
+			if side != "" {
+				if err := trash.Move(side); err != nil {
+					return model.DupesTrashedMsg{Trashed: trashed, Err: fmt.Errorf("%s: %w", side, err)}
+				}
+			}
+		}
+		return model.DupesTrashedMsg{Trashed: trashed}
+	}
+}
@@ -0,0 +1,162 @@
+package explorer
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+	"github.com/nooooaaaaah/photoboard/internal/thumbs"
+	"github.com/nooooaaaaah/photoboard/internal/utils"
+)
+
+type Gallery struct {
+	Pool *thumbs.Pool
+}
+
+func NewGallery(pool *thumbs.Pool) Gallery {
+	return Gallery{Pool: pool}
+}
+
+func (g Gallery) StartGallery(m model.Model) (tea.Model, tea.Cmd) {
+	if m.ActiveColumn >= len(m.Columns) {
+		return m, nil
+	}
+
+	col := m.Columns[m.ActiveColumn]
+	selected := ""
+	if item, ok := col.List.SelectedItem().(defs.FileItem); ok {
+		selected = item.Path
+	}
+
+	state := model.GalleryState{Dir: col.Path}
+	done := m.Thumbs.For(col.Path)
+	for _, item := range col.List.Items() {
+		if fileItem, ok := item.(defs.FileItem); ok && !fileItem.IsDir && utils.IsImageFile(fileItem.Path) {
+			if fileItem.Path == selected {
+				state.Cursor = len(state.Items)
+			}
+			state.Items = append(state.Items, fileItem)
+		}
+	}
+
+	m.ShowGallery = true
+	m.Gallery = state
+
+	// Queue the whole directory nearest-first; moveTo bumps what's visible
+	g.Pool.Focus(state.Dir)
+	for i, item := range state.Items {
+		if _, ok := done[item.Path]; !ok {
+			g.Pool.Submit(state.Dir, item.Path, 1+abs(i-state.Cursor))
+		}
+	}
+	return g.moveTo(m, state.Cursor)
+}
+
+func (g Gallery) HandleGalleryUpdate(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cols, rows := m.GalleryGrid()
+	cursor := m.Gallery.Cursor
+
+	switch msg.String() {
+	case "esc", "q", "g":
+		// Still in the directory, so its thumbnails keep coming; the
+		// navigator's Focus cancels them on the way out
+		m.ShowGallery = false
+		m.Gallery = model.GalleryState{}
+		return m, nil
+	case "enter", "p":
+		if cursor < len(m.Gallery.Items) {
+			return m.OpenPreview(m.Gallery.Items[cursor].Path)
+		}
+		return m, nil
+	case "left", "h":
+		cursor--
+	case "right", "l":
+		cursor++
+	case "up", "k":
+		cursor -= cols
+	case "down", "j":
+		cursor += cols
+	case "pgup":
+		cursor -= cols * rows
+	case "pgdown":
+		cursor += cols * rows
+	case "home":
+		cursor = 0
+	case "end":
+		cursor = len(m.Gallery.Items) - 1
+	default:
+		return m, nil
+	}
+
+	return g.moveTo(m, cursor)
+}
+
+func (g Gallery) HandleGalleryMouse(m model.Model, msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+
+	start, end := m.VisibleThumbs()
+	for i := start; i < end; i++ {
+		if zone.Get(fmt.Sprintf("thumb-%d", i)).InBounds(msg) {
+			// Clicking the selected thumbnail again opens it
+			if i == m.Gallery.Cursor {
+				return m.OpenPreview(m.Gallery.Items[i].Path)
+			}
+			return g.moveTo(m, i)
+		}
+	}
+	return m, nil
+}
+
+// moveTo clamps and sets the cursor, scrolls it into view and moves the
+// thumbnails that became visible to the front of the queue
+func (g Gallery) moveTo(m model.Model, cursor int) (tea.Model, tea.Cmd) {
+	if len(m.Gallery.Items) == 0 {
+		return m, nil
+	}
+	cursor = max(0, min(cursor, len(m.Gallery.Items)-1))
+	m.Gallery.Cursor = cursor
+
+	cols, rows := m.GalleryGrid()
+	row := cursor / cols
+	if row < m.Gallery.Offset {
+		m.Gallery.Offset = row
+	} else if row >= m.Gallery.Offset+rows {
+		m.Gallery.Offset = row - rows + 1
+	}
+
+	start, end := m.VisibleThumbs()
+	for i := start; i < end; i++ {
+		path := m.Gallery.Items[i].Path
+		if _, ok := m.Thumbs.Text[path]; !ok {
+			g.Pool.Submit(m.Gallery.Dir, path, 0)
+		}
+	}
+	return m, nil
+}
+
+// Listen waits for the next finished thumbnail and renders it for the grid.
+// The model calls it again after every ThumbnailMsg.
+func (g Gallery) Listen() tea.Cmd {
+	return func() tea.Msg {
+		res := <-g.Pool.Results()
+		if res.Err != nil {
+			return model.ThumbnailMsg{Dir: res.Dir, Path: res.Path, Err: res.Err}
+		}
+
+		thumb, err := render.NewHalfBlock(lipgloss.ColorProfile()).Render(res.Image, model.ThumbWidth, model.ThumbHeight)
+		return model.ThumbnailMsg{Dir: res.Dir, Path: res.Path, Text: thumb.Text, Err: err}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -5,9 +5,16 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/nooooaaaaah/photoboard/internal/defs"
 	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/thumbs"
 )
 
-type Navigator struct{}
+type Navigator struct {
+	Pool *thumbs.Pool
+}
+
+func NewNavigator(pool *thumbs.Pool) Navigator {
+	return Navigator{Pool: pool}
+}
 
 func (n Navigator) HandleNavigation(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	minColumnWidth := 30
@@ -42,6 +49,8 @@ func (n Navigator) HandleNavigation(m model.Model, msg tea.KeyMsg) (tea.Model, t
 				err := m.AddColumn(i.Path, columnWidth)
 				if err == nil {
 					m.ActiveColumn++
+					n.Pool.Focus(i.Path)
+					return m, model.LoadGitStatus(i.Path)
 				}
 			}
 		}
@@ -52,6 +61,7 @@ func (n Navigator) HandleNavigation(m model.Model, msg tea.KeyMsg) (tea.Model, t
 			m.ActiveColumn--
 			// Remove columns to the right
 			m.Columns = m.Columns[:m.ActiveColumn+1]
+			n.Pool.Focus(m.Columns[m.ActiveColumn].Path)
 		}
 		return m, nil
 
@@ -1,24 +1,90 @@
 package explorer
 
 import (
+	"errors"
+	"image"
+	"math"
 	"os"
+	"path/filepath"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
+	"github.com/muesli/termenv"
 	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+	"github.com/nooooaaaaah/photoboard/internal/metadata"
 	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+	"github.com/nooooaaaaah/photoboard/internal/thumbs"
 	"github.com/nooooaaaaah/photoboard/internal/utils"
 	"github.com/nooooaaaaah/photoboard/internal/utils/highlight"
 )
 
-type Previewer struct{}
+type Previewer struct {
+	Renderer render.Renderer
+	Pool     *thumbs.Pool
+}
+
+func NewPreviewer(renderer render.Renderer, pool *thumbs.Pool) Previewer {
+	return Previewer{Renderer: renderer, Pool: pool}
+}
 
 func (p Previewer) HandlePreviewUpdate(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Ratings, labels and flags work on the previewed image too
+	if m.PreviewIsImage && m.HandleTagKey(msg.String(), m.PreviewPath) {
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "esc":
-		m.ShowPreview = false
-		return m, nil
+		return m, m.ClosePreview()
+	case "i":
+		if m.PreviewIsImage && !m.PreviewUnsupported {
+			m.ShowMetadata = !m.ShowMetadata
+			return p.rerender(m)
+		}
+	case "y":
+		if m.PreviewIsImage && len(m.PreviewMeta) > 0 {
+			text := metadata.Text(m.PreviewMeta)
+			return m, func() tea.Msg {
+				// Through render.Output, so the copy can't land in
+				// the middle of a frame
+				termenv.NewOutput(render.Output).Copy(text)
+				return model.MetadataCopiedMsg{}
+			}
+		}
+	case " ":
+		if m.Animation.ID != 0 {
+			if m.Animation.Paused {
+				m.Animation.Resume()
+				return p.showFrame(m)
+			}
+			m.Animation.Paused = true
+			return m, nil
+		}
+	case ".", ",":
+		if m.Animation.ID != 0 {
+			m.Animation.Paused = true
+			if msg.String() == "." {
+				m.Animation.Step(1)
+			} else {
+				m.Animation.Step(-1)
+			}
+			return p.showFrame(m)
+		}
+	case "+", "-", "=", "w":
+		if m.ImageView.Source != nil {
+			return p.zoom(m, msg.String())
+		}
+	case "up", "k", "down", "j", "left", "h", "right", "l":
+		if m.ImageView.Zoom != 0 {
+			return p.pan(m, msg.String())
+		}
+	}
+
+	switch msg.String() {
 	case "up", "k":
 		m.Viewport.LineUp(1)
 	case "down", "j":
@@ -42,22 +108,369 @@ func (p Previewer) StartPreview(m model.Model, msg tea.KeyMsg) (tea.Model, tea.C
 	activeList := m.Columns[m.ActiveColumn].List
 	if i, ok := activeList.SelectedItem().(defs.FileItem); ok && !i.IsDir {
 		if utils.IsImageFile(i.Path) {
-			return handleImagePreview(m, i)
+			return p.handleImagePreview(m, i)
 		}
 		return handleFilePreview(m, i)
 	}
 	return m, nil
 }
 
-func handleImagePreview(m model.Model, item defs.FileItem) (tea.Model, tea.Cmd) {
-	asciiArt := utils.ImageToAscii(item.Path)
+func (p Previewer) renderer() render.Renderer {
+	if p.Renderer == nil {
+		return render.NewHalfBlock(lipgloss.ColorProfile())
+	}
+	return p.Renderer
+}
+
+func (p Previewer) handleImagePreview(m model.Model, item defs.FileItem) (tea.Model, tea.Cmd) {
+	renderer := p.renderer()
+	m.Animation = model.Animation{}
+	m.ImageView = model.ImageView{}
+
+	cols, rows := imagePaneSize(m)
+	pw, ph := render.PixelBudget(renderer, cols, rows)
+
+	var rendered render.Image
+	var err error
+	var still image.Image
+	var reduced bool
+	if imaging.Format(item.Path) == imaging.FormatGIF {
+		frames, err := imaging.LoadFrames(item.Path, pw, ph)
+		switch {
+		case err != nil || len(frames.Images) == 0:
+		case len(frames.Images) > 1:
+			m.Animation = model.NewAnimation(frames.Images, frames.Delays, frames.Plays)
+		default:
+			// A GIF that doesn't move is already decoded
+			still, reduced = frames.Images[0], frames.Scaled
+		}
+	}
+
+	if m.Animation.ID != 0 {
+		rendered, err = renderer.Render(m.Animation.Frames[0], cols, rows)
+	} else {
+		if still == nil {
+			img, r, loadErr := imaging.LoadSizeReduced(item.Path, pw, ph)
+			var unsupported *imaging.UnsupportedError
+			if errors.As(loadErr, &unsupported) {
+				return p.handleUnsupportedPreview(m, item, unsupported, cols, rows)
+			}
+			if loadErr != nil {
+				log.Error("Failed to decode image", "error", loadErr)
+				return m, nil
+			}
+			still, reduced = img, r
+		}
+		m.ImageView = model.NewImageView(still, reduced)
+		rendered, err = p.renderView(&m.ImageView, cols, rows)
+	}
+	if err != nil {
+		log.Error("Failed to render image", "renderer", renderer.Name(), "error", err)
+		return m, nil
+	}
+
+	info, err := metadata.Read(item.Path)
+	if err != nil {
+		log.Warn("Failed to read metadata", "path", item.Path, "error", err)
+	}
+
 	m.ShowPreview = true
 	m.PreviewIsImage = true
-	m.Viewport = viewport.New(80, 40)
-	m.Viewport.SetContent(asciiArt)
+	m.PreviewUnsupported = false
+	m.PreviewTitle = item.Filename
+	m.PreviewPath = item.Path
+	m.PreviewMeta = info.Fields
+	m.PreviewImage = rendered
+	m.Viewport = viewport.New(cols, rows)
+	m.Viewport.SetContent(rendered.Text)
+	p.warmThumbnails(m, item)
+
+	cmd := emitImage(m, rendered)
+	if m.Animation.ID != 0 {
+		m.Animation.Rendered[0] = &rendered
+		return m, tea.Batch(cmd, m.Animation.Tick())
+	}
+	return m, cmd
+}
+
+// emitImage sends a rendered image's graphics to the terminal, placing
+// overlays over the preview's viewport
+func emitImage(m model.Model, rendered render.Image) tea.Cmd {
+	if rendered.Overlay {
+		x, y := imageOrigin(m)
+		return render.EmitAt(rendered.Setup, x, y)
+	}
+	return render.Emit(rendered.Setup)
+}
+
+// renderView renders the part of the preview's image that's in view at its
+// current zoom. It works from the image already in memory, so it's cheap
+// enough to run on every key press. The renderers fit what they're given
+// into the cells they get, so the cells are cut down to the region's size
+// at the view's scale, or 1:1 would blow small images up to fill the pane.
+func (p Previewer) renderView(v *model.ImageView, cols, rows int) (render.Image, error) {
+	renderer := p.renderer()
+	pw, ph := render.PixelBudget(renderer, cols, rows)
+	r, s := v.Region(pw, ph)
+	img := v.Source
+	if r != img.Bounds() {
+		img = imaging.Crop(img, r)
+	}
+	c, rr := render.CellsFor(renderer, int(math.Ceil(float64(r.Dx())*s)), int(math.Ceil(float64(r.Dy())*s)))
+	return renderer.Render(img, max(min(c, cols), 1), max(min(rr, rows), 1))
+}
+
+// redraw swaps the preview's image for a fresh render of its view
+func (p Previewer) redraw(m model.Model) (tea.Model, tea.Cmd) {
+	rendered, err := p.renderView(&m.ImageView, m.Viewport.Width, m.Viewport.Height)
+	if err != nil {
+		log.Error("Failed to render image", "error", err)
+		return m, nil
+	}
+
+	old := m.PreviewImage
+	m.PreviewImage = rendered
+	m.Viewport.SetContent(rendered.Text)
+	m.Viewport.GotoTop()
+
+	cmds := []tea.Cmd{render.Emit(old.Teardown)}
+	if old.Overlay {
+		// Wipe the old overlay before drawing the new one over it
+		render.Output.ClearOverlays()
+		cmds = append(cmds, tea.ClearScreen)
+	}
+	return m, tea.Sequence(tea.Batch(cmds...), emitImage(m, rendered))
+}
+
+// zoom handles the zoom keys: + and - step, = is one image pixel per
+// screen pixel and w fits the window
+func (p Previewer) zoom(m model.Model, key string) (tea.Model, tea.Cmd) {
+	pw, ph := render.PixelBudget(p.renderer(), m.Viewport.Width, m.Viewport.Height)
+	v := &m.ImageView
+	switch key {
+	case "+":
+		v.ZoomBy(1, pw, ph)
+	case "-":
+		v.ZoomBy(-1, pw, ph)
+	case "=":
+		v.Zoom = 1
+	case "w":
+		v.Zoom = 0
+	}
+
+	// An embedded preview runs out of detail past fit, so load the full
+	// image in the background, once
+	var load tea.Cmd
+	if v.Reduced && v.Zoom != 0 && !v.Loading {
+		v.Loading = true
+		path, zoom := m.PreviewPath, v.Zoom
+		load = func() tea.Msg {
+			img, err := imaging.Load(path)
+			return model.FullImageMsg{Path: path, Image: img, Err: err, Actual: key == "=", Zoom: zoom}
+		}
+	}
+	updated, cmd := p.redraw(m)
+	return updated, tea.Batch(cmd, load)
+}
+
+// FullImageLoaded swaps the full image in for the embedded preview that
+// was on show, keeping the view where it is
+func (p Previewer) FullImageLoaded(m model.Model, msg model.FullImageMsg) (tea.Model, tea.Cmd) {
+	v := &m.ImageView
+	v.Loading = false
+	v.Reduced = false
+	switch {
+	case msg.Err != nil:
+		log.Warn("Failed to load full image", "path", msg.Path, "error", msg.Err)
+		return m, nil
+	case msg.Image.Bounds().Dx() <= v.Source.Bounds().Dx():
+		return m, nil
+	case msg.Actual && v.Zoom == msg.Zoom:
+		// = asked for one image pixel per screen pixel of the real thing
+		v.Source = msg.Image
+	default:
+		v.Rescale(msg.Image)
+	}
+	return p.redraw(m)
+}
+
+// pan moves a zoomed-in view a fifth of the way across
+func (p Previewer) pan(m model.Model, key string) (tea.Model, tea.Cmd) {
+	pw, ph := render.PixelBudget(p.renderer(), m.Viewport.Width, m.Viewport.Height)
+	const step = 0.2
+	switch key {
+	case "up", "k":
+		m.ImageView.Pan(0, -step, pw, ph)
+	case "down", "j":
+		m.ImageView.Pan(0, step, pw, ph)
+	case "left", "h":
+		m.ImageView.Pan(-step, 0, pw, ph)
+	case "right", "l":
+		m.ImageView.Pan(step, 0, pw, ph)
+	}
+	return p.redraw(m)
+}
+
+// Animate shows the next frame of the previewed animation
+func (p Previewer) Animate(m model.Model, msg model.AnimationTickMsg) (tea.Model, tea.Cmd) {
+	if !m.Animation.Advance() {
+		// Out of plays, so stay on the last frame
+		m.Animation.Paused = true
+		return m, nil
+	}
+	return p.showFrame(m)
+}
+
+// showFrame puts the animation's current frame in the preview, rendering it
+// the first time round, and schedules the next one while playing
+func (p Previewer) showFrame(m model.Model) (tea.Model, tea.Cmd) {
+	a := &m.Animation
+	var cmds []tea.Cmd
+
+	rendered := a.Rendered[a.Frame]
+	if rendered == nil {
+		out, err := p.renderer().Render(a.Frames[a.Frame], m.Viewport.Width, m.Viewport.Height)
+		if err != nil {
+			log.Error("Failed to render frame", "frame", a.Frame, "error", err)
+			a.Paused = true
+			return m, nil
+		}
+		rendered = &out
+		a.Rendered[a.Frame] = rendered
+		// Images that stay in the terminal, like kitty's, only need
+		// sending once
+		if !out.Overlay {
+			cmds = append(cmds, render.Emit(out.Setup))
+		}
+	}
+
+	// Overlays are all drawn at the same spot, so the new frame replaces
+	// the last one
+	if rendered.Overlay {
+		x, y := imageOrigin(m)
+		cmds = append(cmds, render.EmitAt(rendered.Setup, x, y))
+	}
+
+	m.PreviewImage = *rendered
+	m.Viewport.SetContent(rendered.Text)
+	if a.Playing() {
+		cmds = append(cmds, a.Tick())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// handleUnsupportedPreview opens the preview for an image we can't decode
+// with a notice in place of the picture. The metadata panel stays open
+// since it's all there is to see.
+func (p Previewer) handleUnsupportedPreview(m model.Model, item defs.FileItem, err *imaging.UnsupportedError, cols, rows int) (tea.Model, tea.Cmd) {
+	info, metaErr := metadata.Read(item.Path)
+	if metaErr != nil {
+		log.Warn("Failed to read metadata", "path", item.Path, "error", metaErr)
+	}
+
+	notice := lipgloss.JoinVertical(lipgloss.Center,
+		err.Error(),
+		m.Styler.MutedStyle().Render("unsupported, metadata only"),
+	)
+
+	m.ShowPreview = true
+	m.PreviewIsImage = true
+	m.PreviewTitle = item.Filename
+	m.PreviewPath = item.Path
+	m.PreviewMeta = info.Fields
+	m.PreviewImage = render.Image{}
+	m.PreviewUnsupported = true
+	cols, rows = imagePaneSize(m)
+	m.Viewport = viewport.New(cols, rows)
+	m.Viewport.SetContent(lipgloss.Place(cols, rows, lipgloss.Center, lipgloss.Center, notice))
 	return m, nil
 }
 
+// showPath swaps whatever's in the preview for the image at path. It's how
+// slideshows and culling move between images, so a file that won't load
+// gets a notice rather than dropping out of the preview.
+func (p Previewer) showPath(m model.Model, path string) (model.Model, tea.Cmd) {
+	var teardown tea.Cmd
+	if m.ShowPreview {
+		teardown = m.ClosePreview()
+	}
+
+	item := defs.FileItem{Filename: filepath.Base(path), Path: path}
+	updated, cmd := p.handleImagePreview(m, item)
+	m = updated.(model.Model)
+
+	if !m.ShowPreview {
+		cols, rows := imagePaneSize(m)
+		m.ShowPreview = true
+		m.PreviewIsImage = true
+		m.PreviewTitle = item.Filename
+		m.PreviewPath = item.Path
+		m.Viewport.Width, m.Viewport.Height = cols, rows
+		m.Viewport.SetContent(lipgloss.Place(cols, rows, lipgloss.Center, lipgloss.Center, "Couldn't load this image"))
+	}
+	return m, tea.Batch(teardown, cmd)
+}
+
+// rerender draws the open image preview again, e.g. after the info panel
+// changed the room it has. A still image keeps its zoom and isn't decoded
+// again.
+func (p Previewer) rerender(m model.Model) (tea.Model, tea.Cmd) {
+	if m.ImageView.Source != nil {
+		cols, rows := imagePaneSize(m)
+		m.Viewport = viewport.New(cols, rows)
+		return p.redraw(m)
+	}
+
+	path := m.PreviewPath
+	teardown := m.ClosePreview()
+	item := defs.FileItem{Filename: filepath.Base(path), Path: path}
+	updated, cmd := p.handleImagePreview(m, item)
+	return updated, tea.Batch(teardown, cmd)
+}
+
+// Resize fits the open image preview to a new window size
+func (p Previewer) Resize(m model.Model) (tea.Model, tea.Cmd) {
+	if m.PreviewPath == "" {
+		return m, nil
+	}
+	return p.rerender(m)
+}
+
+// warmThumbnails queues thumbnails for the images around item, nearest
+// first, so stepping through the directory or opening the gallery is quick
+func (p Previewer) warmThumbnails(m model.Model, item defs.FileItem) {
+	if p.Pool == nil || m.ActiveColumn >= len(m.Columns) {
+		return
+	}
+
+	col := m.Columns[m.ActiveColumn]
+	selected := col.List.Index()
+	for i, listItem := range col.List.Items() {
+		if fileItem, ok := listItem.(defs.FileItem); ok && !fileItem.IsDir && fileItem.Path != item.Path && utils.IsImageFile(fileItem.Path) {
+			p.Pool.Submit(col.Path, fileItem.Path, abs(i-selected))
+		}
+	}
+}
+
+// imagePaneSize is the number of cells available to an image inside the
+// preview chrome
+func imagePaneSize(m model.Model) (int, int) {
+	w, h := m.Styler.ImagePreviewStyle().GetFrameSize()
+	if m.MetadataVisible() {
+		w += model.MetadataWidth
+	}
+	return max(m.WindowWidth-w, 10), max(m.WindowHeight-h-1, 5)
+}
+
+// imageOrigin is the screen cell where the image preview's viewport starts:
+// inside the preview border and padding, below the title line
+func imageOrigin(m model.Model) (int, int) {
+	style := m.Styler.ImagePreviewStyle()
+	x := style.GetMarginLeft() + style.GetBorderLeftSize() + style.GetPaddingLeft()
+	y := style.GetMarginTop() + style.GetBorderTopSize() + style.GetPaddingTop() + 1
+	return x, y
+}
+
 func handleFilePreview(m model.Model, item defs.FileItem) (tea.Model, tea.Cmd) {
 	content, err := os.ReadFile(item.Path)
 	if err != nil {
@@ -67,7 +480,9 @@ func handleFilePreview(m model.Model, item defs.FileItem) (tea.Model, tea.Cmd) {
 
 	m.ShowPreview = true
 	m.PreviewIsImage = false
-	m.PreviewContent = highlight.GetSyntaxHighlightedContent(content, item.Path)
+	m.PreviewTitle = item.Filename
+	m.PreviewPath = ""
+	m.PreviewContent = highlight.GetSyntaxHighlightedContent(content, item.Path, m.Styler.ChromaStyle())
 	m.Viewport = viewport.New(80, 40)
 	m.Viewport.SetContent(m.PreviewContent)
 	return m, nil
@@ -0,0 +1,125 @@
+package explorer
+
+import (
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/model"
+	"github.com/nooooaaaaah/photoboard/internal/utils"
+)
+
+// Slideshow shows one image after another through the previewer, so slides
+// get the same rendering, animation and metadata as a normal preview
+type Slideshow struct {
+	Previewer Previewer
+	Interval  time.Duration
+}
+
+func NewSlideshow(prev Previewer, interval time.Duration) Slideshow {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return Slideshow{Previewer: prev, Interval: interval}
+}
+
+// StartSlideshow runs over the selected images if there are any, otherwise
+// over the active column's images from the highlighted one
+func (s Slideshow) StartSlideshow(m model.Model) (tea.Model, tea.Cmd) {
+	paths, start := imageList(m)
+	if len(paths) == 0 {
+		m.StatusMsg = "No images for a slideshow"
+		return m, nil
+	}
+
+	m.Slideshow = model.NewSlideshow(paths, start, s.Interval)
+	return s.show(m)
+}
+
+// imageList is what slideshows and culling go through: the selected
+// images, or else the active column's images, along with where to start
+func imageList(m model.Model) ([]string, int) {
+	var paths []string
+	start := 0
+
+	if len(m.Selection) > 0 {
+		for path := range m.Selection {
+			if utils.IsImageFile(path) {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+	} else if m.ActiveColumn < len(m.Columns) {
+		col := m.Columns[m.ActiveColumn]
+		highlighted := ""
+		if item, ok := col.List.SelectedItem().(defs.FileItem); ok {
+			highlighted = item.Path
+		}
+		for _, item := range col.List.Items() {
+			if fileItem, ok := item.(defs.FileItem); ok && !fileItem.IsDir && utils.IsImageFile(fileItem.Path) {
+				if fileItem.Path == highlighted {
+					start = len(paths)
+				}
+				paths = append(paths, fileItem.Path)
+			}
+		}
+	}
+	return paths, start
+}
+
+func (s Slideshow) HandleSlideshowUpdate(m model.Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	show := &m.Slideshow
+	switch msg.String() {
+	case "esc", "q":
+		m.Slideshow = model.Slideshow{}
+		return m, m.ClosePreview()
+	case " ":
+		if show.Paused {
+			show.Paused = false
+			show.Restart()
+			return m, show.Tick()
+		}
+		show.Paused = true
+		return m, nil
+	case "right", "n":
+		if show.Step(1) {
+			show.Restart()
+			return s.show(m)
+		}
+		return m, nil
+	case "left", "b":
+		if show.Step(-1) {
+			show.Restart()
+			return s.show(m)
+		}
+		return m, nil
+	case "s":
+		show.ToggleShuffle()
+		return m, nil
+	case "r":
+		show.Loop = !show.Loop
+		return m, nil
+	}
+	// Zoom, the info panel and the rest work like in a normal preview
+	return s.Previewer.HandlePreviewUpdate(m, msg)
+}
+
+// NextSlide moves on when the interval is up, pausing on the last slide
+// when not looping
+func (s Slideshow) NextSlide(m model.Model, msg model.SlideshowTickMsg) (tea.Model, tea.Cmd) {
+	if !m.Slideshow.Step(1) {
+		m.Slideshow.Paused = true
+		return m, nil
+	}
+	return s.show(m)
+}
+
+// show puts the current slide in the preview and schedules the next one
+func (s Slideshow) show(m model.Model) (tea.Model, tea.Cmd) {
+	m, cmd := s.Previewer.showPath(m, m.Slideshow.Path())
+	if m.Slideshow.Paused {
+		return m, cmd
+	}
+	return m, tea.Batch(cmd, m.Slideshow.Tick())
+}
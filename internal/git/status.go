@@ -0,0 +1,172 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+)
+
+var ErrNotRepo = errors.New("not inside a git worktree")
+
+// Info is a snapshot of the git state for one directory
+type Info struct {
+	Root     string
+	Branch   string
+	statuses map[string]defs.GitStatus // absolute path -> status
+	dirs     map[string]bool           // entries git reported as whole directories
+	rollup   map[string]defs.GitStatus // directory -> highest non-ignored status below it
+}
+
+// Load runs git status scoped to dir. It is meant to be called off the UI
+// goroutine since it can take a while in large repositories.
+func Load(dir string) (Info, error) {
+	root, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return Info{}, ErrNotRepo
+	}
+
+	info := Info{
+		Root:   logicalPath(dir, filepath.Clean(strings.TrimSpace(string(root)))),
+		Branch: branch(dir),
+	}
+
+	out, err := run(dir, "status", "--porcelain=v1", "-z", "--ignored=matching", "--", ".")
+	if err != nil {
+		return info, err
+	}
+	info.parse(out)
+
+	return info, nil
+}
+
+// logicalPath maps a symlink-resolved path reported by git back onto the path
+// the user navigated through, so it lines up with FileItem paths.
+func logicalPath(dir, real string) string {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return real
+	}
+	rel, err := filepath.Rel(realDir, real)
+	if err != nil {
+		return real
+	}
+	return filepath.Join(dir, rel)
+}
+
+func branch(dir string) string {
+	if out, err := run(dir, "branch", "--show-current"); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+
+	// Detached HEAD
+	if out, err := run(dir, "rev-parse", "--short", "HEAD"); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	return ""
+}
+
+func run(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	return stdout.Bytes(), err
+}
+
+// parse reads `git status --porcelain=v1 -z` output. Paths are relative to
+// the repository root; renames carry the old path as an extra NUL field.
+func (i *Info) parse(out []byte) {
+	i.statuses = make(map[string]defs.GitStatus)
+	i.dirs = make(map[string]bool)
+	i.rollup = make(map[string]defs.GitStatus)
+
+	fields := strings.Split(string(out), "\x00")
+	for n := 0; n < len(fields); n++ {
+		entry := fields[n]
+		if len(entry) < 4 {
+			continue
+		}
+
+		x, y, rel := entry[0], entry[1], entry[3:]
+		if x == 'R' || x == 'C' {
+			n++ // skip the original path
+		}
+
+		var status defs.GitStatus
+		switch {
+		case x == '?' && y == '?':
+			status = defs.GitUntracked
+		case x == '!' && y == '!':
+			status = defs.GitIgnored
+		case x == 'U' || y == 'U' || y != ' ':
+			status = defs.GitModified
+		default:
+			status = defs.GitStaged
+		}
+
+		path := filepath.Join(i.Root, filepath.FromSlash(strings.TrimSuffix(rel, "/")))
+		if strings.HasSuffix(rel, "/") {
+			i.dirs[path] = true
+		}
+		if status > i.statuses[path] {
+			i.statuses[path] = status
+		}
+		if status != defs.GitIgnored {
+			i.rollUp(path, status)
+		}
+	}
+}
+
+// rollUp raises the rolled up status of every directory above path, up to
+// the root, to at least status
+func (i *Info) rollUp(path string, status defs.GitStatus) {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if i.rollup[dir] >= status {
+			return // so is everything above it
+		}
+		i.rollup[dir] = status
+		if dir == i.Root || dir == filepath.Dir(dir) {
+			return
+		}
+	}
+}
+
+// StatusOf returns the status of path. Directories roll up the highest status
+// of their children, except ignored children which don't make the parent
+// ignored.
+func (i Info) StatusOf(path string, isDir bool) defs.GitStatus {
+	if i.statuses == nil {
+		return defs.GitClean
+	}
+
+	if status, ok := i.statuses[path]; ok {
+		return status
+	}
+
+	// Inside an untracked or ignored directory reported as a whole
+	for dir := filepath.Dir(path); len(dir) >= len(i.Root) && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if i.dirs[dir] {
+			return i.statuses[dir]
+		}
+	}
+
+	if !isDir {
+		return defs.GitClean
+	}
+	return i.rollup[path]
+}
+
+// Decorate returns a copy of item with its git status filled in
+func (i Info) Decorate(item defs.FileItem) defs.FileItem {
+	if item.Filename == ".." {
+		return item
+	}
+	item.GitStatus = i.StatusOf(item.Path, item.IsDir)
+	return item
+}
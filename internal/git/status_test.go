@@ -0,0 +1,73 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+)
+
+func TestParse(t *testing.T) {
+	out := " M photos/a.jpg\x00" +
+		"M  photos/b.jpg\x00" +
+		"R  photos/new.jpg\x00photos/old.jpg\x00" +
+		"RM renamed.jpg\x00was.jpg\x00" +
+		"A  staged/deep/c.jpg\x00" +
+		"UU conflict.jpg\x00" +
+		"?? untracked/\x00" +
+		"?? loose.png\x00" +
+		"!! build/\x00" +
+		"!! photos/cache.db\x00"
+
+	info := Info{Root: "/repo"}
+	info.parse([]byte(out))
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  defs.GitStatus
+	}{
+		{"/repo/photos/a.jpg", false, defs.GitModified},
+		{"/repo/photos/b.jpg", false, defs.GitStaged},
+		{"/repo/photos/new.jpg", false, defs.GitStaged},
+		{"/repo/photos/old.jpg", false, defs.GitClean},
+		{"/repo/renamed.jpg", false, defs.GitModified},
+		{"/repo/was.jpg", false, defs.GitClean},
+		{"/repo/conflict.jpg", false, defs.GitModified},
+		{"/repo/loose.png", false, defs.GitUntracked},
+		{"/repo/clean.jpg", false, defs.GitClean},
+		{"/repo/photos/cache.db", false, defs.GitIgnored},
+
+		// Whole directories and what's inside them
+		{"/repo/untracked", true, defs.GitUntracked},
+		{"/repo/untracked/x.jpg", false, defs.GitUntracked},
+		{"/repo/untracked/sub", true, defs.GitUntracked},
+		{"/repo/build", true, defs.GitIgnored},
+		{"/repo/build/out.jpg", false, defs.GitIgnored},
+
+		// Rolled up from children; ignored children don't count
+		{"/repo/photos", true, defs.GitModified},
+		{"/repo/staged", true, defs.GitStaged},
+		{"/repo/staged/deep", true, defs.GitStaged},
+		{"/repo/clean", true, defs.GitClean},
+		{"/repo", true, defs.GitModified},
+	}
+	for _, tt := range tests {
+		if got := info.StatusOf(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("StatusOf(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseIgnoredOnly(t *testing.T) {
+	info := Info{Root: "/repo"}
+	info.parse([]byte("!! photos/cache.db\x00"))
+	if got := info.StatusOf("/repo/photos", true); got != defs.GitClean {
+		t.Errorf("directory with only ignored children = %v, want clean", got)
+	}
+}
+
+func TestStatusOfUnloaded(t *testing.T) {
+	if got := (Info{}).StatusOf("/repo/a.jpg", false); got != defs.GitClean {
+		t.Errorf("got %v, want clean", got)
+	}
+}
@@ -0,0 +1,180 @@
+package icons
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+)
+
+type kind int
+
+const (
+	kindFile kind = iota
+	kindDir
+	kindParent
+	kindSymlink
+	kindExec
+	kindImage
+	kindVideo
+	kindAudio
+	kindArchive
+	kindDocument
+	kindText
+	kindConfig
+	kindCode
+	kindGit
+	kindLock
+)
+
+// Set maps file kinds and specific extensions/names to glyphs
+type Set struct {
+	Kinds      map[kind]string
+	Extensions map[string]string
+	Names      map[string]string
+}
+
+var NerdFont = Set{
+	Kinds: map[kind]string{
+		kindFile:     "\uf15b",
+		kindDir:      "\uf07b",
+		kindParent:   "\uf07c",
+		kindSymlink:  "\uf481",
+		kindExec:     "\uf489",
+		kindImage:    "\uf1c5",
+		kindVideo:    "\uf03d",
+		kindAudio:    "\uf001",
+		kindArchive:  "\uf410",
+		kindDocument: "\uf1c1",
+		kindText:     "\uf15c",
+		kindConfig:   "\ue615",
+		kindCode:     "\uf121",
+		kindGit:      "\ue702",
+		kindLock:     "\uf023",
+	},
+	Extensions: map[string]string{
+		".go":   "\ue627",
+		".md":   "\ue609",
+		".json": "\ue60b",
+		".js":   "\ue74e",
+		".ts":   "\ue628",
+		".py":   "\ue606",
+		".rs":   "\ue7a8",
+		".html": "\ue736",
+		".css":  "\ue749",
+		".sh":   "\uf489",
+		".pdf":  "\uf1c1",
+	},
+	Names: map[string]string{
+		"Dockerfile": "\uf308",
+		".git":       "\ue5fb",
+		"LICENSE":    "\uf2c2",
+	},
+}
+
+var ASCII = Set{
+	Kinds: map[kind]string{
+		kindFile:     "-",
+		kindDir:      ">",
+		kindParent:   "<",
+		kindSymlink:  "@",
+		kindExec:     "*",
+		kindImage:    "i",
+		kindVideo:    "v",
+		kindAudio:    "a",
+		kindArchive:  "z",
+		kindDocument: "d",
+		kindText:     "t",
+		kindConfig:   "~",
+		kindCode:     "c",
+		kindGit:      "g",
+		kindLock:     "#",
+	},
+}
+
+// None keeps the original look: an arrow for directories, blank otherwise
+var None = Set{
+	Kinds: map[kind]string{
+		kindFile: " ",
+		kindDir:  "▶",
+	},
+}
+
+var extensionKinds = map[string]kind{
+	".png": kindImage, ".jpg": kindImage, ".jpeg": kindImage, ".gif": kindImage,
+	".webp": kindImage, ".bmp": kindImage, ".tif": kindImage, ".tiff": kindImage,
+	".svg": kindImage, ".heic": kindImage, ".avif": kindImage, ".ico": kindImage,
+	".mp4": kindVideo, ".mkv": kindVideo, ".mov": kindVideo, ".webm": kindVideo, ".avi": kindVideo,
+	".mp3": kindAudio, ".flac": kindAudio, ".wav": kindAudio, ".ogg": kindAudio, ".m4a": kindAudio,
+	".zip": kindArchive, ".tar": kindArchive, ".gz": kindArchive, ".xz": kindArchive,
+	".7z": kindArchive, ".rar": kindArchive, ".zst": kindArchive, ".bz2": kindArchive,
+	".pdf": kindDocument, ".doc": kindDocument, ".docx": kindDocument, ".odt": kindDocument,
+	".txt": kindText, ".md": kindText, ".rst": kindText, ".log": kindText,
+	".json": kindConfig, ".yaml": kindConfig, ".yml": kindConfig, ".toml": kindConfig,
+	".ini": kindConfig, ".conf": kindConfig, ".xml": kindConfig, ".xmp": kindConfig,
+	".go": kindCode, ".rs": kindCode, ".py": kindCode, ".js": kindCode, ".ts": kindCode,
+	".c": kindCode, ".h": kindCode, ".cpp": kindCode, ".java": kindCode, ".rb": kindCode,
+	".sh": kindCode, ".html": kindCode, ".css": kindCode, ".lua": kindCode,
+	".lock": kindLock,
+}
+
+var nameKinds = map[string]kind{
+	".gitignore":     kindGit,
+	".gitattributes": kindGit,
+	".gitmodules":    kindGit,
+	".git":           kindGit,
+	"go.sum":         kindLock,
+	"Makefile":       kindConfig,
+}
+
+// Sets lists the icon sets selectable from config
+var Sets = map[string]Set{
+	"nerd":  NerdFont,
+	"ascii": ASCII,
+	"none":  None,
+}
+
+func classify(item defs.FileItem) kind {
+	if item.Filename == ".." {
+		return kindParent
+	}
+	if k, ok := nameKinds[item.Filename]; ok {
+		return k
+	}
+	if item.Mode&fs.ModeSymlink != 0 {
+		return kindSymlink
+	}
+	if item.IsDir {
+		return kindDir
+	}
+	if k, ok := extensionKinds[strings.ToLower(filepath.Ext(item.Filename))]; ok {
+		return k
+	}
+	if item.Mode.IsRegular() && item.Mode.Perm()&0o111 != 0 {
+		return kindExec
+	}
+	return kindFile
+}
+
+// Icon resolves the glyph for item, preferring an exact name, then the
+// extension, then the file kind.
+func (s Set) Icon(item defs.FileItem) string {
+	if icon, ok := s.Names[item.Filename]; ok {
+		return icon
+	}
+	if !item.IsDir {
+		if icon, ok := s.Extensions[strings.ToLower(filepath.Ext(item.Filename))]; ok {
+			return icon
+		}
+	}
+
+	k := classify(item)
+	if icon, ok := s.Kinds[k]; ok {
+		return icon
+	}
+	if item.IsDir || k == kindParent {
+		return s.Kinds[kindDir]
+	}
+	return s.Kinds[kindFile]
+}
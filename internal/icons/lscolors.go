@@ -0,0 +1,207 @@
+package icons
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+)
+
+// LSColors holds the parsed LS_COLORS database
+type LSColors struct {
+	types map[string]lipgloss.Style // di, ln, ex, fi, ...
+	exts  map[string]lipgloss.Style // lowercased "*.ext" patterns, by extension
+	// Lowercased "*name" and "*.tar.gz" style patterns that aren't a plain
+	// extension, longest first
+	suffixes []suffixStyle
+}
+
+type suffixStyle struct {
+	suffix string
+	style  lipgloss.Style
+}
+
+// LoadLSColors parses $LS_COLORS, falling back to the output of `dircolors`
+// when the variable is unset, the same way ls gets its defaults.
+func LoadLSColors() *LSColors {
+	spec := os.Getenv("LS_COLORS")
+	if spec == "" {
+		spec = dircolorsDefault()
+	}
+	return ParseLSColors(spec)
+}
+
+func dircolorsDefault() string {
+	out, err := exec.Command("dircolors", "-b").Output()
+	if err != nil {
+		return ""
+	}
+
+	// Output looks like: LS_COLORS='rs=0:di=01;34:...';\nexport LS_COLORS
+	s := string(out)
+	start := strings.Index(s, "'")
+	end := strings.LastIndex(s, "'")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return s[start+1 : end]
+}
+
+func ParseLSColors(spec string) *LSColors {
+	c := &LSColors{
+		types: make(map[string]lipgloss.Style),
+		exts:  make(map[string]lipgloss.Style),
+	}
+
+	suffixes := make(map[string]lipgloss.Style)
+	for _, entry := range strings.Split(spec, ":") {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || value == "" {
+			continue
+		}
+		style := sgrStyle(value)
+		if !strings.HasPrefix(key, "*") {
+			c.types[key] = style
+			continue
+		}
+		suffix := strings.ToLower(key[1:])
+		if filepath.Ext(suffix) == suffix && suffix != "" {
+			c.exts[suffix] = style
+		} else {
+			suffixes[suffix] = style
+		}
+	}
+
+	for suffix, style := range suffixes {
+		c.suffixes = append(c.suffixes, suffixStyle{suffix, style})
+	}
+	sort.Slice(c.suffixes, func(i, j int) bool {
+		return len(c.suffixes[i].suffix) > len(c.suffixes[j].suffix)
+	})
+	return c
+}
+
+// Style returns the LS_COLORS style for item and whether one was defined
+func (c *LSColors) Style(item defs.FileItem) (lipgloss.Style, bool) {
+	if c == nil {
+		return lipgloss.NewStyle(), false
+	}
+
+	var key string
+	switch {
+	case item.Mode&fs.ModeSymlink != 0:
+		key = "ln"
+		if item.Orphan {
+			key = "or"
+		}
+	case item.IsDir:
+		key = "di"
+	case item.Mode&fs.ModeNamedPipe != 0:
+		key = "pi"
+	case item.Mode&fs.ModeSocket != 0:
+		key = "so"
+	case item.Mode&fs.ModeDevice != 0 && item.Mode&fs.ModeCharDevice != 0:
+		key = "cd"
+	case item.Mode&fs.ModeDevice != 0:
+		key = "bd"
+	case item.Mode.Perm()&0o111 != 0:
+		key = "ex"
+	}
+
+	if key != "" {
+		if style, ok := c.types[key]; ok {
+			return style, true
+		}
+	}
+
+	// Suffix patterns apply to regular files; the longest match wins
+	if !item.IsDir {
+		name := strings.ToLower(item.Filename)
+		ext := filepath.Ext(name)
+		extStyle, extOK := c.exts[ext]
+		for _, s := range c.suffixes {
+			if extOK && len(s.suffix) <= len(ext) {
+				break
+			}
+			if strings.HasSuffix(name, s.suffix) {
+				return s.style, true
+			}
+		}
+		if extOK {
+			return extStyle, true
+		}
+	}
+
+	if style, ok := c.types["fi"]; ok && !item.IsDir {
+		return style, true
+	}
+	return lipgloss.NewStyle(), false
+}
+
+// sgrStyle converts an SGR parameter string like "01;38;5;208" to a style
+func sgrStyle(sgr string) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	parts := strings.Split(sgr, ";")
+
+	for i := 0; i < len(parts); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case n == 1:
+			style = style.Bold(true)
+		case n == 3:
+			style = style.Italic(true)
+		case n == 4:
+			style = style.Underline(true)
+		case n >= 30 && n <= 37:
+			style = style.Foreground(lipgloss.Color(strconv.Itoa(n - 30)))
+		case n >= 90 && n <= 97:
+			style = style.Foreground(lipgloss.Color(strconv.Itoa(n - 90 + 8)))
+		case n >= 40 && n <= 47:
+			style = style.Background(lipgloss.Color(strconv.Itoa(n - 40)))
+		case n == 38 || n == 48:
+			color, skip := extendedColor(parts[i+1:])
+			i += skip
+			if color == "" {
+				continue
+			}
+			if n == 38 {
+				style = style.Foreground(lipgloss.Color(color))
+			} else {
+				style = style.Background(lipgloss.Color(color))
+			}
+		}
+	}
+	return style
+}
+
+// extendedColor parses the arguments following 38/48: "5;n" or "2;r;g;b"
+func extendedColor(args []string) (string, int) {
+	if len(args) >= 2 && args[0] == "5" {
+		return args[1], 2
+	}
+	if len(args) >= 4 && args[0] == "2" {
+		r, _ := strconv.Atoi(args[1])
+		g, _ := strconv.Atoi(args[2])
+		b, _ := strconv.Atoi(args[3])
+		return "#" + hexByte(r) + hexByte(g) + hexByte(b), 4
+	}
+	return "", 0
+}
+
+func hexByte(v int) string {
+	s := strconv.FormatInt(int64(v&0xff), 16)
+	if len(s) == 1 {
+		s = "0" + s
+	}
+	return s
+}
@@ -0,0 +1,245 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats Sniff can tell apart. Raw covers the camera formats whose magic
+// isn't plain TIFF; the TIFF-based ones are recognised by extension.
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+	FormatBMP  = "bmp"
+	FormatTIFF = "tiff"
+	FormatSVG  = "svg"
+	FormatHEIC = "heic"
+	FormatAVIF = "avif"
+	FormatRaw  = "raw"
+)
+
+// UnsupportedError means the file is an image, just not one we can decode
+type UnsupportedError struct {
+	Format string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s images can't be decoded", strings.ToUpper(e.Format))
+}
+
+var extensionFormats = map[string]string{
+	".png": FormatPNG, ".jpg": FormatJPEG, ".jpeg": FormatJPEG, ".gif": FormatGIF,
+	".webp": FormatWebP, ".bmp": FormatBMP, ".tif": FormatTIFF, ".tiff": FormatTIFF,
+	".svg": FormatSVG, ".heic": FormatHEIC, ".heif": FormatHEIC, ".avif": FormatAVIF,
+	".cr2": FormatRaw, ".nef": FormatRaw, ".nrw": FormatRaw, ".arw": FormatRaw,
+	".dng": FormatRaw, ".orf": FormatRaw, ".rw2": FormatRaw, ".raf": FormatRaw,
+	".pef": FormatRaw, ".srw": FormatRaw, ".cr3": FormatRaw,
+}
+
+// sniffSize covers every magic number below, plus enough of an SVG's XML
+// prolog and comments to reach the <svg element
+const sniffSize = 1024
+
+// Format returns the image format of the file at path from its first bytes,
+// falling back to the extension when the content isn't recognised. Raws
+// that are TIFF inside keep their extension's answer.
+func Format(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return extensionFormats[strings.ToLower(filepath.Ext(path))]
+	}
+	defer f.Close()
+	return detect(f, path)
+}
+
+func detect(f *os.File, path string) string {
+	byExt := extensionFormats[strings.ToLower(filepath.Ext(path))]
+	head := make([]byte, sniffSize)
+	n, _ := f.ReadAt(head, 0)
+	sniffed := Sniff(head[:n])
+
+	if sniffed == "" || (sniffed == FormatTIFF && byExt == FormatRaw) {
+		return byExt
+	}
+	return sniffed
+}
+
+// Sniff identifies an image format from the head of a file
+func Sniff(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return FormatJPEG
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return FormatGIF
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return FormatWebP
+	case isBMP(head):
+		return FormatBMP
+	case bytes.HasPrefix(head, []byte("II*\x00")) && len(head) >= 10 && string(head[8:10]) == "CR":
+		return FormatRaw
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return FormatTIFF
+	case bytes.HasPrefix(head, []byte("IIRO")), bytes.HasPrefix(head, []byte("IIRS")),
+		bytes.HasPrefix(head, []byte("IIU\x00")), bytes.HasPrefix(head, []byte("FUJIFILMCCD-RAW")):
+		return FormatRaw
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		return sniffISOBMFF(head)
+	case isSVG(head):
+		return FormatSVG
+	}
+	return ""
+}
+
+// isBMP checks the BM magic along with the header fields after it: the
+// size of the info header, which only comes in a few sizes, and a pixel
+// data offset that lands past it
+func isBMP(head []byte) bool {
+	if len(head) < 18 || !bytes.HasPrefix(head, []byte("BM")) {
+		return false
+	}
+	offset := binary.LittleEndian.Uint32(head[10:14])
+	info := binary.LittleEndian.Uint32(head[14:18])
+	switch info {
+	case 12, 16, 40, 52, 56, 64, 108, 124:
+	default:
+		return false
+	}
+	return offset >= 14+info && offset < 1<<24
+}
+
+// sniffISOBMFF tells HEIC, AVIF and Canon's CR3 apart by their brands
+func sniffISOBMFF(head []byte) string {
+	brands := []string{string(head[8:12])}
+	size := int(head[0])<<24 | int(head[1])<<16 | int(head[2])<<8 | int(head[3])
+	for i := 16; i+4 <= size && i+4 <= len(head); i += 4 {
+		brands = append(brands, string(head[i:i+4]))
+	}
+
+	has := func(want ...string) bool {
+		for _, brand := range brands {
+			for _, w := range want {
+				if brand == w {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	// mif1 is shared by HEIC and AVIF, so look for the specific brands first
+	switch {
+	case has("crx "):
+		return FormatRaw
+	case has("avif", "avis"):
+		return FormatAVIF
+	case has("heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1"):
+		return FormatHEIC
+	}
+	return ""
+}
+
+// isSVG checks that the first element, after any XML declaration, comments
+// and doctype, is <svg. HTML with inline SVG doesn't count.
+func isSVG(head []byte) bool {
+	text := head
+	for {
+		text = bytes.TrimLeft(text, " \t\r\n\ufeff")
+		var end []byte
+		switch {
+		case bytes.HasPrefix(text, []byte("<?")):
+			end = []byte("?>")
+		case bytes.HasPrefix(text, []byte("<!--")):
+			end = []byte("-->")
+		case bytes.HasPrefix(text, []byte("<!")):
+			end = []byte(">")
+		default:
+			return bytes.HasPrefix(text, []byte("<svg"))
+		}
+		i := bytes.Index(text, end)
+		if i < 0 {
+			return false
+		}
+		text = text[i+len(end):]
+	}
+}
+
+// IsImage reports whether path is an image, by extension or, for files
+// with no extension or one that could still be an image, by content
+func IsImage(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, ok := extensionFormats[ext]; ok {
+		return true
+	}
+	if !worthSniffing(ext) {
+		return false
+	}
+	return sniffedFormat(path) != ""
+}
+
+// notImages are common extensions of files that are never images, for
+// systems whose MIME database doesn't know them
+var notImages = map[string]bool{
+	".txt": true, ".md": true, ".log": true, ".json": true, ".yaml": true, ".yml": true,
+	".toml": true, ".xml": true, ".html": true, ".css": true, ".js": true, ".ts": true,
+	".go": true, ".py": true, ".rs": true, ".c": true, ".h": true, ".sh": true,
+	".mod": true, ".sum": true, ".pdf": true, ".zip": true, ".gz": true, ".tar": true,
+	".mp3": true, ".mp4": true, ".mov": true, ".xmp": true,
+}
+
+// worthSniffing reports whether a file with extension ext might be an
+// image despite it: there's no extension, or nothing knows it as anything
+// else
+func worthSniffing(ext string) bool {
+	if ext == "" {
+		return true
+	}
+	if notImages[ext] {
+		return false
+	}
+	typ := mime.TypeByExtension(ext)
+	return typ == "" || strings.HasPrefix(typ, "image/")
+}
+
+type sniffResult struct {
+	size    int64
+	modTime time.Time
+	format  string
+}
+
+// sniffCache holds what sniffedFormat found, by path, for as long as the
+// file's size and modification time stay the same
+var sniffCache sync.Map
+
+// sniffedFormat is Format for regular files, remembered so listing a
+// directory again doesn't open every file in it again
+func sniffedFormat(path string) string {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	if v, ok := sniffCache.Load(path); ok {
+		if r := v.(sniffResult); r.size == info.Size() && r.modTime.Equal(info.ModTime()) {
+			return r.format
+		}
+	}
+	format := Format(path)
+	sniffCache.Store(path, sniffResult{size: info.Size(), modTime: info.ModTime(), format: format})
+	return format
+}
+
+// Decodable reports whether images of format can be turned into pixels.
+// Unknown formats are worth a try with whatever decoders are registered.
+func Decodable(format string) bool {
+	return format != FormatHEIC && format != FormatAVIF
+}
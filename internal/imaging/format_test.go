@@ -0,0 +1,109 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bmpHeader is the start of a BMP with a 40 byte info header
+func bmpHeader(offset, info uint32) []byte {
+	head := make([]byte, 54)
+	copy(head, "BM")
+	binary.LittleEndian.PutUint32(head[2:], 54)
+	binary.LittleEndian.PutUint32(head[10:], offset)
+	binary.LittleEndian.PutUint32(head[14:], info)
+	return head
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), FormatPNG},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), FormatJPEG},
+		{"gif87", []byte("GIF87a..."), FormatGIF},
+		{"gif89", []byte("GIF89a..."), FormatGIF},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), FormatWebP},
+		{"riff but wav", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), ""},
+		{"bmp", bmpHeader(54, 40), FormatBMP},
+		{"bmp v5", bmpHeader(138, 124), FormatBMP},
+		{"bmp with a bad info size", bmpHeader(54, 41), ""},
+		{"bmp with pixels inside the header", bmpHeader(20, 40), ""},
+		{"text starting with BM", []byte("BMW owners club meeting notes"), ""},
+		{"short BM", []byte("BM\x00\x00"), ""},
+		{"tiff le", []byte("II*\x00\x08\x00\x00\x00"), FormatTIFF},
+		{"tiff be", []byte("MM\x00*\x00\x00\x00\x08"), FormatTIFF},
+		{"cr2", []byte("II*\x00\x10\x00\x00\x00CR\x02\x00"), FormatRaw},
+		{"orf", []byte("IIRO\x08\x00\x00\x00"), FormatRaw},
+		{"raf", []byte("FUJIFILMCCD-RAW 0201"), FormatRaw},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic"), FormatHEIC},
+		{"avif", []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1miaf"), FormatAVIF},
+		{"avif brand after mif1", []byte("\x00\x00\x00\x1cftypmif1\x00\x00\x00\x00mif1avifmiaf"), FormatAVIF},
+		{"cr3", []byte("\x00\x00\x00\x18ftypcrx \x00\x00\x00\x01crx isom"), FormatRaw},
+		{"mp4", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2"), ""},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), FormatSVG},
+		{"svg with prolog", []byte("\ufeff<?xml version=\"1.0\"?>\n<!-- made by hand -->\n<!DOCTYPE svg>\n<svg>"), FormatSVG},
+		{"html with inline svg", []byte("<html><svg></svg></html>"), ""},
+		{"unterminated comment", []byte("<!-- <svg>"), ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sniff(tt.head); got != tt.want {
+				t.Errorf("Sniff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	dir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		// Known image extensions aren't opened
+		{filepath.Join(dir, "missing.jpg"), true},
+		{write("no-extension", png), true},
+		{write("odd.blob", png), true},
+		{write("notes", []byte("just text")), false},
+		// Files that are something else by extension aren't sniffed
+		{write("readme.txt", png), false},
+		{write("page.html", png), false},
+		{dir, false},
+	}
+	for _, tt := range tests {
+		if got := IsImage(tt.path); got != tt.want {
+			t.Errorf("IsImage(%s) = %v, want %v", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+}
+
+func TestIsImageNoticesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if IsImage(path) {
+		t.Fatal("text is an image")
+	}
+	if err := os.WriteFile(path, []byte("GIF89a and then some"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !IsImage(path) {
+		t.Error("still not an image after it was rewritten as one")
+	}
+}
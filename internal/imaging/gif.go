@@ -0,0 +1,103 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"image/gif"
+	"os"
+	"time"
+)
+
+// maxFrames and maxFramesBytes cap what compositing adds on top of the
+// decoded GIF, so a pathological one still plays what fits instead of
+// using up all memory. They can't help with gif.DecodeAll itself, which
+// has decoded every frame by the time they're checked.
+const (
+	maxFrames      = 1000
+	maxFramesBytes = 256 << 20
+)
+
+// Frames is a decoded animation. Every frame is already composited onto
+// the full canvas, so it can be shown on its own.
+type Frames struct {
+	Images []image.Image
+	Delays []time.Duration
+	// Plays is how many times the animation runs, 0 meaning forever
+	Plays int
+	// Scaled means the frames are smaller than the GIF's canvas
+	Scaled bool
+}
+
+// LoadFrames decodes every frame of the GIF at path, applying each frame's
+// disposal method, and scales them to fit in w x h
+func LoadFrames(path string, w, h int) (*Frames, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	fw, fh := g.Config.Width, g.Config.Height
+	if w > 0 && h > 0 && (fw > w || fh > h) {
+		fw, fh = Fit(fw, fh, w, h, 1)
+	}
+
+	// Every composited frame is a full RGBA copy of the canvas
+	limit := min(len(g.Image), maxFrames, max(maxFramesBytes/max(fw*fh*4, 1), 1))
+
+	out := &Frames{Scaled: fw != g.Config.Width || fh != g.Config.Height}
+	switch {
+	case g.LoopCount == 0:
+		out.Plays = 0
+	case g.LoopCount < 0:
+		out.Plays = 1
+	default:
+		out.Plays = g.LoopCount + 1
+	}
+
+	for i, frame := range g.Image[:limit] {
+
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if fw == canvas.Rect.Dx() && fh == canvas.Rect.Dy() {
+			out.Images = append(out.Images, cloneRGBA(canvas))
+		} else {
+			out.Images = append(out.Images, Resize(canvas, fw, fh))
+		}
+
+		// Browsers treat tiny delays as 100ms, and so do GIF authors
+		delay := time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		if delay <= 10*time.Millisecond {
+			delay = 100 * time.Millisecond
+		}
+		out.Delays = append(out.Delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return out, nil
+}
+
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	out := image.NewRGBA(img.Rect)
+	copy(out.Pix, img.Pix)
+	return out
+}
@@ -0,0 +1,77 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeGIF(t *testing.T, w, h, frames, loop int) string {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White, color.RGBA{255, 0, 0, 255}}
+	g := &gif.GIF{LoopCount: loop}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+		frame.SetColorIndex(i%w, 0, uint8(1+i%2))
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, i*5)
+	}
+
+	path := filepath.Join(t.TempDir(), "a.gif")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := gif.EncodeAll(f, g); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		loop   int
+		w, h   int
+		plays  int
+		scaled bool
+	}{
+		{"forever", 0, 100, 100, 0, false},
+		{"once", -1, 100, 100, 1, false},
+		{"three times", 2, 100, 100, 3, false},
+		{"scaled down", 0, 20, 10, 0, true},
+	}
+	for _, tt := range tests {
+		frames, err := LoadFrames(writeGIF(t, 40, 20, 3, tt.loop), tt.w, tt.h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(frames.Images) != 3 || frames.Plays != tt.plays || frames.Scaled != tt.scaled {
+			t.Errorf("%s: got %d frames, %d plays, scaled %v", tt.name, len(frames.Images), frames.Plays, frames.Scaled)
+		}
+		if tt.scaled && frames.Images[0].Bounds().Dx() != 20 {
+			t.Errorf("%s: frames are %v", tt.name, frames.Images[0].Bounds())
+		}
+		// Tiny delays play at 100ms, like browsers do
+		if want := []time.Duration{100 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond}; !slices.Equal(frames.Delays, want) {
+			t.Errorf("%s: delays %v", tt.name, frames.Delays)
+		}
+	}
+}
+
+func TestLoadFramesBudget(t *testing.T) {
+	// A 2000x2000 canvas is 16MB a frame, so only 16 of them fit
+	frames, err := LoadFrames(writeGIF(t, 2000, 2000, 20, 0), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := maxFramesBytes / (2000 * 2000 * 4); len(frames.Images) != want {
+		t.Errorf("kept %d frames, want %d", len(frames.Images), want)
+	}
+}
@@ -0,0 +1,50 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Load decodes the image at path with whatever decoders are registered.
+// Files no decoder understands, like camera raws, fall back to their
+// largest embedded preview.
+func Load(path string) (image.Image, error) {
+	return LoadSize(path, 0, 0)
+}
+
+// toRGBA returns img as premultiplied RGBA, converting only when needed
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// Fit returns the largest width/height with the aspect ratio of w x h that
+// fits in maxW x maxH. pixelAspect is the height/width ratio of one target
+// pixel, e.g. 1.0 for square pixels.
+func Fit(w, h, maxW, maxH int, pixelAspect float64) (int, int) {
+	if w <= 0 || h <= 0 || maxW <= 0 || maxH <= 0 {
+		return 0, 0
+	}
+	if pixelAspect <= 0 {
+		pixelAspect = 1
+	}
+
+	dw := maxW
+	dh := int(float64(dw)*float64(h)/(float64(w)*pixelAspect) + 0.5)
+	if dh > maxH {
+		dh = maxH
+		dw = int(float64(dh)*float64(w)*pixelAspect/float64(h) + 0.5)
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+	return dw, dh
+}
@@ -0,0 +1,520 @@
+package imaging
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// errNotBaseline means decodeJPEGDC can't handle the file and the full
+// decoder has to
+var errNotBaseline = errors.New("jpeg: not a baseline file")
+
+// decodeJPEGDC decodes a baseline JPEG at an eighth of its size, one pixel
+// per 8x8 block. A block's DC coefficient is the average of its pixels, so
+// the AC coefficients only need skipping over: no IDCT, no upsampling and a
+// 64th of the memory. Progressive, arithmetic coded, 12-bit and CMYK files
+// give errNotBaseline.
+func decodeJPEGDC(r io.Reader) (image.Image, error) {
+	d := &dcDecoder{r: bufio.NewReaderSize(r, 32<<10), transform: true}
+	var soi [2]byte
+	if _, err := io.ReadFull(d.r, soi[:]); err != nil {
+		return nil, err
+	}
+	if soi != [2]byte{0xFF, 0xD8} {
+		return nil, errors.New("jpeg: missing SOI marker")
+	}
+
+	for {
+		marker, err := d.nextMarker()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case marker == 0xD9:
+			return nil, errors.New("jpeg: no image data")
+		case marker == 0xDA:
+			if err := d.scan(); err != nil {
+				return nil, err
+			}
+			return d.image(), nil
+		case marker >= 0xD0 && marker <= 0xD7, marker == 0x01:
+			// Markers without a length
+			continue
+		}
+
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case marker == 0xC0, marker == 0xC1:
+			err = d.frame(n)
+		case marker >= 0xC2 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC:
+			err = errNotBaseline
+		case marker == 0xC4:
+			err = d.huffmanTables(n)
+		case marker == 0xDB:
+			err = d.quantTables(n)
+		case marker == 0xDD:
+			err = d.restartInterval(n)
+		case marker == 0xEE:
+			err = d.adobe(n)
+		default:
+			_, err = d.r.Discard(n)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type dcComponent struct {
+	id     byte
+	h, v   int
+	tq     byte
+	dc, ac byte
+	// plane holds one sample per block, stride blocks across
+	plane  []uint8
+	stride int
+	pred   int32
+}
+
+type dcDecoder struct {
+	r             *bufio.Reader
+	width, height int
+	comps         []dcComponent
+	quant         [4]uint16
+	dcTables      [4]*huffman
+	acTables      [4]*huffman
+	restart       int
+	// transform is false when an Adobe marker says three components are
+	// RGB rather than YCbCr
+	transform bool
+	bits      bitReader
+}
+
+func (d *dcDecoder) nextMarker() (byte, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if c != 0xFF {
+		return 0, errors.New("jpeg: expected a marker")
+	}
+	for c == 0xFF {
+		if c, err = d.r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return c, nil
+}
+
+// length reads a segment's length, less the two bytes of the length itself
+func (d *dcDecoder) length() (int, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(d.r, b[:]); err != nil {
+		return 0, err
+	}
+	n := int(b[0])<<8 | int(b[1]) - 2
+	if n < 0 {
+		return 0, errors.New("jpeg: bad segment length")
+	}
+	return n, nil
+}
+
+func (d *dcDecoder) segment(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(d.r, b)
+	return b, err
+}
+
+func (d *dcDecoder) frame(n int) error {
+	b, err := d.segment(n)
+	if err != nil {
+		return err
+	}
+	if len(b) < 6 || b[0] != 8 {
+		return errNotBaseline
+	}
+	d.height = int(b[1])<<8 | int(b[2])
+	d.width = int(b[3])<<8 | int(b[4])
+	nc := int(b[5])
+	if d.width == 0 || d.height == 0 || (nc != 1 && nc != 3) || len(b) < 6+3*nc {
+		return errNotBaseline
+	}
+	d.comps = make([]dcComponent, nc)
+	for i := range d.comps {
+		c := b[6+3*i:]
+		d.comps[i] = dcComponent{id: c[0], h: int(c[1] >> 4), v: int(c[1] & 15), tq: c[2] & 3}
+		if d.comps[i].h < 1 || d.comps[i].h > 4 || d.comps[i].v < 1 || d.comps[i].v > 4 {
+			return errors.New("jpeg: bad sampling factors")
+		}
+	}
+	return nil
+}
+
+func (d *dcDecoder) quantTables(n int) error {
+	b, err := d.segment(n)
+	if err != nil {
+		return err
+	}
+	for len(b) > 0 {
+		precision, id := b[0]>>4, b[0]&3
+		size := 65
+		if precision == 1 {
+			size = 129
+		}
+		if len(b) < size {
+			return errors.New("jpeg: short quantization table")
+		}
+		// Only the DC entry matters, and it comes first
+		if precision == 1 {
+			d.quant[id] = uint16(b[1])<<8 | uint16(b[2])
+		} else {
+			d.quant[id] = uint16(b[1])
+		}
+		b = b[size:]
+	}
+	return nil
+}
+
+func (d *dcDecoder) huffmanTables(n int) error {
+	b, err := d.segment(n)
+	if err != nil {
+		return err
+	}
+	for len(b) > 0 {
+		if len(b) < 17 {
+			return errors.New("jpeg: short Huffman table")
+		}
+		class, id := b[0]>>4, b[0]&3
+		var counts [16]int
+		total := 0
+		for i := range counts {
+			counts[i] = int(b[1+i])
+			total += counts[i]
+		}
+		if len(b) < 17+total {
+			return errors.New("jpeg: short Huffman table")
+		}
+		h, err := newHuffman(counts, b[17:17+total])
+		if err != nil {
+			return err
+		}
+		if class == 0 {
+			d.dcTables[id] = h
+		} else {
+			d.acTables[id] = h
+		}
+		b = b[17+total:]
+	}
+	return nil
+}
+
+func (d *dcDecoder) restartInterval(n int) error {
+	b, err := d.segment(n)
+	if err != nil {
+		return err
+	}
+	if len(b) < 2 {
+		return errors.New("jpeg: short restart interval")
+	}
+	d.restart = int(b[0])<<8 | int(b[1])
+	return nil
+}
+
+func (d *dcDecoder) adobe(n int) error {
+	b, err := d.segment(n)
+	if err != nil {
+		return err
+	}
+	if len(b) >= 12 && string(b[:5]) == "Adobe" {
+		d.transform = b[11] != 0
+	}
+	return nil
+}
+
+// scan decodes the image data, which has to be one scan holding every
+// component
+func (d *dcDecoder) scan() error {
+	n, err := d.length()
+	if err != nil {
+		return err
+	}
+	b, err := d.segment(n)
+	if err != nil {
+		return err
+	}
+	if d.comps == nil {
+		return errors.New("jpeg: scan before frame")
+	}
+	if len(b) < 1 || int(b[0]) != len(d.comps) || len(b) < 1+2*len(d.comps)+3 {
+		return errNotBaseline
+	}
+	for i := range d.comps {
+		id, tables := b[1+2*i], b[2+2*i]
+		c := &d.comps[i]
+		if c.id != id {
+			return errNotBaseline
+		}
+		c.dc, c.ac = tables>>4&3, tables&3
+		if d.dcTables[c.dc] == nil || d.acTables[c.ac] == nil {
+			return errors.New("jpeg: missing Huffman table")
+		}
+	}
+
+	hmax, vmax := 1, 1
+	for _, c := range d.comps {
+		hmax, vmax = max(hmax, c.h), max(vmax, c.v)
+	}
+	// A single component is stored block by block, whatever its factors
+	if len(d.comps) == 1 {
+		d.comps[0].h, d.comps[0].v = 1, 1
+		hmax, vmax = 1, 1
+	}
+	mcusX := (d.width + 8*hmax - 1) / (8 * hmax)
+	mcusY := (d.height + 8*vmax - 1) / (8 * vmax)
+	for i := range d.comps {
+		c := &d.comps[i]
+		c.stride = mcusX * c.h
+		c.plane = make([]uint8, c.stride*mcusY*c.v)
+	}
+
+	d.bits = bitReader{r: d.r}
+	for mcu := 0; mcu < mcusX*mcusY; mcu++ {
+		if d.restart > 0 && mcu > 0 && mcu%d.restart == 0 {
+			d.bits.reset()
+			for i := range d.comps {
+				d.comps[i].pred = 0
+			}
+		}
+		mx, my := mcu%mcusX, mcu/mcusX
+		for i := range d.comps {
+			c := &d.comps[i]
+			for by := 0; by < c.v; by++ {
+				for bx := 0; bx < c.h; bx++ {
+					dc, err := d.block(c)
+					if err != nil {
+						return err
+					}
+					// The DC coefficient is eight times the block's mean,
+					// level shifted
+					v := int(dc)*int(d.quant[c.tq])/8 + 128
+					c.plane[(my*c.v+by)*c.stride+mx*c.h+bx] = uint8(min(max(v, 0), 255))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// block decodes one block's DC coefficient and skips its AC ones
+func (d *dcDecoder) block(c *dcComponent) (int32, error) {
+	s, err := d.bits.decode(d.dcTables[c.dc])
+	if err != nil {
+		return 0, err
+	}
+	diff, err := d.bits.receive(s)
+	if err != nil {
+		return 0, err
+	}
+	c.pred += diff
+
+	ac := d.acTables[c.ac]
+	for k := 1; k < 64; k++ {
+		rs, err := d.bits.decode(ac)
+		if err != nil {
+			return 0, err
+		}
+		run, size := rs>>4, rs&15
+		if size == 0 {
+			if run != 15 {
+				break
+			}
+			k += 15
+			continue
+		}
+		k += int(run)
+		if err := d.bits.skip(size); err != nil {
+			return 0, err
+		}
+	}
+	return c.pred, nil
+}
+
+func (d *dcDecoder) image() image.Image {
+	w, h := (d.width+7)/8, (d.height+7)/8
+	r := image.Rect(0, 0, w, h)
+	if len(d.comps) == 1 {
+		c := d.comps[0]
+		img := image.NewGray(r)
+		for y := 0; y < h; y++ {
+			copy(img.Pix[y*img.Stride:y*img.Stride+w], c.plane[y*c.stride:])
+		}
+		return img
+	}
+
+	hmax, vmax := 1, 1
+	for _, c := range d.comps {
+		hmax, vmax = max(hmax, c.h), max(vmax, c.v)
+	}
+	sample := func(c dcComponent, x, y int) uint8 {
+		return c.plane[y*c.v/vmax*c.stride+x*c.h/hmax]
+	}
+	img := image.NewRGBA(r)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a, b, cc := sample(d.comps[0], x, y), sample(d.comps[1], x, y), sample(d.comps[2], x, y)
+			if d.transform {
+				a, b, cc = color.YCbCrToRGB(a, b, cc)
+			}
+			i := img.PixOffset(x, y)
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = a, b, cc, 0xFF
+		}
+	}
+	return img
+}
+
+// huffman is a canonical Huffman table, with codes of up to 8 bits
+// looked up in one go
+type huffman struct {
+	lookup  [256]uint16 // length<<8 | value, 0 when the code is longer
+	maxCode [17]int32
+	valPtr  [17]int32
+	minCode [17]int32
+	values  []byte
+}
+
+func newHuffman(counts [16]int, values []byte) (*huffman, error) {
+	h := &huffman{values: values}
+	code, k := int32(0), 0
+	for l := 1; l <= 16; l++ {
+		n := counts[l-1]
+		h.valPtr[l], h.minCode[l], h.maxCode[l] = int32(k), code, -1
+		if n > 0 {
+			h.maxCode[l] = code + int32(n) - 1
+		}
+		if int(code)+n > 1<<l || k+n > len(values) {
+			return nil, errors.New("jpeg: bad Huffman table")
+		}
+		for i := 0; i < n; i++ {
+			if l <= 8 {
+				first := int(code) << (8 - l)
+				for j := 0; j < 1<<(8-l); j++ {
+					h.lookup[first+j] = uint16(l)<<8 | uint16(values[k])
+				}
+			}
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return h, nil
+}
+
+// bitReader reads entropy coded data, dropping the zero stuffed after
+// 0xFF bytes. Once it runs into a marker it only gives zeros.
+type bitReader struct {
+	r      *bufio.Reader
+	acc    uint32
+	n      uint
+	marker bool
+}
+
+func (b *bitReader) fill() error {
+	for b.n <= 24 {
+		var c byte
+		if !b.marker {
+			var err error
+			if c, err = b.r.ReadByte(); err != nil {
+				return err
+			}
+			if c == 0xFF {
+				next, err := b.r.ReadByte()
+				if err != nil {
+					return err
+				}
+				if next != 0 {
+					b.marker, c = true, 0
+				}
+			}
+		}
+		b.acc = b.acc<<8 | uint32(c)
+		b.n += 8
+	}
+	return nil
+}
+
+// reset drops the leftover bits and the restart marker that follows them
+func (b *bitReader) reset() {
+	b.acc, b.n = 0, 0
+	if b.marker {
+		b.marker = false
+		return
+	}
+	for {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return
+		}
+		if c != 0xFF {
+			continue
+		}
+		if next, err := b.r.ReadByte(); err != nil || next != 0 {
+			return
+		}
+	}
+}
+
+func (b *bitReader) decode(h *huffman) (byte, error) {
+	if err := b.fill(); err != nil {
+		return 0, err
+	}
+	if v := h.lookup[b.acc>>(b.n-8)&0xFF]; v != 0 {
+		b.n -= uint(v >> 8)
+		return byte(v), nil
+	}
+	code := int32(0)
+	for l := 1; l <= 16; l++ {
+		b.n--
+		code = code<<1 | int32(b.acc>>b.n&1)
+		if code <= h.maxCode[l] {
+			i := int(h.valPtr[l] + code - h.minCode[l])
+			if i >= len(h.values) {
+				break
+			}
+			return h.values[i], nil
+		}
+	}
+	return 0, errors.New("jpeg: bad Huffman code")
+}
+
+// receive reads an s bit coefficient and sign extends it
+func (b *bitReader) receive(s byte) (int32, error) {
+	if s == 0 {
+		return 0, nil
+	}
+	if s > 16 {
+		return 0, errors.New("jpeg: bad coefficient size")
+	}
+	if err := b.fill(); err != nil {
+		return 0, err
+	}
+	b.n -= uint(s)
+	v := int32(b.acc >> b.n & (1<<s - 1))
+	if v < 1<<(s-1) {
+		v += -1<<s + 1
+	}
+	return v, nil
+}
+
+func (b *bitReader) skip(s byte) error {
+	if err := b.fill(); err != nil {
+		return err
+	}
+	b.n -= uint(s)
+	return nil
+}
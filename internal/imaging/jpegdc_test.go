@@ -0,0 +1,81 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// blockMean averages the 8x8 block of img at bx, by
+func blockMean(img image.Image, bx, by int) (r, g, b int) {
+	n := 0
+	for y := by * 8; y < min(by*8+8, img.Bounds().Dy()); y++ {
+		for x := bx * 8; x < min(bx*8+8, img.Bounds().Dx()); x++ {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			r, g, b = r+int(cr>>8), g+int(cg>>8), b+int(cb>>8)
+			n++
+		}
+	}
+	return r / n, g / n, b / n
+}
+
+func TestDecodeJPEGDCMatchesBlockMeans(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 203, 117))
+	for y := 0; y < 117; y++ {
+		for x := 0; x < 203; x++ {
+			src.Set(x, y, color.RGBA{uint8(x), uint8(y * 2), uint8((x + y) / 2), 255})
+		}
+	}
+	gray := image.NewGray(src.Bounds())
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i % 251)
+	}
+
+	for name, img := range map[string]image.Image{"color": src, "gray": gray} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+				t.Fatal(err)
+			}
+			full, err := jpeg.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			small, err := decodeJPEGDC(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := small.Bounds().Size(), image.Pt(26, 15); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			// The partial blocks at the edges are padded by the encoder
+			for by := 0; by < 14; by++ {
+				for bx := 0; bx < 25; bx++ {
+					wr, wg, wb := blockMean(full, bx, by)
+					cr, cg, cb, _ := small.At(bx, by).RGBA()
+					if abs(int(cr>>8)-wr) > 12 || abs(int(cg>>8)-wg) > 12 || abs(int(cb>>8)-wb) > 12 {
+						t.Fatalf("block %d,%d is %d,%d,%d, want about %d,%d,%d", bx, by, cr>>8, cg>>8, cb>>8, wr, wg, wb)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJPEGDCRejectsProgressive(t *testing.T) {
+	// SOI then a progressive frame header
+	data := []byte{0xFF, 0xD8, 0xFF, 0xC2, 0x00, 0x0B, 8, 0, 8, 0, 8, 1, 1, 0x11, 0}
+	if _, err := decodeJPEGDC(bytes.NewReader(data)); err != errNotBaseline {
+		t.Errorf("got %v, want errNotBaseline", err)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package imaging
+
+import (
+	"image"
+	"os"
+
+	"github.com/nooooaaaaah/photoboard/internal/exif"
+	"github.com/nooooaaaaah/photoboard/internal/xmp"
+)
+
+// Orient applies an EXIF orientation (1 to 8) so the image is upright.
+// Orientations 5 to 8 swap width and height.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	// source pixel for each destination pixel
+	var at func(x, y int) (int, int)
+	switch orientation {
+	case 2: // mirrored left-right
+		at = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3: // rotated 180
+		at = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4: // mirrored top-bottom
+		at = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5: // transposed
+		at = func(x, y int) (int, int) { return y, x }
+	case 6: // needs a 90 degree clockwise turn
+		at = func(x, y int) (int, int) { return y, h - 1 - x }
+	case 7: // transversed
+		at = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case 8: // needs a 90 degree anti-clockwise turn
+		at = func(x, y int) (int, int) { return w - 1 - y, x }
+	}
+
+	for y := 0; y < dh; y++ {
+		row := dst.Pix[y*dst.Stride:]
+		for x := 0; x < dw; x++ {
+			sx, sy := at(x, y)
+			i := sy*src.Stride + sx*4
+			copy(row[x*4:x*4+4], src.Pix[i:i+4])
+		}
+	}
+	return dst
+}
+
+// orientation works out how the pixels in f are meant to be turned: EXIF
+// first, then HEIF transform properties, then an XMP tiff:Orientation
+func orientation(f *os.File, data *exif.Data) int {
+	if data != nil {
+		if o, ok := data.Orientation(); ok {
+			return o
+		}
+	}
+	if o, ok := exif.HEIFOrientation(f); ok {
+		return o
+	}
+	if data == nil {
+		if props, err := xmp.ReadHead(f); err == nil {
+			switch o := props.Get("tiff:Orientation"); o {
+			case "1", "2", "3", "4", "5", "6", "7", "8":
+				return int(o[0] - '0')
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,50 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestOrient(t *testing.T) {
+	// A 2x3 image, stored as
+	//   1 2
+	//   3 4
+	//   5 6
+	// with bounds that don't start at zero
+	src := image.NewGray(image.Rect(5, 7, 7, 10))
+	for i := 0; i < 6; i++ {
+		src.SetGray(5+i%2, 7+i/2, color.Gray{uint8(i + 1)})
+	}
+
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{1, [][]uint8{{1, 2}, {3, 4}, {5, 6}}},
+		{2, [][]uint8{{2, 1}, {4, 3}, {6, 5}}},
+		{3, [][]uint8{{6, 5}, {4, 3}, {2, 1}}},
+		{4, [][]uint8{{5, 6}, {3, 4}, {1, 2}}},
+		{5, [][]uint8{{1, 3, 5}, {2, 4, 6}}},
+		{6, [][]uint8{{5, 3, 1}, {6, 4, 2}}},
+		{7, [][]uint8{{6, 4, 2}, {5, 3, 1}}},
+		{8, [][]uint8{{2, 4, 6}, {1, 3, 5}}},
+		{0, [][]uint8{{1, 2}, {3, 4}, {5, 6}}},
+		{9, [][]uint8{{1, 2}, {3, 4}, {5, 6}}},
+	}
+	for _, tt := range tests {
+		out := Orient(src, tt.orientation)
+		b := out.Bounds()
+		got := make([][]uint8, b.Dy())
+		for y := range got {
+			got[y] = make([]uint8, b.Dx())
+			for x := range got[y] {
+				got[y][x] = color.GrayModel.Convert(out.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("orientation %d: got %v, want %v", tt.orientation, got, tt.want)
+		}
+	}
+}
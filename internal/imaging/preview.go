@@ -0,0 +1,247 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/nooooaaaaah/photoboard/internal/exif"
+	"github.com/nooooaaaaah/photoboard/internal/svg"
+)
+
+// LoadSize is Load for callers that will only show the image at up to
+// w x h pixels. When the file carries an embedded preview big enough to fill
+// that without upscaling (the EXIF thumbnail of a JPEG, or one of the JPEGs
+// a camera raw keeps next to the sensor data) it's decoded instead, which is
+// usually a tiny fraction of the work. Failing that, a baseline JPEG that
+// is at least eight times the size asked for is decoded at an eighth of
+// its size. Either way the result is turned upright according to the
+// file's orientation.
+func LoadSize(path string, w, h int) (image.Image, error) {
+	img, _, err := LoadSizeReduced(path, w, h)
+	return img, err
+}
+
+// LoadSizeReduced is LoadSize that also reports whether the image is
+// smaller than the file could give, because it came from an embedded
+// preview or is vector art drawn to size
+func LoadSizeReduced(path string, w, h int) (image.Image, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	format := detect(f, path)
+	switch {
+	case format == FormatSVG:
+		// Vector art is drawn at exactly the size asked for
+		img, err := svg.Decode(f, w, h)
+		return img, true, err
+	case !Decodable(format):
+		return nil, false, &UnsupportedError{Format: format}
+	}
+
+	data, _ := exif.Decode(f)
+	o := orientation(f, data)
+	if o >= 5 {
+		// w x h is upright, the stored pixels are on their side
+		w, h = h, w
+	}
+
+	img, reduced, err := decodeBest(f, data, format, w, h)
+	if err != nil {
+		return nil, false, err
+	}
+	// Embedded previews are stored the same way round as the main image
+	return Orient(img, o), reduced, nil
+}
+
+func decodeBest(f *os.File, data *exif.Data, format string, w, h int) (image.Image, bool, error) {
+	previews := embeddedPreviews(f, data)
+	if p, ok := coveringPreview(previews, w, h); ok {
+		if img, err := decodePreview(f, p); err == nil {
+			return img, true, nil
+		}
+	}
+
+	// Sensor data can't be decoded, but the largest preview is the next
+	// best thing. The TIFF decoder would only find a tiny thumbnail.
+	if format == FormatRaw {
+		for i := len(previews) - 1; i >= 0; i-- {
+			if img, err := decodePreview(f, previews[i]); err == nil {
+				return img, i < len(previews)-1, nil
+			}
+		}
+		return nil, false, &UnsupportedError{Format: format}
+	}
+
+	if format == FormatJPEG {
+		if img, ok := decodeEighth(f, w, h); ok {
+			return img, true, nil
+		}
+	}
+
+	img, _, err := image.Decode(io.NewSectionReader(f, 0, math.MaxInt64))
+	return img, false, err
+}
+
+// decodeEighth decodes a JPEG at an eighth of its size when that still
+// fills w x h
+func decodeEighth(f *os.File, w, h int) (image.Image, bool) {
+	if w <= 0 || h <= 0 {
+		return nil, false
+	}
+	cfg, err := jpeg.DecodeConfig(io.NewSectionReader(f, 0, math.MaxInt64))
+	if err != nil {
+		return nil, false
+	}
+	fw, fh := Fit(cfg.Width, cfg.Height, w, h, 1)
+	if cfg.Width/8 < fw || cfg.Height/8 < fh {
+		return nil, false
+	}
+	img, err := decodeJPEGDC(io.NewSectionReader(f, 0, math.MaxInt64))
+	if err != nil {
+		return nil, false
+	}
+	return img, true
+}
+
+type preview struct {
+	section exif.Section
+	width   int
+	height  int
+}
+
+// embeddedPreviews lists the decodable JPEGs inside f with their sizes,
+// smallest first
+func embeddedPreviews(f *os.File, data *exif.Data) []preview {
+	var sections []exif.Section
+	if data != nil {
+		sections = append(sections, data.Previews()...)
+	}
+	if s, ok := exif.JFIFThumbnail(f); ok {
+		sections = append(sections, s)
+	}
+	if s, ok := exif.RAFPreview(f); ok {
+		sections = append(sections, s)
+	}
+
+	var out []preview
+	for _, s := range sections {
+		cfg, format, err := image.DecodeConfig(s.Reader(f))
+		if err != nil || format != "jpeg" {
+			continue
+		}
+		p := preview{section: s, width: cfg.Width, height: cfg.Height}
+		i := len(out)
+		for i > 0 && out[i-1].width*out[i-1].height > p.width*p.height {
+			i--
+		}
+		out = append(out[:i], append([]preview{p}, out[i:]...)...)
+	}
+	return out
+}
+
+// coveringPreview returns the smallest preview that fills w x h
+func coveringPreview(previews []preview, w, h int) (preview, bool) {
+	if w <= 0 || h <= 0 {
+		return preview{}, false
+	}
+	for _, p := range previews {
+		fw, fh := Fit(p.width, p.height, w, h, 1)
+		if p.width >= fw && p.height >= fh {
+			return p, true
+		}
+	}
+	return preview{}, false
+}
+
+func decodePreview(f *os.File, p preview) (image.Image, error) {
+	img, _, err := image.Decode(p.section.Reader(f))
+	if err != nil {
+		return nil, err
+	}
+	return cropToAspect(img, f), nil
+}
+
+// cropToAspect trims the letterboxing cameras add when the thumbnail's
+// aspect ratio (usually 4:3) differs from the photo's
+func cropToAspect(img image.Image, f *os.File) image.Image {
+	rw, rh, ok := mainDimensions(f)
+	if !ok {
+		return img
+	}
+
+	b := img.Bounds()
+	want := float64(rw) / float64(rh)
+	have := float64(b.Dx()) / float64(b.Dy())
+	if math.Abs(want-have)/want < 0.02 {
+		return img
+	}
+
+	r := b
+	if have > want {
+		w := int(float64(b.Dy())*want + 0.5)
+		r.Min.X += (b.Dx() - w) / 2
+		r.Max.X = r.Min.X + w
+	} else {
+		h := int(float64(b.Dx())/want + 0.5)
+		r.Min.Y += (b.Dy() - h) / 2
+		r.Max.Y = r.Min.Y + h
+	}
+
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok && !r.Empty() {
+		return sub.SubImage(r)
+	}
+	return img
+}
+
+// Size reads an image's upright width and height from its headers, without
+// decoding the pixels
+func Size(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	switch format := detect(f, path); {
+	case format == FormatSVG:
+		doc, err := svg.Parse(f)
+		if err != nil {
+			return 0, 0, err
+		}
+		return int(doc.Width + 0.5), int(doc.Height + 0.5), nil
+	case !Decodable(format):
+		return 0, 0, &UnsupportedError{Format: format}
+	}
+
+	w, h, ok := mainDimensions(f)
+	if !ok {
+		return 0, 0, fmt.Errorf("can't tell how big %s is", filepath.Base(path))
+	}
+	data, _ := exif.Decode(f)
+	if orientation(f, data) >= 5 {
+		w, h = h, w
+	}
+	return w, h, nil
+}
+
+// mainDimensions reads the size of the full image from its header, falling
+// back to what EXIF says for formats the decoders don't know
+func mainDimensions(f *os.File) (int, int, bool) {
+	if cfg, _, err := image.DecodeConfig(io.NewSectionReader(f, 0, math.MaxInt64)); err == nil {
+		return cfg.Width, cfg.Height, true
+	}
+	if data, err := exif.Decode(f); err == nil {
+		return data.Dimensions()
+	}
+	return 0, 0, false
+}
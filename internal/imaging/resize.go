@@ -0,0 +1,105 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Resize scales img to w x h. Downscaling averages every source pixel that
+// falls under a target pixel (area filter), which avoids the aliasing of
+// nearest-neighbour sampling; upscaling interpolates bilinearly.
+func Resize(img image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if w <= 0 || h <= 0 {
+		return dst
+	}
+
+	src := toRGBA(img)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw == 0 || sh == 0 {
+		return dst
+	}
+
+	if sw >= w && sh >= h {
+		resizeArea(src, dst)
+	} else {
+		resizeBilinear(src, dst)
+	}
+	return dst
+}
+
+func resizeArea(src, dst *image.RGBA) {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := dst.Bounds().Dx(), dst.Bounds().Dy()
+	sx := float64(sw) / float64(dw)
+	sy := float64(sh) / float64(dh)
+
+	for y := 0; y < dh; y++ {
+		y0, y1 := float64(y)*sy, float64(y+1)*sy
+		for x := 0; x < dw; x++ {
+			x0, x1 := float64(x)*sx, float64(x+1)*sx
+
+			var r, g, b, a, total float64
+			for py := int(y0); py < int(math.Ceil(y1)) && py < sh; py++ {
+				wy := math.Min(y1, float64(py+1)) - math.Max(y0, float64(py))
+				for px := int(x0); px < int(math.Ceil(x1)) && px < sw; px++ {
+					wx := math.Min(x1, float64(px+1)) - math.Max(x0, float64(px))
+					weight := wx * wy
+					i := src.PixOffset(px, py)
+					r += float64(src.Pix[i]) * weight
+					g += float64(src.Pix[i+1]) * weight
+					b += float64(src.Pix[i+2]) * weight
+					a += float64(src.Pix[i+3]) * weight
+					total += weight
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			if total > 0 {
+				dst.Pix[j] = uint8(r/total + 0.5)
+				dst.Pix[j+1] = uint8(g/total + 0.5)
+				dst.Pix[j+2] = uint8(b/total + 0.5)
+				dst.Pix[j+3] = uint8(a/total + 0.5)
+			}
+		}
+	}
+}
+
+func resizeBilinear(src, dst *image.RGBA) {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := dst.Bounds().Dx(), dst.Bounds().Dy()
+	sx := float64(sw) / float64(dw)
+	sy := float64(sh) / float64(dh)
+
+	for y := 0; y < dh; y++ {
+		fy := math.Max(0, (float64(y)+0.5)*sy-0.5)
+		y0 := int(fy)
+		y1 := min(y0+1, sh-1)
+		ty := fy - float64(y0)
+
+		for x := 0; x < dw; x++ {
+			fx := math.Max(0, (float64(x)+0.5)*sx-0.5)
+			x0 := int(fx)
+			x1 := min(x0+1, sw-1)
+			tx := fx - float64(x0)
+
+			i00, i10 := src.PixOffset(x0, y0), src.PixOffset(x1, y0)
+			i01, i11 := src.PixOffset(x0, y1), src.PixOffset(x1, y1)
+			j := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				top := float64(src.Pix[i00+c])*(1-tx) + float64(src.Pix[i10+c])*tx
+				bottom := float64(src.Pix[i01+c])*(1-tx) + float64(src.Pix[i11+c])*tx
+				dst.Pix[j+c] = uint8(top*(1-ty) + bottom*ty + 0.5)
+			}
+		}
+	}
+}
+
+// Crop copies the r part of img into a new image starting at the origin
+func Crop(img image.Image, r image.Rectangle) *image.RGBA {
+	r = r.Intersect(img.Bounds())
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
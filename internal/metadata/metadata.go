@@ -0,0 +1,395 @@
+// Package metadata collects the photo details shown in the preview's info
+// panel from EXIF, falling back to XMP for anything EXIF doesn't have.
+package metadata
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/exif"
+	"github.com/nooooaaaaah/photoboard/internal/sidecar"
+	"github.com/nooooaaaaah/photoboard/internal/xmp"
+)
+
+// Field is one labelled line of the info panel
+type Field struct {
+	Label string
+	Value string
+}
+
+// Info is everything read from one file
+type Info struct {
+	Fields []Field
+	EXIF   *exif.Data
+	XMP    xmp.Properties
+	heif   int
+}
+
+// Read gathers the metadata of the image at path. Files without any EXIF
+// or XMP still get their dimensions and size.
+func Read(path string) (Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+
+	var info Info
+	if data, err := exif.Decode(f); err == nil {
+		info.EXIF = data
+	}
+	info.XMP = readXMP(f, info.EXIF)
+	// What's in the sidecar is newer than what's embedded
+	if props, err := sidecar.ReadProperties(path); err == nil {
+		if info.XMP == nil {
+			info.XMP = make(xmp.Properties)
+		}
+		for key, values := range props {
+			info.XMP[key] = values
+		}
+	}
+	info.heif, _ = exif.HEIFOrientation(f)
+	info.Fields = info.fields(f)
+	return info, nil
+}
+
+// readXMP prefers the packet TIFF files point at, and otherwise searches
+// the head of the file
+func readXMP(f *os.File, data *exif.Data) xmp.Properties {
+	var props xmp.Properties
+	var err error
+	if data != nil && len(data.XMP()) > 0 {
+		props, err = xmp.Parse(data.XMP())
+	} else {
+		props, err = xmp.ReadHead(f)
+	}
+	if err != nil {
+		return nil
+	}
+	return props
+}
+
+func (info Info) fields(f *os.File) []Field {
+	var out []Field
+	add := func(label, value string) {
+		if value != "" {
+			out = append(out, Field{Label: label, Value: value})
+		}
+	}
+
+	add("Camera", info.camera())
+	add("Lens", info.first(exif.TagLensModel, "exifEX:LensModel", "aux:Lens"))
+	add("Focal length", info.focalLength())
+	add("Aperture", info.aperture())
+	add("Shutter", info.shutter())
+	add("ISO", info.iso())
+	add("Captured", info.captured())
+	add("GPS", info.gps())
+	add("Orientation", OrientationName(info.Orientation()))
+	add("Dimensions", info.dimensions(f))
+	if st, err := f.Stat(); err == nil {
+		add("Size", HumanSize(st.Size()))
+	}
+	add("Rating", info.rating())
+	add("Label", info.XMP.Get("xmp:Label"))
+	add("Title", info.XMP.Get("dc:title"))
+	add("Keywords", strings.Join(info.XMP["dc:subject"], ", "))
+	add("Software", info.first(exif.TagSoftware, "xmp:CreatorTool"))
+	return out
+}
+
+// entry looks tag up in IFD0 and then the Exif IFD
+func (info Info) entry(tag uint16) (exif.Entry, bool) {
+	if info.EXIF == nil {
+		return exif.Entry{}, false
+	}
+	if e, ok := info.EXIF.IFDs[0][tag]; ok {
+		return e, true
+	}
+	e, ok := info.EXIF.Exif[tag]
+	return e, ok
+}
+
+// first returns the string value of tag, or the first XMP key that's set
+func (info Info) first(tag uint16, keys ...string) string {
+	if e, ok := info.entry(tag); ok {
+		if s := e.String(); s != "" {
+			return s
+		}
+	}
+	for _, key := range keys {
+		if v := info.XMP.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// float returns a numeric tag, or an XMP value which may be a fraction
+func (info Info) float(tag uint16, key string) (float64, bool) {
+	if e, ok := info.entry(tag); ok {
+		if v, ok := e.Float(0); ok {
+			return v, true
+		}
+	}
+	return parseRational(info.XMP.Get(key))
+}
+
+// rating shows stars, or the pick/reject flag that can stand in for them
+func (info Info) rating() string {
+	tags := sidecar.FromXMP(info.XMP)
+	stars := ""
+	if tags.Rating > 0 {
+		stars = strings.Repeat("★", tags.Rating) + strings.Repeat("☆", 5-tags.Rating)
+	}
+	switch tags.Flag {
+	case defs.FlagPick:
+		return strings.TrimSpace(stars + " picked")
+	case defs.FlagReject:
+		return "rejected"
+	}
+	return stars
+}
+
+func (info Info) camera() string {
+	brand := info.first(exif.TagMake, "tiff:Make")
+	model := info.first(exif.TagModel, "tiff:Model")
+	// Most models already start with the make, e.g. "Canon EOS R5"
+	if words := strings.Fields(brand); len(words) > 0 && strings.HasPrefix(strings.ToLower(model), strings.ToLower(words[0])) {
+		return model
+	}
+	return strings.TrimSpace(brand + " " + model)
+}
+
+func (info Info) focalLength() string {
+	mm, ok := info.float(exif.TagFocalLength, "exif:FocalLength")
+	if !ok || mm <= 0 {
+		return ""
+	}
+	s := strconv.FormatFloat(mm, 'f', -1, 64) + " mm"
+	if eq, ok := info.float(exif.TagFocalLength35mm, "exif:FocalLengthIn35mmFilm"); ok && eq > 0 && eq != mm {
+		s += fmt.Sprintf(" (%g mm equiv.)", eq)
+	}
+	return s
+}
+
+func (info Info) aperture() string {
+	if f, ok := info.float(exif.TagFNumber, "exif:FNumber"); ok && f > 0 {
+		return "f/" + strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return ""
+}
+
+func (info Info) shutter() string {
+	t, ok := info.float(exif.TagExposureTime, "exif:ExposureTime")
+	if !ok || t <= 0 {
+		return ""
+	}
+	if t < 1 {
+		return fmt.Sprintf("1/%d s", int(math.Round(1/t)))
+	}
+	return strconv.FormatFloat(t, 'f', -1, 64) + " s"
+}
+
+func (info Info) iso() string {
+	if iso, ok := info.float(exif.TagISO, ""); ok && iso > 0 {
+		return strconv.Itoa(int(iso))
+	}
+	return info.XMP.Get("exif:ISOSpeedRatings")
+}
+
+func (info Info) captured() string {
+	s := info.first(exif.TagDateTimeOriginal)
+	if s == "" {
+		s = info.first(exif.TagDateTime)
+	}
+	if s != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05", s); err == nil {
+			s = t.Format("2006-01-02 15:04:05")
+			if off := info.first(exif.TagOffsetTimeOriginal); off != "" {
+				s += " " + off
+			}
+			return s
+		}
+		return s
+	}
+
+	for _, key := range []string{"exif:DateTimeOriginal", "photoshop:DateCreated", "xmp:CreateDate"} {
+		v := info.XMP.Get(key)
+		if v == "" {
+			continue
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t.Format("2006-01-02 15:04:05 -07:00")
+			}
+		}
+		return v
+	}
+	return ""
+}
+
+func (info Info) gps() string {
+	lat, lon, ok := info.Location()
+	if !ok {
+		return ""
+	}
+	s := fmt.Sprintf("%.6f, %.6f", lat, lon)
+	if info.EXIF != nil {
+		if alt, ok := info.EXIF.GPS[exif.TagGPSAltitude].Float(0); ok {
+			if ref, _ := info.EXIF.GPS[exif.TagGPSAltitudeRef].Uint(0); ref == 1 {
+				alt = -alt
+			}
+			s += fmt.Sprintf(" (%.0f m)", alt)
+		}
+	}
+	return s
+}
+
+// Location returns the GPS position in decimal degrees
+func (info Info) Location() (float64, float64, bool) {
+	if info.EXIF != nil && info.EXIF.GPS != nil {
+		lat, okLat := degrees(info.EXIF.GPS[exif.TagGPSLatitude], info.EXIF.GPS[exif.TagGPSLatitudeRef].String())
+		lon, okLon := degrees(info.EXIF.GPS[exif.TagGPSLongitude], info.EXIF.GPS[exif.TagGPSLongitudeRef].String())
+		if okLat && okLon {
+			return lat, lon, true
+		}
+	}
+
+	lat, okLat := xmpDegrees(info.XMP.Get("exif:GPSLatitude"))
+	lon, okLon := xmpDegrees(info.XMP.Get("exif:GPSLongitude"))
+	return lat, lon, okLat && okLon
+}
+
+// degrees converts an EXIF degrees/minutes/seconds triple
+func degrees(e exif.Entry, ref string) (float64, bool) {
+	var dms [3]float64
+	for i := range dms {
+		v, ok := e.Float(i)
+		if !ok {
+			return 0, false
+		}
+		dms[i] = v
+	}
+	deg := dms[0] + dms[1]/60 + dms[2]/3600
+	if ref == "S" || ref == "W" {
+		deg = -deg
+	}
+	return deg, true
+}
+
+// xmpDegrees converts XMP's "DDD,MM.mmk" form, where k is N, S, E or W
+func xmpDegrees(s string) (float64, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	ref := s[len(s)-1]
+	parts := strings.Split(s[:len(s)-1], ",")
+
+	var deg float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || i > 2 {
+			return 0, false
+		}
+		deg += v / math.Pow(60, float64(i))
+	}
+	if ref == 'S' || ref == 'W' {
+		deg = -deg
+	}
+	return deg, true
+}
+
+// Orientation returns the EXIF orientation, 1 to 8, falling back to HEIF
+// transform properties and then XMP. 1 means the pixels are stored upright.
+func (info Info) Orientation() int {
+	if info.EXIF != nil {
+		if o, ok := info.EXIF.Orientation(); ok {
+			return o
+		}
+	}
+	if info.heif != 0 {
+		return info.heif
+	}
+	if v, err := strconv.Atoi(info.XMP.Get("tiff:Orientation")); err == nil && v >= 1 && v <= 8 {
+		return v
+	}
+	return 1
+}
+
+var orientationNames = []string{
+	1: "Normal",
+	2: "Mirrored",
+	3: "Rotated 180°",
+	4: "Flipped vertically",
+	5: "Mirrored, rotated 90° CCW",
+	6: "Rotated 90° CW",
+	7: "Mirrored, rotated 90° CW",
+	8: "Rotated 90° CCW",
+}
+
+// OrientationName describes an EXIF orientation value
+func OrientationName(o int) string {
+	if o < 1 || o >= len(orientationNames) {
+		return ""
+	}
+	return orientationNames[o]
+}
+
+func parseRational(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+func (info Info) dimensions(f *os.File) string {
+	if cfg, _, err := image.DecodeConfig(io.NewSectionReader(f, 0, math.MaxInt64)); err == nil {
+		return fmt.Sprintf("%d × %d", cfg.Width, cfg.Height)
+	}
+	if info.EXIF != nil {
+		if w, h, ok := info.EXIF.Dimensions(); ok {
+			return fmt.Sprintf("%d × %d", w, h)
+		}
+	}
+	return ""
+}
+
+// HumanSize formats a byte count like "1.5 MiB"
+func HumanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Text formats fields one per line for copying
+func Text(fields []Field) string {
+	var b strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s: %s\n", f.Label, f.Value)
+	}
+	return b.String()
+}
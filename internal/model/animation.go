@@ -0,0 +1,93 @@
+package model
+
+import (
+	"image"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+)
+
+// AnimationTickMsg asks for the next frame of the previewed animation. The
+// ID ties it to one playback, so ticks from a closed preview die out.
+type AnimationTickMsg struct {
+	ID int
+}
+
+// Animation is the playback state of an animated image preview. A zero ID
+// means nothing is playing.
+type Animation struct {
+	ID     int
+	Frames []image.Image
+	Delays []time.Duration
+	// Rendered caches each frame's output so later loops are cheap; it's
+	// shared between copies of the model
+	Rendered []*render.Image
+	Frame    int
+	Paused   bool
+	// Plays left before stopping on the last frame, 0 meaning forever
+	Plays int
+}
+
+var lastAnimationID int
+
+func nextAnimationID() int {
+	lastAnimationID++
+	return lastAnimationID
+}
+
+// NewAnimation starts a playback with an ID no earlier one has used
+func NewAnimation(frames []image.Image, delays []time.Duration, plays int) Animation {
+	return Animation{
+		ID:       nextAnimationID(),
+		Frames:   frames,
+		Delays:   delays,
+		Rendered: make([]*render.Image, len(frames)),
+		Plays:    plays,
+	}
+}
+
+// Playing reports whether ticks should keep coming
+func (a Animation) Playing() bool {
+	return a.ID != 0 && !a.Paused
+}
+
+// Tick schedules the next frame after the current one's delay
+func (a Animation) Tick() tea.Cmd {
+	id := a.ID
+	return tea.Tick(a.Delays[a.Frame], func(time.Time) tea.Msg {
+		return AnimationTickMsg{ID: id}
+	})
+}
+
+// Advance moves playback on a frame. Wrapping around uses up a play; it
+// returns false once there are none left.
+func (a *Animation) Advance() bool {
+	if a.Frame == len(a.Frames)-1 {
+		if a.Plays == 1 {
+			return false
+		}
+		if a.Plays > 1 {
+			a.Plays--
+		}
+	}
+	a.Step(1)
+	return true
+}
+
+// Step moves to the next or previous frame, wrapping around
+func (a *Animation) Step(delta int) {
+	n := len(a.Frames)
+	a.Frame = ((a.Frame+delta)%n + n) % n
+}
+
+// Resume continues a paused playback under a new ID, so a tick still in
+// flight from before the pause can't double the speed
+func (a *Animation) Resume() {
+	a.Paused = false
+	a.ID = nextAnimationID()
+	// A finished play-once animation starts over
+	if a.Plays == 1 && a.Frame == len(a.Frames)-1 {
+		a.Frame = 0
+	}
+}
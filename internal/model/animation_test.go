@@ -0,0 +1,88 @@
+package model
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func animation(frames, plays int) Animation {
+	images := make([]image.Image, frames)
+	delays := make([]time.Duration, frames)
+	return NewAnimation(images, delays, plays)
+}
+
+func TestAnimationAdvance(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+		plays  int
+		steps  int
+		// Where playback is after steps advances, and whether the last
+		// one still moved
+		frame     int
+		playsLeft int
+		moving    bool
+	}{
+		{"forever wraps", 3, 0, 7, 1, 0, true},
+		{"once stops on the last frame", 3, 1, 3, 2, 1, false},
+		{"once plays every frame", 3, 1, 2, 2, 1, true},
+		{"twice wraps once", 3, 2, 3, 0, 1, true},
+		{"twice stops after the second run", 3, 2, 6, 2, 1, false},
+		{"single frame forever", 1, 0, 5, 0, 0, true},
+	}
+	for _, tt := range tests {
+		a := animation(tt.frames, tt.plays)
+		moving := true
+		for i := 0; i < tt.steps && moving; i++ {
+			moving = a.Advance()
+		}
+		if a.Frame != tt.frame || a.Plays != tt.playsLeft || moving != tt.moving {
+			t.Errorf("%s: at frame %d with %d plays left, moving %v; want %d, %d, %v",
+				tt.name, a.Frame, a.Plays, moving, tt.frame, tt.playsLeft, tt.moving)
+		}
+	}
+}
+
+func TestAnimationStep(t *testing.T) {
+	tests := []struct {
+		from, delta, want int
+	}{
+		{0, 1, 1},
+		{3, 1, 0},
+		{0, -1, 3},
+		{2, -6, 0},
+		{1, 9, 2},
+	}
+	for _, tt := range tests {
+		a := animation(4, 0)
+		a.Frame = tt.from
+		a.Step(tt.delta)
+		if a.Frame != tt.want {
+			t.Errorf("Step(%d) from %d went to %d, want %d", tt.delta, tt.from, a.Frame, tt.want)
+		}
+	}
+}
+
+func TestAnimationResume(t *testing.T) {
+	a := animation(3, 0)
+	a.Frame, a.Paused = 1, true
+	id := a.ID
+	a.Resume()
+	if a.Paused || a.ID == id || a.Frame != 1 {
+		t.Errorf("resumed to paused %v, id %d (was %d), frame %d", a.Paused, a.ID, id, a.Frame)
+	}
+	if !a.Playing() {
+		t.Error("not playing after resume")
+	}
+
+	// A finished play-once animation starts over
+	once := animation(3, 1)
+	for once.Advance() {
+	}
+	once.Paused = true
+	once.Resume()
+	if once.Frame != 0 {
+		t.Errorf("finished animation resumed at frame %d, want 0", once.Frame)
+	}
+}
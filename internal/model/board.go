@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nooooaaaaah/photoboard/internal/board"
+)
+
+type BoardHandler interface {
+	// OpenBoard adds the selected images, if any, to the board called name
+	// and opens it
+	OpenBoard(Model, string) (tea.Model, tea.Cmd)
+	HandleBoardUpdate(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+	HandleBoardPrompt(m Model, kind, value string) (tea.Model, tea.Cmd)
+	// Resize composes the preview again for the new pane size
+	Resize(Model) (tea.Model, tea.Cmd)
+}
+
+// BoardState is the moodboard being edited. Every change is saved straight
+// away and the collage preview composed again in the background.
+type BoardState struct {
+	Active bool
+	Board  board.Board
+	Cursor int
+	// Version goes up with every change so a preview of an older layout
+	// that arrives late is dropped
+	Version int
+	Preview string
+	Missing int
+}
+
+// BoardPreviewMsg delivers a rendered collage preview
+type BoardPreviewMsg struct {
+	Version int
+	Text    string
+	Missing int
+	Err     error
+}
+
+// BoardExportedMsg reports writing a board out as an image
+type BoardExportedMsg struct {
+	Path    string
+	Missing int
+	Err     error
+}
+
+// boardListWidth is the width of the image list next to the preview,
+// border included
+const boardListWidth = 36
+
+// BoardPane is the number of cells the collage preview gets
+func (m Model) BoardPane() (int, int) {
+	fw, fh := m.Styler.ImagePreviewStyle().GetFrameSize()
+	// The title line and the footer
+	return max(m.WindowWidth-boardListWidth-fw, 10), max(m.WindowHeight-fh-2, 5)
+}
+
+func (m Model) boardView() string {
+	s := m.Board
+	b := s.Board
+	cols, rows := m.BoardPane()
+	_, fh := m.Styler.ImagePreviewStyle().GetFrameSize()
+
+	lines := []string{
+		m.Styler.PreviewTitleStyle().Render(b.Name),
+		m.Styler.MutedStyle().Render(fmt.Sprintf("%s · %d across", b.Layout, b.Columns)),
+		m.Styler.MutedStyle().Render(fmt.Sprintf("%dpx gaps · %s", b.Spacing, b.Background)),
+		"",
+	}
+	colStyle := m.Styler.ColumnStyle()
+	height := rows + 1 + fh
+	inner := boardListWidth - colStyle.GetHorizontalBorderSize()
+
+	// Scroll the list to keep the cursor in view
+	room := max(height-colStyle.GetVerticalFrameSize()-len(lines), 1)
+	start := max(min(s.Cursor-room/2, len(b.Images)-room), 0)
+	for i := start; i < min(start+room, len(b.Images)); i++ {
+		style := lipgloss.NewStyle()
+		if i == s.Cursor {
+			style = m.Styler.SelectedItemStyle()
+		}
+		width := inner - colStyle.GetHorizontalPadding()
+		name := truncate(fmt.Sprintf("%2d %s", i+1, filepath.Base(b.Images[i])), width-style.GetHorizontalPadding())
+		lines = append(lines, style.Width(width).Render(name))
+	}
+	if len(b.Images) == 0 {
+		lines = append(lines, m.Styler.MutedStyle().Render("No images yet. Select some"), m.Styler.MutedStyle().Render("and press B to add them."))
+	}
+	// Width and Height count padding but not the border
+	list := colStyle.Width(inner).Height(height - colStyle.GetVerticalBorderSize()).MaxHeight(height).
+		Render(strings.Join(lines, "\n"))
+
+	title := m.Styler.PreviewTitleStyle().Render("Preview")
+	if s.Missing > 0 {
+		title += m.Styler.MutedStyle().Render(fmt.Sprintf("  %d images missing", s.Missing))
+	}
+	preview := s.Preview
+	if preview == "" && len(b.Images) > 0 {
+		preview = m.Styler.MutedStyle().Render("composing…")
+	}
+	preview = lipgloss.Place(cols, rows, lipgloss.Center, lipgloss.Center, preview)
+	pane := m.Styler.ImagePreviewStyle().Render(title + "\n" + preview)
+
+	footer := m.Styler.MutedStyle().MaxWidth(m.WindowWidth).
+		Render("j/k move · J/K reorder · d remove · L layout · +/- columns · [/] spacing · c color · e export · esc close")
+	if m.Prompt.Kind != "" {
+		footer = m.Styler.StatusBarStyle().Width(m.WindowWidth).MaxHeight(1).Render(m.Prompt.Input.View())
+	} else if m.StatusMsg != "" {
+		footer = m.Styler.StatusBarStyle().Width(m.WindowWidth).MaxHeight(1).Render(m.StatusMsg)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lipgloss.JoinHorizontal(lipgloss.Top, list, pane), footer)
+}
+
+// truncate cuts s down to width cells, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
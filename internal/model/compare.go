@@ -0,0 +1,248 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/metadata"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+)
+
+type CompareHandler interface {
+	StartCompare(Model) (tea.Model, tea.Cmd)
+	HandleCompareUpdate(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+	FullImageLoaded(Model, FullImageMsg) (tea.Model, tea.Cmd)
+	Resize(Model) (tea.Model, tea.Cmd)
+}
+
+// CompareSide is one of the two images being compared
+type CompareSide struct {
+	Path  string
+	View  ImageView
+	Image render.Image
+	Meta  []metadata.Field
+}
+
+// Compare shows two images next to each other. The zoom is kept as a
+// multiple of each side's fit and the centre as a fraction of the image, so
+// both sides show the same part of the picture at the same size even when
+// one is a smaller export of the other.
+type Compare struct {
+	Active bool
+	Sides  [2]CompareSide
+	// Zoom is times fit, 0 meaning fit
+	Zoom float64
+	// Actual keeps Zoom at one pixel per screen pixel on the left side,
+	// even once a bigger image is swapped in
+	Actual   bool
+	CX, CY   float64
+	ShowInfo bool
+}
+
+// CompareRow is one line of the EXIF comparison
+type CompareRow struct {
+	Label       string
+	Left, Right string
+}
+
+// Differs reports whether the two sides disagree on this line
+func (r CompareRow) Differs() bool {
+	return r.Left != r.Right
+}
+
+// NewCompare shows the two sides fitted and centred
+func NewCompare(left, right CompareSide) Compare {
+	return Compare{Active: true, Sides: [2]CompareSide{left, right}, CX: 0.5, CY: 0.5, ShowInfo: true}
+}
+
+// Sync sets both sides' views from the shared zoom and centre. The centre
+// is clamped by the left side so panning stops at its edges.
+func (c *Compare) Sync(pw, ph int) {
+	if c.Actual {
+		c.Zoom = 1 / c.Sides[0].View.FitScale(pw, ph)
+	}
+	for i := range c.Sides {
+		v := &c.Sides[i].View
+		v.Zoom = 0
+		if c.Zoom != 0 {
+			v.Zoom = c.Zoom * v.FitScale(pw, ph)
+		}
+		v.CX, v.CY = c.CX, c.CY
+		v.Region(pw, ph)
+	}
+	c.CX, c.CY = c.Sides[0].View.CX, c.Sides[0].View.CY
+	for i := range c.Sides {
+		c.Sides[i].View.CX, c.Sides[i].View.CY = c.CX, c.CY
+	}
+}
+
+// ZoomBy steps the shared zoom, no further than either side can go
+func (c *Compare) ZoomBy(steps int, pw, ph int) {
+	c.Sync(pw, ph)
+	c.Actual = false
+	limit := math.Inf(1)
+	for _, side := range c.Sides {
+		limit = math.Min(limit, maxZoom/side.View.FitScale(pw, ph))
+	}
+	if limit <= 1 {
+		return
+	}
+	z := math.Max(c.Zoom, 1) * math.Pow(zoomStep, float64(steps))
+	if z <= 1 {
+		c.Zoom = 0
+		return
+	}
+	c.Zoom = math.Min(z, limit)
+}
+
+// ZoomActual zooms to one pixel per screen pixel on the left side
+func (c *Compare) ZoomActual(pw, ph int) {
+	c.Actual = true
+	c.Sync(pw, ph)
+}
+
+// Pan moves the view by a fraction of what's visible
+func (c *Compare) Pan(dx, dy float64, pw, ph int) {
+	c.Sync(pw, ph)
+	v := c.Sides[0].View
+	v.Pan(dx, dy, pw, ph)
+	c.CX, c.CY = v.CX, v.CY
+}
+
+// Swap puts the right image on the left
+func (c *Compare) Swap() {
+	c.Sides[0], c.Sides[1] = c.Sides[1], c.Sides[0]
+}
+
+// Rows lines up both sides' metadata, in the left side's order with
+// anything only the right has at the end
+func (c Compare) Rows() []CompareRow {
+	right := make(map[string]string)
+	for _, f := range c.Sides[1].Meta {
+		right[f.Label] = f.Value
+	}
+
+	var rows []CompareRow
+	seen := make(map[string]bool)
+	for _, f := range c.Sides[0].Meta {
+		seen[f.Label] = true
+		rows = append(rows, CompareRow{Label: f.Label, Left: f.Value, Right: right[f.Label]})
+	}
+	for _, f := range c.Sides[1].Meta {
+		if !seen[f.Label] {
+			rows = append(rows, CompareRow{Label: f.Label, Right: f.Value})
+		}
+	}
+	return rows
+}
+
+// Label describes the zoom for the titles
+func (c Compare) Label() string {
+	if c.Zoom == 0 {
+		return ""
+	}
+	if c.Actual && !c.Sides[0].View.Reduced {
+		return "100%"
+	}
+	return fmt.Sprintf("×%.2g", c.Zoom)
+}
+
+// ComparePane is the number of cells each side's image gets
+func (m Model) ComparePane() (int, int) {
+	fw, fh := m.Styler.ImagePreviewStyle().GetFrameSize()
+	// A title line in each pane and the footer under them
+	rows := m.WindowHeight - fh - 2 - m.compareInfoHeight()
+	return max(m.WindowWidth/2-fw, 10), max(rows, 5)
+}
+
+// CompareOrigin is the screen cell where side's image starts
+func (m Model) CompareOrigin(side int) (int, int) {
+	style := m.Styler.ImagePreviewStyle()
+	cols, _ := m.ComparePane()
+	x := style.GetMarginLeft() + style.GetBorderLeftSize() + style.GetPaddingLeft()
+	y := style.GetMarginTop() + style.GetBorderTopSize() + style.GetPaddingTop() + 1
+	if side == 1 {
+		x += cols + style.GetHorizontalFrameSize()
+	}
+	return x, y
+}
+
+// compareInfoHeight is the room the EXIF table takes, at most a third of
+// the window
+func (m Model) compareInfoHeight() int {
+	if !m.Compare.ShowInfo {
+		return 0
+	}
+	return min(len(m.Compare.Rows())+1, m.WindowHeight/3)
+}
+
+func (m Model) compareView() string {
+	c := m.Compare
+	cols, rows := m.ComparePane()
+
+	var panes []string
+	for _, side := range c.Sides {
+		title := m.Styler.PreviewTitleStyle().Render(filepath.Base(side.Path))
+		if zoom := c.Label(); zoom != "" {
+			title += m.Styler.MutedStyle().Render("  " + zoom)
+		}
+		title = lipgloss.NewStyle().MaxWidth(cols).Render(title)
+		image := lipgloss.Place(cols, rows, lipgloss.Left, lipgloss.Top, side.Image.Text)
+		panes = append(panes, m.Styler.ImagePreviewStyle().Render(title+"\n"+image))
+	}
+
+	parts := []string{lipgloss.JoinHorizontal(lipgloss.Top, panes...)}
+	if c.ShowInfo {
+		parts = append(parts, m.compareInfo())
+	}
+
+	footer := "+/- zoom · = 1:1 · w fit · hjkl pan · i exif · x swap · esc close"
+	if m.StatusMsg != "" {
+		footer = m.StatusMsg + "  " + footer
+	}
+	parts = append(parts, m.Styler.MutedStyle().MaxWidth(m.WindowWidth).Render(footer))
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+// compareInfo is the metadata of both sides in columns, with the lines
+// that differ picked out
+func (m Model) compareInfo() string {
+	height := m.compareInfoHeight()
+	valueWidth := max((m.WindowWidth-metadataLabelWidth)/2-1, 10)
+	label := m.Styler.MutedStyle().Width(metadataLabelWidth)
+	same := lipgloss.NewStyle().Width(valueWidth).MaxWidth(valueWidth).MaxHeight(1)
+	differs := m.Styler.GitStatusStyle(defs.GitModified).Width(valueWidth).MaxWidth(valueWidth).MaxHeight(1)
+
+	// What differs goes first so it's still there when the table is cut
+	// short
+	rows := m.Compare.Rows()
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Differs() && !rows[j].Differs()
+	})
+	changed := 0
+	for _, r := range rows {
+		if r.Differs() {
+			changed++
+		}
+	}
+
+	lines := []string{m.Styler.MutedStyle().Render(fmt.Sprintf("%d of %d fields differ", changed, len(rows)))}
+	for _, r := range rows {
+		if len(lines) >= height {
+			break
+		}
+		value := same
+		if r.Differs() {
+			value = differs
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top,
+			label.Render(r.Label), value.Render(r.Left), " ", value.Render(r.Right)))
+	}
+	return strings.Join(lines, "\n")
+}
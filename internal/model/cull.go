@@ -0,0 +1,98 @@
+package model
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+)
+
+type CullHandler interface {
+	StartCull(Model) (tea.Model, tea.Cmd)
+	HandleCullUpdate(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+}
+
+// Cull is a culling pass: going through images one at a time, keeping or
+// rejecting each. Decisions are saved to sidecars as picks and rejects as
+// they're made, so quitting halfway loses nothing.
+type Cull struct {
+	Active    bool
+	Paths     []string
+	Index     int
+	Decisions map[string]defs.Flag
+	// Done shows the summary instead of the preview
+	Done bool
+	// Moving is set while the rejects are being moved
+	Moving bool
+}
+
+// CullMovedMsg reports moving a pass's rejects out of the way
+type CullMovedMsg struct {
+	Moved int
+	Dest  string
+	Err   error
+}
+
+// Path is the image being culled
+func (c Cull) Path() string {
+	return c.Paths[c.Index]
+}
+
+// Count is how many images have been marked flag
+func (c Cull) Count(flag defs.Flag) int {
+	n := 0
+	for _, path := range c.Paths {
+		if c.Decisions[path] == flag {
+			n++
+		}
+	}
+	return n
+}
+
+// Rejects lists the rejected images in order
+func (c Cull) Rejects() []string {
+	var out []string
+	for _, path := range c.Paths {
+		if c.Decisions[path] == defs.FlagReject {
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+// Label is the progress shown over the image
+func (c Cull) Label() string {
+	return fmt.Sprintf("%d/%d · %d kept · %d rejected",
+		c.Index+1, len(c.Paths), c.Count(defs.FlagPick), c.Count(defs.FlagReject))
+}
+
+func (m Model) cullSummaryView() string {
+	c := m.Cull
+	muted := m.Styler.MutedStyle()
+	lines := []string{
+		m.Styler.PreviewTitleStyle().Render("Culling done"),
+		"",
+		fmt.Sprintf("%d images", len(c.Paths)),
+		fmt.Sprintf("%d kept", c.Count(defs.FlagPick)),
+		fmt.Sprintf("%d rejected", c.Count(defs.FlagReject)),
+		fmt.Sprintf("%d undecided", c.Count(defs.FlagNone)),
+		"",
+	}
+	if c.Count(defs.FlagReject) > 0 {
+		lines = append(lines,
+			"m  move rejects to _rejects",
+			"t  move rejects to the trash",
+		)
+	}
+	lines = append(lines,
+		"b  back to the last image",
+		"q  done, leave the files where they are",
+	)
+	if m.StatusMsg != "" {
+		lines = append(lines, "", muted.Render(m.StatusMsg))
+	}
+
+	box := m.Styler.ImagePreviewStyle().Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	return lipgloss.Place(m.WindowWidth, m.WindowHeight, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/dupes"
+	"github.com/nooooaaaaah/photoboard/internal/metadata"
+)
+
+type DupesHandler interface {
+	StartDupes(Model) (tea.Model, tea.Cmd)
+	HandleDupesUpdate(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+	Scanned(Model, DupesScannedMsg) (tea.Model, tea.Cmd)
+	LoadGroup(Model) (tea.Model, tea.Cmd)
+}
+
+// DupesState is the duplicate review: groups of copies, one shown at a
+// time side by side, with files marked for deletion
+type DupesState struct {
+	Root     string
+	Scanning bool
+	Progress string
+	Groups   []dupes.Group
+	Group    int
+	Cursor   int
+	// Marks holds each group's files marked for deletion, so trashing
+	// only ever touches the group on screen
+	Marks  []map[string]bool
+	Thumbs map[string]string
+	// Confirm asks before the marked files go to the trash
+	Confirm bool
+	// Updates brings progress and then the result of the running scan,
+	// which Cancel stops
+	Updates <-chan tea.Msg
+	Cancel  context.CancelFunc
+}
+
+// Listen waits for the scan's next message. The model calls it again
+// after every progress report.
+func (d DupesState) Listen() tea.Cmd {
+	updates := d.Updates
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// DupesScannedMsg carries the result of a duplicate scan
+type DupesScannedMsg struct {
+	Root   string
+	Groups []dupes.Group
+	Err    error
+}
+
+// DupesProgressMsg reports how far a scan has got
+type DupesProgressMsg struct {
+	Root        string
+	Done, Total int
+}
+
+// DupesTrashedMsg reports moving marked duplicates to the trash
+type DupesTrashedMsg struct {
+	Trashed []string
+	Err     error
+}
+
+// DupeThumbMsg delivers a rendered preview for the review
+type DupeThumbMsg struct {
+	Path string
+	Text string
+}
+
+// Marked is the current group's files marked for deletion
+func (d DupesState) Marked() map[string]bool {
+	if d.Group >= len(d.Marks) {
+		return nil
+	}
+	return d.Marks[d.Group]
+}
+
+// Remove drops paths from the groups, along with groups that no longer
+// have a copy to compare
+func (d *DupesState) Remove(paths []string) {
+	gone := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		gone[path] = true
+	}
+
+	groups := d.Groups[:0]
+	marks := d.Marks[:0]
+	for i, g := range d.Groups {
+		images := g.Images[:0]
+		for _, img := range g.Images {
+			if !gone[img.Path] {
+				images = append(images, img)
+			}
+		}
+		if len(images) > 1 {
+			for path := range gone {
+				delete(d.Marks[i], path)
+			}
+			g.Images = images
+			groups = append(groups, g)
+			marks = append(marks, d.Marks[i])
+		}
+	}
+	d.Groups = groups
+	d.Marks = marks
+	d.Group = min(d.Group, max(len(groups)-1, 0))
+	d.Cursor = 0
+}
+
+// DupeCardWidth is the most a preview in the review gets, so two or three
+// still fit side by side
+const DupeCardWidth = 48
+
+// DupeCards is how many previews fit across and how big each one is
+func (m Model) DupeCards() (n, w, h int) {
+	n = max(m.WindowWidth/DupeCardWidth, 1)
+	w = min(m.WindowWidth/n, DupeCardWidth) - 2
+	// Header, caption, footer and the borders
+	h = max(m.WindowHeight-8, 4)
+	return n, w, h
+}
+
+func (m Model) dupesView() string {
+	d := m.Dupes
+	header := m.Styler.HeaderStyle().Width(m.WindowWidth)
+
+	if d.Scanning {
+		text := "Looking for duplicates in " + d.Root
+		if d.Progress != "" {
+			text += "  " + d.Progress
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, header.Render(text), m.Styler.MutedStyle().Render("esc to cancel"))
+	}
+	if len(d.Groups) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, header.Render("No duplicates in "+d.Root), m.Styler.MutedStyle().Render("esc to close"))
+	}
+
+	g := d.Groups[d.Group]
+	kind := fmt.Sprintf("near, up to %d bits from the best", g.Distance)
+	if g.Exact {
+		kind = "identical files"
+	}
+	title := fmt.Sprintf("Duplicates in %s — group %d/%d · %s", d.Root, d.Group+1, len(d.Groups), kind)
+
+	n, w, h := m.DupeCards()
+	start := (d.Cursor / n) * n
+	end := min(start+n, len(g.Images))
+	var cards []string
+	for i := start; i < end; i++ {
+		cards = append(cards, m.dupeCard(g, i, w, h))
+	}
+
+	marked := len(d.Marked())
+	status := fmt.Sprintf("%d/%d", d.Cursor+1, len(g.Images))
+	if marked > 0 {
+		status += fmt.Sprintf("  [%d marked for deletion]", marked)
+	}
+	if m.StatusMsg != "" {
+		status += "  " + m.StatusMsg
+	}
+	if d.Confirm {
+		status = fmt.Sprintf("Move %d files from this group to the trash? y/n", marked)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		header.Render(title),
+		lipgloss.JoinHorizontal(lipgloss.Top, cards...),
+		m.Styler.MutedStyle().Render("←/→ image · n/b group · d mark · k keep only this · enter trash this group's marked · esc close"),
+		m.Styler.StatusBarStyle().Width(m.WindowWidth).MaxHeight(1).Render(status),
+	)
+}
+
+func (m Model) dupeCard(g dupes.Group, i, w, h int) string {
+	img := g.Images[i]
+
+	thumb, ok := m.Dupes.Thumbs[img.Path]
+	if !ok {
+		thumb = m.Styler.MutedStyle().Render("loading…")
+	}
+	thumb = lipgloss.Place(w, h, lipgloss.Center, lipgloss.Center, thumb)
+
+	name := img.Path
+	if rel, err := filepath.Rel(m.Dupes.Root, img.Path); err == nil {
+		name = rel
+	}
+	details := []string{fmt.Sprintf("%d×%d", img.Width, img.Height), metadata.HumanSize(img.Size)}
+	for j := 0; j < i; j++ {
+		if g.Images[j].Sum == img.Sum {
+			details = append(details, fmt.Sprintf("same bytes as %d", j+1))
+			break
+		}
+	}
+	if i == 0 {
+		details = append(details, "best")
+	}
+
+	captionStyle := m.Styler.ItemStyle(defs.FileItem{Filename: filepath.Base(img.Path), Path: img.Path})
+	if i == m.Dupes.Cursor {
+		captionStyle = m.Styler.SelectedItemStyle()
+	}
+	caption := captionStyle.Width(w).MaxWidth(w).Render(name)
+	info := m.Styler.MutedStyle().Width(w).MaxWidth(w).Render(strings.Join(details, " · "))
+	if m.Dupes.Marked()[img.Path] {
+		info = m.Styler.FlagStyle(defs.FlagReject).Width(w).MaxWidth(w).Render("✕ delete · " + strings.Join(details, " · "))
+	}
+
+	border := m.Styler.ColumnStyle().UnsetBorderRight().Border(lipgloss.RoundedBorder())
+	if i == m.Dupes.Cursor {
+		border = border.BorderForeground(m.Styler.ActiveColumnStyle().GetBorderRightForeground())
+	}
+	return border.Render(lipgloss.JoinVertical(lipgloss.Left, thumb, caption, info))
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+)
+
+// Thumbnail cell size in the gallery grid, not counting the caption and
+// the border around each cell
+const (
+	ThumbWidth  = 24
+	ThumbHeight = 10
+)
+
+type GalleryHandler interface {
+	StartGallery(Model) (tea.Model, tea.Cmd)
+	HandleGalleryUpdate(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+	HandleGalleryMouse(Model, tea.MouseMsg) (tea.Model, tea.Cmd)
+	Listen() tea.Cmd
+}
+
+type GalleryState struct {
+	Dir    string
+	Items  []defs.FileItem
+	Cursor int
+	Offset int // first visible grid row
+}
+
+// ThumbCache holds the rendered thumbnails of one directory. It outlives
+// the gallery, so thumbnails that finish while it's closed, like the ones
+// a preview warms up, are there when it opens.
+type ThumbCache struct {
+	Dir  string
+	Text map[string]string
+}
+
+// For returns the thumbnails of dir, starting over when it's a different
+// directory
+func (c *ThumbCache) For(dir string) map[string]string {
+	if c.Dir != dir || c.Text == nil {
+		*c = ThumbCache{Dir: dir, Text: make(map[string]string)}
+	}
+	return c.Text
+}
+
+// ThumbnailMsg delivers a rendered thumbnail for the gallery
+type ThumbnailMsg struct {
+	Dir  string
+	Path string
+	Text string
+	Err  error
+}
+
+// GalleryGrid returns how many thumbnail columns fit across the window and
+// how many rows fit below the header and above the status bar
+func (m Model) GalleryGrid() (cols, rows int) {
+	cols = max(m.WindowWidth/(ThumbWidth+2), 1)
+	rows = max((m.WindowHeight-2)/(ThumbHeight+3), 1)
+	return cols, rows
+}
+
+// VisibleThumbs returns the index range of gallery items currently on screen
+func (m Model) VisibleThumbs() (start, end int) {
+	cols, rows := m.GalleryGrid()
+	start = m.Gallery.Offset * cols
+	end = min(start+cols*rows, len(m.Gallery.Items))
+	return start, end
+}
+
+func (m Model) galleryView() string {
+	cols, rows := m.GalleryGrid()
+	start, end := m.VisibleThumbs()
+
+	dir := ""
+	if m.ActiveColumn < len(m.Columns) {
+		dir = filepath.Base(m.Columns[m.ActiveColumn].Path)
+	}
+	header := m.Styler.HeaderStyle().Width(m.WindowWidth).
+		Render(fmt.Sprintf("%s — %d images", dir, len(m.Gallery.Items)))
+
+	if len(m.Gallery.Items) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, header, "No images in this directory")
+	}
+
+	var gridRows []string
+	for row := 0; row < rows; row++ {
+		var cells []string
+		for col := 0; col < cols; col++ {
+			i := start + row*cols + col
+			if i >= end {
+				break
+			}
+			cells = append(cells, m.galleryCell(i))
+		}
+		if len(cells) == 0 {
+			break
+		}
+		gridRows = append(gridRows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	status := m.Styler.StatusBarStyle().Width(m.WindowWidth).MaxHeight(1).
+		Render(fmt.Sprintf("%d/%d  %s", m.Gallery.Cursor+1, len(m.Gallery.Items), m.Gallery.Items[m.Gallery.Cursor].Filename))
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, strings.Join(gridRows, "\n"), status)
+}
+
+// thumbnailText is what goes in a gallery cell for a finished thumbnail,
+// with a short note in place of images that failed
+func (m Model) thumbnailText(msg ThumbnailMsg) string {
+	var unsupported *imaging.UnsupportedError
+	switch {
+	case msg.Err == nil:
+		return msg.Text
+	case errors.As(msg.Err, &unsupported):
+		return strings.ToUpper(unsupported.Format) + "\n" + m.Styler.MutedStyle().Render("metadata only")
+	}
+	return m.Styler.MutedStyle().Render("no preview")
+}
+
+func (m Model) galleryCell(i int) string {
+	item := m.Gallery.Items[i]
+
+	thumb, ok := m.Thumbs.Text[item.Path]
+	if !ok {
+		thumb = m.Styler.MutedStyle().Render("loading…")
+	}
+	thumb = lipgloss.Place(ThumbWidth, ThumbHeight, lipgloss.Center, lipgloss.Center, thumb)
+
+	captionStyle := m.Styler.ItemStyle(item)
+	if i == m.Gallery.Cursor {
+		captionStyle = m.Styler.SelectedItemStyle()
+	}
+	caption := captionStyle.Width(ThumbWidth).MaxWidth(ThumbWidth).Render(item.Filename)
+
+	border := m.Styler.ColumnStyle().UnsetBorderRight().Border(lipgloss.RoundedBorder())
+	if i == m.Gallery.Cursor {
+		border = border.BorderForeground(m.Styler.ActiveColumnStyle().GetBorderRightForeground())
+	}
+
+	return zone.Mark(fmt.Sprintf("thumb-%d", i), border.Render(lipgloss.JoinVertical(lipgloss.Center, thumb, caption)))
+}
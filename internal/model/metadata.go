@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// MetadataWidth is the width of the info panel next to an image preview,
+// border included
+const MetadataWidth = 40
+
+const metadataLabelWidth = 13
+
+// MetadataCopiedMsg reports the metadata has been sent to the clipboard
+type MetadataCopiedMsg struct{}
+
+// MetadataVisible reports whether the info panel is shown next to the
+// image preview
+func (m Model) MetadataVisible() bool {
+	return m.ShowMetadata || m.PreviewUnsupported
+}
+
+// metadataView renders the info panel at the given outer height
+func (m Model) metadataView(height int) string {
+	style := m.Styler.MetadataPanelStyle()
+	fw, _ := style.GetFrameSize()
+	inner := MetadataWidth - fw
+
+	label := m.Styler.MutedStyle().Width(metadataLabelWidth)
+	value := lipgloss.NewStyle().Width(inner - metadataLabelWidth)
+
+	var rows []string
+	rows = append(rows, m.Styler.PreviewTitleStyle().Render("Info"), "")
+	for _, f := range m.PreviewMeta {
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, label.Render(f.Label), value.Render(f.Value)))
+	}
+	if len(m.PreviewMeta) == 0 {
+		rows = append(rows, m.Styler.MutedStyle().Render("No metadata"))
+	}
+
+	rows = append(rows, "")
+	if m.StatusMsg != "" {
+		rows = append(rows, m.StatusMsg)
+	}
+	hint := "i hide · y copy"
+	if m.PreviewUnsupported {
+		hint = "y copy"
+	}
+	rows = append(rows, m.Styler.MutedStyle().Render(hint))
+
+	// Width and Height count padding but not the border
+	return style.
+		Width(MetadataWidth - style.GetHorizontalBorderSize()).
+		Height(max(height-style.GetVerticalBorderSize(), 1)).
+		Render(strings.Join(rows, "\n"))
+}
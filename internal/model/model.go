@@ -9,7 +9,12 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
+	"github.com/nooooaaaaah/photoboard/internal/config"
 	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/git"
+	"github.com/nooooaaaaah/photoboard/internal/metadata"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+	"github.com/nooooaaaaah/photoboard/internal/sidecar"
 	"github.com/nooooaaaaah/photoboard/internal/utils"
 )
 
@@ -20,6 +25,10 @@ type Navigator interface {
 type Previewer interface {
 	HandlePreviewUpdate(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
 	StartPreview(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+	Animate(Model, AnimationTickMsg) (tea.Model, tea.Cmd)
+	FullImageLoaded(Model, FullImageMsg) (tea.Model, tea.Cmd)
+	// Resize draws the open image preview again to fit the window
+	Resize(Model) (tea.Model, tea.Cmd)
 }
 
 type UIHandler interface {
@@ -27,80 +36,359 @@ type UIHandler interface {
 }
 
 type ColumnView struct {
-	List     list.Model
-	Path     string
-	Selected string
-	Width    int
+	List        list.Model
+	Path        string
+	Selected    string
+	Width       int
+	HiddenCount int
+	Git         *git.Info
+}
+
+// GitStatusMsg carries the result of an asynchronous git status for a column
+type GitStatusMsg struct {
+	Path string
+	Info git.Info
+	Err  error
 }
 
 type Model struct {
-	Columns        []ColumnView
-	ActiveColumn   int
-	ShowPreview    bool
-	PreviewContent string
-	Viewport       viewport.Model
-	PreviewIsImage bool
-	imageContent   string
-	Styler         defs.Styler
-	navigator      Navigator
-	previewer      Previewer
-	uiHandler      UIHandler
-	WindowWidth    int
-	WindowHeight   int
+	Columns            []ColumnView
+	ActiveColumn       int
+	ShowPreview        bool
+	PreviewContent     string
+	PreviewTitle       string
+	Viewport           viewport.Model
+	PreviewIsImage     bool
+	PreviewImage       render.Image
+	PreviewPath        string
+	PreviewMeta        []metadata.Field
+	ShowMetadata       bool
+	PreviewUnsupported bool
+	Animation          Animation
+	ImageView          ImageView
+	Slideshow          Slideshow
+	Cull               Cull
+	Compare            Compare
+	Board              BoardState
+	ShowDupes          bool
+	Dupes              DupesState
+	Selection          map[string]bool
+	Filter             sidecar.Filter
+	Prompt             Prompt
+	ShowGallery        bool
+	Gallery            GalleryState
+	Thumbs             ThumbCache
+	imageContent       string
+	Styler             defs.Styler
+	Config             config.Config
+	ShowHidden         bool
+	StatusMsg          string
+	pendingKey         string
+	navigator          Navigator
+	previewer          Previewer
+	gallery            GalleryHandler
+	slideshow          SlideshowHandler
+	cull               CullHandler
+	dupes              DupesHandler
+	compare            CompareHandler
+	board              BoardHandler
+	uiHandler          UIHandler
+	WindowWidth        int
+	WindowHeight       int
 }
 
-func NewModel(path string, styler defs.Styler, nav Navigator, prev Previewer, ui UIHandler) Model {
+// Handlers are the pieces of the explorer the model hands its messages to
+type Handlers struct {
+	Navigator Navigator
+	Previewer Previewer
+	Gallery   GalleryHandler
+	Slideshow SlideshowHandler
+	Cull      CullHandler
+	Dupes     DupesHandler
+	Compare   CompareHandler
+	Board     BoardHandler
+	UI        UIHandler
+}
+
+func NewModel(path string, cfg config.Config, styler defs.Styler, h Handlers) Model {
 	return Model{
 		Columns:      make([]ColumnView, 0),
 		ActiveColumn: 0,
 		Styler:       styler,
-		navigator:    nav,
-		previewer:    prev,
-		uiHandler:    ui,
+		Config:       cfg,
+		ShowHidden:   cfg.ShowHidden,
+		navigator:    h.Navigator,
+		previewer:    h.Previewer,
+		gallery:      h.Gallery,
+		slideshow:    h.Slideshow,
+		cull:         h.Cull,
+		dupes:        h.Dupes,
+		compare:      h.Compare,
+		board:        h.Board,
+		Selection:    make(map[string]bool),
+		uiHandler:    h.UI,
 		WindowWidth:  80,
 		WindowHeight: 24,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	cmds := []tea.Cmd{m.gallery.Listen()}
+	for _, col := range m.Columns {
+		cmds = append(cmds, LoadGitStatus(col.Path))
+	}
+	return tea.Batch(cmds...)
+}
+
+// LoadGitStatus queries git for path in the background
+func LoadGitStatus(path string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := git.Load(path)
+		return GitStatusMsg{Path: path, Info: info, Err: err}
+	}
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
+			// Overlays would be drawn again on the normal screen as
+			// bubbletea leaves the alternate one
+			render.Output.ClearOverlays()
 			return m, tea.Quit
 		}
 
+		if m.Prompt.Kind != "" {
+			return m.handlePrompt(msg)
+		}
+
+		if m.Cull.Active {
+			return m.cull.HandleCullUpdate(m, msg)
+		}
+
+		if m.ShowDupes {
+			return m.dupes.HandleDupesUpdate(m, msg)
+		}
+
+		if m.Compare.Active {
+			return m.compare.HandleCompareUpdate(m, msg)
+		}
+
+		if m.Board.Active {
+			return m.board.HandleBoardUpdate(m, msg)
+		}
+
+		if m.Slideshow.ID != 0 {
+			return m.slideshow.HandleSlideshowUpdate(m, msg)
+		}
+
 		if m.ShowPreview {
 			return m.previewer.HandlePreviewUpdate(m, msg)
 		}
 
+		if m.ShowGallery {
+			return m.gallery.HandleGalleryUpdate(m, msg)
+		}
+
+		// Resolve two-key sequences such as "zh". Any other key after
+		// the prefix is handled as if the prefix hadn't been pressed.
+		if m.pendingKey != "" {
+			seq := m.pendingKey + msg.String()
+			m.pendingKey = ""
+			if seq == "zh" {
+				m.ToggleHidden()
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
+		case "z":
+			m.pendingKey = "z"
+			return m, nil
+		case ".":
+			m.ToggleHidden()
+			return m, nil
 		case "p":
 			return m.previewer.StartPreview(m, msg)
+		case "g":
+			return m.gallery.StartGallery(m)
+		case "s":
+			return m.slideshow.StartSlideshow(m)
+		case "c":
+			return m.cull.StartCull(m)
+		case "D":
+			return m.dupes.StartDupes(m)
+		case "C":
+			return m.compare.StartCompare(m)
+		case "B":
+			return m, m.OpenPrompt("board", "")
+		case " ":
+			m.ToggleSelection()
+			return m, nil
+		case "t":
+			if path := m.highlightedFile(); path != "" {
+				return m, m.OpenPrompt("tags", path)
+			}
+			return m, nil
+		case "F":
+			return m, m.OpenPrompt("filter", "")
 		case "enter", "l", "backspace", "h", "home":
 			return m.navigator.HandleNavigation(m, msg)
 		}
+		if m.HandleTagKey(msg.String(), m.highlightedFile()) {
+			return m, nil
+		}
 
 	case tea.WindowSizeMsg:
-		return m.uiHandler.HandleWindowResize(m, msg)
+		updated, cmd := m.uiHandler.HandleWindowResize(m, msg)
+		m = updated.(Model)
+		// Images are rendered to fit the window, so draw whatever's open
+		// again at the new size
+		var redraw tea.Cmd
+		switch {
+		case m.Compare.Active:
+			updated, redraw = m.compare.Resize(m)
+		case m.Board.Active:
+			updated, redraw = m.board.Resize(m)
+		case m.ShowPreview && m.PreviewIsImage:
+			updated, redraw = m.previewer.Resize(m)
+		}
+		return updated, tea.Batch(cmd, redraw)
+
+	case GitStatusMsg:
+		if msg.Err != nil {
+			return m, nil
+		}
+		for i := range m.Columns {
+			if m.Columns[i].Path == msg.Path {
+				info := msg.Info
+				m.Columns[i].Git = &info
+				m.Columns[i].applyGitStatus()
+			}
+		}
+		return m, nil
+
+	case AnimationTickMsg:
+		// Ticks for a closed preview or an earlier playback stop here,
+		// which ends their loop
+		if !m.ShowPreview || msg.ID != m.Animation.ID || !m.Animation.Playing() {
+			return m, nil
+		}
+		return m.previewer.Animate(m, msg)
+
+	case FullImageMsg:
+		if m.Compare.Active {
+			return m.compare.FullImageLoaded(m, msg)
+		}
+		// The preview may have moved on while the image loaded
+		if !m.ShowPreview || !m.PreviewIsImage || msg.Path != m.PreviewPath {
+			return m, nil
+		}
+		return m.previewer.FullImageLoaded(m, msg)
+
+	case SlideshowTickMsg:
+		if msg.ID != m.Slideshow.ID || m.Slideshow.Paused {
+			return m, nil
+		}
+		return m.slideshow.NextSlide(m, msg)
+
+	case CullMovedMsg:
+		m.Cull = Cull{}
+		if msg.Err != nil {
+			m.StatusMsg = fmt.Sprintf("Moved %d rejects, then: %v", msg.Moved, msg.Err)
+		} else {
+			m.StatusMsg = fmt.Sprintf("Moved %d rejects to %s", msg.Moved, msg.Dest)
+		}
+		m.RefreshColumns()
+		return m, nil
+
+	case DupesProgressMsg:
+		if !m.ShowDupes || msg.Root != m.Dupes.Root || !m.Dupes.Scanning {
+			return m, nil
+		}
+		m.Dupes.Progress = fmt.Sprintf("%d/%d images", msg.Done, msg.Total)
+		return m, m.Dupes.Listen()
+
+	case DupesScannedMsg:
+		if !m.ShowDupes || msg.Root != m.Dupes.Root {
+			return m, nil
+		}
+		return m.dupes.Scanned(m, msg)
+
+	case DupesTrashedMsg:
+		m.Dupes.Remove(msg.Trashed)
+		if msg.Err != nil {
+			m.StatusMsg = fmt.Sprintf("Trashed %d files, then: %v", len(msg.Trashed), msg.Err)
+		} else {
+			m.StatusMsg = fmt.Sprintf("Trashed %d files", len(msg.Trashed))
+		}
+		m.RefreshColumns()
+		if !m.ShowDupes {
+			return m, nil
+		}
+		return m.dupes.LoadGroup(m)
+
+	case BoardPreviewMsg:
+		if !m.Board.Active || msg.Version != m.Board.Version {
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.StatusMsg = "Couldn't compose the board: " + msg.Err.Error()
+			return m, nil
+		}
+		m.Board.Preview = msg.Text
+		m.Board.Missing = msg.Missing
+		return m, nil
+
+	case BoardExportedMsg:
+		switch {
+		case msg.Err != nil:
+			m.StatusMsg = "Export failed: " + msg.Err.Error()
+		case msg.Missing > 0:
+			m.StatusMsg = fmt.Sprintf("Exported to %s, leaving out %d missing images", msg.Path, msg.Missing)
+		default:
+			m.StatusMsg = "Exported to " + msg.Path
+		}
+		m.RefreshColumns()
+		return m, nil
+
+	case MetadataCopiedMsg:
+		m.StatusMsg = "Copied metadata to clipboard"
+		return m, nil
+
+	case DupeThumbMsg:
+		if m.ShowDupes {
+			m.Dupes.Thumbs[msg.Path] = msg.Text
+		}
+		return m, nil
+
+	case ThumbnailMsg:
+		// Stragglers from another directory don't push out the open
+		// gallery's thumbnails
+		if !m.ShowGallery || msg.Dir == m.Gallery.Dir {
+			m.Thumbs.For(msg.Dir)[msg.Path] = m.thumbnailText(msg)
+		}
+		return m, m.gallery.Listen()
 
 	case tea.MouseMsg:
-		if msg.Action != tea.MouseActionRelease {
+		if msg.Action != tea.MouseActionRelease || m.Cull.Done || m.ShowDupes || m.Compare.Active || m.Board.Active {
 			return m, nil
 		}
 
 		// Handle preview mode clicks
 		if m.ShowPreview {
 			if zone.Get("exit-preview").InBounds(msg) {
-				m.ShowPreview = false
-				return m, nil
+				m.Slideshow = Slideshow{}
+				m.Cull = Cull{}
+				return m, m.ClosePreview()
 			}
 			return m, nil
 		}
 
+		if m.ShowGallery {
+			return m.gallery.HandleGalleryMouse(m, msg)
+		}
+
 		// Handle list item clicks
 		if m.ActiveColumn < len(m.Columns) {
 			activeList := m.Columns[m.ActiveColumn].List
@@ -127,6 +415,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Keep the prompt's cursor blinking
+	if m.Prompt.Kind != "" {
+		var cmd tea.Cmd
+		m.Prompt.Input, cmd = m.Prompt.Input.Update(msg)
+		return m, cmd
+	}
+
 	// If we have an active column, update its list
 	if m.ActiveColumn < len(m.Columns) {
 		var cmd tea.Cmd
@@ -137,12 +432,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// highlightedFile is the path of the file under the cursor in the active
+// column, or "" for a directory
+func (m Model) highlightedFile() string {
+	if m.ActiveColumn >= len(m.Columns) {
+		return ""
+	}
+	if item, ok := m.Columns[m.ActiveColumn].List.SelectedItem().(defs.FileItem); ok && !item.IsDir {
+		return item.Path
+	}
+	return ""
+}
+
 func (m Model) View() string {
+	if m.Cull.Done {
+		return m.cullSummaryView()
+	}
+
+	if m.ShowDupes {
+		return m.dupesView()
+	}
+
+	if m.Compare.Active {
+		return m.compareView()
+	}
+
+	if m.Board.Active {
+		return m.boardView()
+	}
+
 	if m.ShowPreview {
+		title := m.Styler.PreviewTitleStyle().Render(m.PreviewTitle)
+		if a := m.Animation; a.ID != 0 {
+			state := fmt.Sprintf("  frame %d/%d", a.Frame+1, len(a.Frames))
+			if a.Paused {
+				state += " · paused"
+			}
+			title += m.Styler.MutedStyle().Render(state)
+		}
+		if zoom := m.ImageView.Label(); zoom != "" {
+			title += m.Styler.MutedStyle().Render("  " + zoom)
+		}
+		if m.Slideshow.ID != 0 {
+			title += m.Styler.MutedStyle().Render("  " + m.Slideshow.Label())
+		}
+		if m.Cull.Active {
+			title += m.Styler.MutedStyle().Render("  " + m.Cull.Label())
+		}
 		if m.PreviewIsImage {
-			return m.Styler.ImagePreviewStyle().Render(m.Viewport.View())
+			preview := m.Styler.ImagePreviewStyle().Render(title + "\n" + m.Viewport.View())
+			if m.MetadataVisible() {
+				return lipgloss.JoinHorizontal(lipgloss.Top, preview, m.metadataView(lipgloss.Height(preview)))
+			}
+			return preview
 		}
-		return m.Styler.FilePreviewStyle().Render(m.Viewport.View())
+		return m.Styler.FilePreviewStyle().Render(title + "\n" + m.Viewport.View())
+	}
+
+	if m.ShowGallery {
+		return m.galleryView()
 	}
 
 	if len(m.Columns) == 0 {
@@ -186,35 +534,45 @@ func (m Model) View() string {
 		}
 
 		// Create column header
-		headerStyle := lipgloss.NewStyle().
-			Bold(true).
-			Padding(0, 1).
-			Width(columnWidth - 2).
-			Background(lipgloss.Color("240"))
+		headerStyle := m.Styler.HeaderStyle().Width(columnWidth - 2)
 
-		header := headerStyle.Render(filepath.Base(col.Path))
+		title := filepath.Base(col.Path)
+		if col.HiddenCount > 0 {
+			title = fmt.Sprintf("%s (%d hidden)", title, col.HiddenCount)
+		}
+		if col.Git != nil && col.Git.Branch != "" {
+			title = fmt.Sprintf("%s [%s]", title, col.Git.Branch)
+		}
+		header := headerStyle.Render(title)
 
 		// Create list items
 		var items []string
 		for j, item := range col.List.Items() {
 			if fileItem, ok := item.(defs.FileItem); ok {
-				itemStyle := lipgloss.NewStyle().
-					Width(columnWidth-2).
-					Padding(0, 1)
-
-				if j == col.List.Index() && i == m.ActiveColumn {
-					itemStyle = itemStyle.
-						Background(lipgloss.Color("205")).
-						Foreground(lipgloss.Color("0"))
+				selected := j == col.List.Index() && i == m.ActiveColumn
+
+				itemStyle := m.Styler.ItemStyle(fileItem)
+				name := fileItem.Filename
+				if selected {
+					itemStyle = m.Styler.SelectedItemStyle()
+				} else {
+					name = m.Styler.NameStyle(fileItem).Render(name)
 				}
+				itemStyle = itemStyle.Width(columnWidth - 2)
 
-				prefix := "  "
-				if fileItem.IsDir {
-					prefix = "▶ "
+				label := m.Styler.ItemIcon(fileItem) + " " + name
+				if m.Selection[fileItem.Path] {
+					label = "* " + label
+				}
+				if marker := m.gitMarker(fileItem.GitStatus); marker != "" {
+					label += " " + marker
+				}
+				if marker := m.tagMarker(fileItem.Tags); marker != "" {
+					label += " " + marker
 				}
 
 				zoneID := fmt.Sprintf("item-%d", j)
-				itemContent := zone.Mark(zoneID, itemStyle.Render(prefix+fileItem.Filename))
+				itemContent := zone.Mark(zoneID, itemStyle.Render(label))
 				items = append(items, itemContent)
 			}
 		}
@@ -223,7 +581,80 @@ func (m Model) View() string {
 		columns = append(columns, style.Render(columnContent))
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Left, columns...)
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Left, columns...),
+		m.statusBar(),
+	)
+}
+
+func (m Model) statusBar() string {
+	if m.ActiveColumn >= len(m.Columns) {
+		return ""
+	}
+	col := m.Columns[m.ActiveColumn]
+
+	if m.Prompt.Kind != "" {
+		return m.Styler.StatusBarStyle().Width(m.WindowWidth).MaxHeight(1).Render(m.Prompt.Input.View())
+	}
+
+	text := fmt.Sprintf("%s  %d/%d", col.Path, col.List.Index()+1, len(col.List.Items()))
+	if m.ShowHidden {
+		text += "  [hidden shown]"
+	}
+	if m.Filter.Active() {
+		text += "  [filter: " + m.Filter.String() + "]"
+	}
+	if n := len(m.Selection); n > 0 {
+		text += fmt.Sprintf("  [%d selected]", n)
+	}
+	if m.StatusMsg != "" {
+		text += "  " + m.StatusMsg
+	}
+	return m.Styler.StatusBarStyle().Width(m.WindowWidth).MaxHeight(1).Render(text)
+}
+
+// OpenPreview selects path in the active column and previews it
+func (m Model) OpenPreview(path string) (tea.Model, tea.Cmd) {
+	if m.ActiveColumn >= len(m.Columns) {
+		return m, nil
+	}
+
+	col := &m.Columns[m.ActiveColumn]
+	for i, item := range col.List.Items() {
+		if fileItem, ok := item.(defs.FileItem); ok && fileItem.Path == path {
+			col.List.Select(i)
+			return m.previewer.StartPreview(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+		}
+	}
+	return m, nil
+}
+
+// ClosePreview leaves preview mode and returns the command that removes any
+// terminal graphics the preview left behind
+func (m *Model) ClosePreview() tea.Cmd {
+	image := m.PreviewImage
+	teardown := image.Teardown
+	// Every frame of an animation may have left an image behind
+	for _, frame := range m.Animation.Rendered {
+		if frame != nil && frame.Teardown != image.Teardown {
+			teardown += frame.Teardown
+		}
+	}
+
+	m.ShowPreview = false
+	m.PreviewPath = ""
+	m.PreviewImage = render.Image{}
+	m.Animation = Animation{}
+	m.ImageView = ImageView{}
+	m.StatusMsg = ""
+
+	// Overlays are painted over the text grid, so force a full repaint to
+	// get rid of them
+	if image.Overlay {
+		render.Output.ClearOverlays()
+		return tea.Batch(render.Emit(teardown), tea.ClearScreen)
+	}
+	return render.Emit(teardown)
 }
 
 func (m *Model) AddColumn(path string, width int) error {
@@ -234,7 +665,7 @@ func (m *Model) AddColumn(path string, width int) error {
 		maxColumns = 1
 	}
 
-	items, err := utils.GetFiles(path)
+	items, hidden, err := utils.GetFiles(path, m.listOptions())
 	if err != nil {
 		return err
 	}
@@ -250,9 +681,10 @@ func (m *Model) AddColumn(path string, width int) error {
 	newList.SetShowHelp(false)
 
 	column := ColumnView{
-		List:  newList,
-		Path:  filepath.Base(path),
-		Width: width,
+		List:        newList,
+		Path:        path,
+		Width:       width,
+		HiddenCount: hidden,
 	}
 
 	// If we're at max columns, remove leftmost column
@@ -264,3 +696,93 @@ func (m *Model) AddColumn(path string, width int) error {
 	m.Columns = append(m.Columns, column)
 	return nil
 }
+
+func (m Model) listOptions() utils.ListOptions {
+	return utils.ListOptions{
+		ShowHidden:         m.ShowHidden,
+		IgnorePatterns:     m.Config.IgnorePatterns,
+		RespectIgnoreFiles: m.Config.RespectIgnoreFiles,
+		Filter:             m.Filter,
+	}
+}
+
+// ToggleSelection marks or unmarks the highlighted file and moves down, so
+// runs of files can be picked by holding space
+func (m *Model) ToggleSelection() {
+	if m.ActiveColumn >= len(m.Columns) {
+		return
+	}
+	col := &m.Columns[m.ActiveColumn]
+	if item, ok := col.List.SelectedItem().(defs.FileItem); ok && !item.IsDir {
+		if m.Selection[item.Path] {
+			delete(m.Selection, item.Path)
+		} else {
+			m.Selection[item.Path] = true
+		}
+	}
+	col.List.CursorDown()
+}
+
+// ToggleHidden flips dotfile/ignored visibility and reloads every column
+func (m *Model) ToggleHidden() {
+	m.ShowHidden = !m.ShowHidden
+	m.RefreshColumns()
+}
+
+// RefreshColumns re-reads each column from disk, keeping the selection on the
+// same filename where it still exists.
+func (m *Model) RefreshColumns() {
+	opts := m.listOptions()
+	for i := range m.Columns {
+		col := &m.Columns[i]
+
+		items, hidden, err := utils.GetFiles(col.Path, opts)
+		if err != nil {
+			continue
+		}
+
+		selected := ""
+		if item, ok := col.List.SelectedItem().(defs.FileItem); ok {
+			selected = item.Filename
+		}
+
+		col.List.SetItems(items)
+		col.HiddenCount = hidden
+		col.applyGitStatus()
+		col.List.Select(0)
+		for j, item := range items {
+			if fileItem, ok := item.(defs.FileItem); ok && fileItem.Filename == selected {
+				col.List.Select(j)
+				break
+			}
+		}
+	}
+}
+
+func (c *ColumnView) applyGitStatus() {
+	if c.Git == nil {
+		return
+	}
+	items := c.List.Items()
+	for i, item := range items {
+		if fileItem, ok := item.(defs.FileItem); ok {
+			items[i] = c.Git.Decorate(fileItem)
+		}
+	}
+	c.List.SetItems(items)
+}
+
+func (m Model) gitMarker(status defs.GitStatus) string {
+	style := m.Styler.GitStatusStyle(status)
+	switch status {
+	case defs.GitModified:
+		return style.Render("M")
+	case defs.GitStaged:
+		return style.Render("S")
+	case defs.GitUntracked:
+		return style.Render("?")
+	case defs.GitIgnored:
+		return style.Render("!")
+	}
+	return ""
+}
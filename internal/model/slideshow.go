@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type SlideshowHandler interface {
+	StartSlideshow(Model) (tea.Model, tea.Cmd)
+	HandleSlideshowUpdate(Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+	NextSlide(Model, SlideshowTickMsg) (tea.Model, tea.Cmd)
+}
+
+// SlideshowTickMsg moves the slideshow on. Like AnimationTickMsg, the ID
+// ties it to one run so stale ticks die out.
+type SlideshowTickMsg struct {
+	ID int
+}
+
+// Slideshow steps through a list of images in the preview. A zero ID means
+// no slideshow is running.
+type Slideshow struct {
+	ID       int
+	Paths    []string
+	Order    []int // indexes into Paths, shuffled or not
+	Index    int   // position in Order
+	Interval time.Duration
+	Paused   bool
+	Shuffle  bool
+	Loop     bool
+}
+
+// NewSlideshow starts a slideshow over paths at start
+func NewSlideshow(paths []string, start int, interval time.Duration) Slideshow {
+	s := Slideshow{
+		ID:       nextAnimationID(),
+		Paths:    paths,
+		Interval: interval,
+		Loop:     true,
+	}
+	s.order(start)
+	return s
+}
+
+// Path is the image being shown
+func (s Slideshow) Path() string {
+	return s.Paths[s.Order[s.Index]]
+}
+
+// Tick schedules the next slide
+func (s Slideshow) Tick() tea.Cmd {
+	id := s.ID
+	return tea.Tick(s.Interval, func(time.Time) tea.Msg {
+		return SlideshowTickMsg{ID: id}
+	})
+}
+
+// Step moves delta slides on. Without loop it stops at either end and
+// returns false.
+func (s *Slideshow) Step(delta int) bool {
+	n := len(s.Order)
+	next := s.Index + delta
+	if !s.Loop && (next < 0 || next >= n) {
+		return false
+	}
+	s.Index = (next%n + n) % n
+	return true
+}
+
+// Restart takes a new ID so a tick from before a pause or a manual step
+// can't cut the next slide short
+func (s *Slideshow) Restart() {
+	s.ID = nextAnimationID()
+}
+
+// ToggleShuffle reorders the slides, keeping the current one on screen
+func (s *Slideshow) ToggleShuffle() {
+	s.Shuffle = !s.Shuffle
+	s.order(s.Order[s.Index])
+}
+
+// order lays out the slides with current first when shuffled, or in
+// directory order otherwise
+func (s *Slideshow) order(current int) {
+	s.Order = make([]int, len(s.Paths))
+	for i := range s.Order {
+		s.Order[i] = i
+	}
+	s.Index = current
+	if s.Shuffle {
+		rand.Shuffle(len(s.Order), func(i, j int) {
+			s.Order[i], s.Order[j] = s.Order[j], s.Order[i]
+		})
+		for i, p := range s.Order {
+			if p == current {
+				s.Order[0], s.Order[i] = s.Order[i], s.Order[0]
+				break
+			}
+		}
+		s.Index = 0
+	}
+}
+
+// Label is the position and state shown over the slide
+func (s Slideshow) Label() string {
+	label := fmt.Sprintf("%d/%d", s.Index+1, len(s.Order))
+	if s.Paused {
+		label += " · paused"
+	}
+	if s.Shuffle {
+		label += " · shuffle"
+	}
+	if !s.Loop {
+		label += " · no loop"
+	}
+	return label
+}
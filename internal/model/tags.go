@@ -0,0 +1,234 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nooooaaaaah/photoboard/internal/board"
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/metadata"
+	"github.com/nooooaaaaah/photoboard/internal/sidecar"
+)
+
+// Prompt is a one-line text input shown in place of the status bar
+type Prompt struct {
+	Kind  string // "tags" or "filter", empty when closed
+	Path  string // file whose tags are being edited
+	Input textinput.Model
+}
+
+// tagChange maps the culling keys to what they do to a file's tags: 0-5
+// rate, 6-9 toggle a color label, P and x toggle pick and reject, u clears
+// the flag
+func tagChange(key string) (func(*defs.Tags), bool) {
+	switch key {
+	case "0", "1", "2", "3", "4", "5":
+		n := int(key[0] - '0')
+		return func(t *defs.Tags) {
+			t.Rating = n
+			// Rating a reject means it's back in the running
+			if t.Flag == defs.FlagReject {
+				t.Flag = defs.FlagNone
+			}
+		}, true
+	case "6", "7", "8", "9":
+		label := sidecar.Labels[key[0]-'6']
+		return func(t *defs.Tags) {
+			if t.Label == label {
+				t.Label = ""
+			} else {
+				t.Label = label
+			}
+		}, true
+	case "P":
+		return func(t *defs.Tags) { t.Flag = toggleFlag(t.Flag, defs.FlagPick) }, true
+	case "x":
+		return func(t *defs.Tags) { t.Flag = toggleFlag(t.Flag, defs.FlagReject) }, true
+	case "u":
+		return func(t *defs.Tags) { t.Flag = defs.FlagNone }, true
+	}
+	return nil, false
+}
+
+func toggleFlag(current, flag defs.Flag) defs.Flag {
+	if current == flag {
+		return defs.FlagNone
+	}
+	return flag
+}
+
+// HandleTagKey applies a culling key to the file at path. It reports false
+// for keys that aren't about tags.
+func (m *Model) HandleTagKey(key, path string) bool {
+	change, ok := tagChange(key)
+	if !ok || path == "" {
+		return false
+	}
+	if err := m.TagFile(path, change); err != nil {
+		m.StatusMsg = "Couldn't save tags: " + err.Error()
+	}
+	return true
+}
+
+// TagFile changes the tags of path, saves them to its sidecar and updates
+// every listed file that shares the sidecar
+func (m *Model) TagFile(path string, change func(*defs.Tags)) error {
+	tags, err := sidecar.Read(path)
+	if err != nil {
+		return err
+	}
+	change(&tags)
+	if err := sidecar.Write(path, tags); err != nil {
+		return err
+	}
+
+	side := sidecar.Path(path)
+	for i := range m.Columns {
+		col := &m.Columns[i]
+		if col.Path != filepath.Dir(path) {
+			continue
+		}
+
+		var items []list.Item
+		for _, item := range col.List.Items() {
+			if fileItem, ok := item.(defs.FileItem); ok && !fileItem.IsDir && sharesSidecar(fileItem.Path, side) {
+				fileItem.Tags = tags
+				if !m.Filter.Match(tags) {
+					col.HiddenCount++
+					continue
+				}
+				item = fileItem
+			}
+			items = append(items, item)
+		}
+		index := col.List.Index()
+		col.List.SetItems(items)
+		col.List.Select(min(index, len(items)-1))
+	}
+
+	if m.ShowPreview && m.PreviewPath == path {
+		if info, err := metadata.Read(path); err == nil {
+			m.PreviewMeta = info.Fields
+		}
+	}
+	return nil
+}
+
+func sharesSidecar(path, side string) bool {
+	for _, name := range sidecar.Names(path) {
+		if name == filepath.Base(side) {
+			return sidecar.Path(path) == side
+		}
+	}
+	return false
+}
+
+// OpenPrompt starts editing tags ("tags"), the filter ("filter"), or asks
+// for a board to open and add the selection to ("board") or a board's color
+// or export path
+// ("board-color", "board-export")
+func (m *Model) OpenPrompt(kind, path string) tea.Cmd {
+	input := textinput.New()
+	input.CharLimit = 256
+	switch kind {
+	case "tags":
+		tags, _ := sidecar.Read(path)
+		input.Prompt = "tags: "
+		input.Placeholder = "comma separated"
+		input.SetValue(strings.Join(tags.Keywords, ", "))
+	case "filter":
+		input.Prompt = "filter: "
+		input.Placeholder = "e.g. 4+ red -reject #beach"
+		input.SetValue(m.Filter.String())
+	case "board":
+		input.Prompt = "open board: "
+		if n := len(m.Selection); n > 0 {
+			input.Prompt = fmt.Sprintf("add %d selected to board: ", n)
+		}
+		input.Placeholder = "name"
+		if names, _ := board.Names(); len(names) > 0 {
+			input.Placeholder = "name, e.g. " + strings.Join(names, ", ")
+		}
+	case "board-color":
+		input.Prompt = "background: "
+		input.Placeholder = "#rrggbb"
+		input.SetValue(m.Board.Board.Background)
+	case "board-export":
+		input.Prompt = "export to: "
+		input.Placeholder = "file.png or file.jpg"
+		dir := "."
+		if m.ActiveColumn < len(m.Columns) {
+			dir = m.Columns[m.ActiveColumn].Path
+		}
+		input.SetValue(filepath.Join(dir, m.Board.Board.Name+".png"))
+	}
+	input.CursorEnd()
+	m.Prompt = Prompt{Kind: kind, Path: path, Input: input}
+	return m.Prompt.Input.Focus()
+}
+
+func (m Model) handlePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Prompt = Prompt{}
+		return m, nil
+	case "enter":
+		prompt := m.Prompt
+		m.Prompt = Prompt{}
+		value := prompt.Input.Value()
+
+		switch prompt.Kind {
+		case "tags":
+			var keywords []string
+			for _, k := range strings.Split(value, ",") {
+				if k = strings.TrimSpace(k); k != "" {
+					keywords = append(keywords, k)
+				}
+			}
+			err := m.TagFile(prompt.Path, func(t *defs.Tags) { t.Keywords = keywords })
+			if err != nil {
+				m.StatusMsg = "Couldn't save tags: " + err.Error()
+			}
+		case "filter":
+			filter, err := sidecar.ParseFilter(value)
+			if err != nil {
+				m.StatusMsg = err.Error()
+				return m, nil
+			}
+			m.Filter = filter
+			m.RefreshColumns()
+		case "board", "board-color", "board-export":
+			return m.board.HandleBoardPrompt(m, prompt.Kind, value)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.Prompt.Input, cmd = m.Prompt.Input.Update(msg)
+	return m, cmd
+}
+
+// tagMarker shows a file's rating, label and flag after its name
+func (m Model) tagMarker(tags defs.Tags) string {
+	var parts []string
+	switch tags.Flag {
+	case defs.FlagPick:
+		parts = append(parts, m.Styler.FlagStyle(tags.Flag).Render("⚑"))
+	case defs.FlagReject:
+		parts = append(parts, m.Styler.FlagStyle(tags.Flag).Render("✕"))
+	}
+	if tags.Rating > 0 {
+		parts = append(parts, m.Styler.RatingStyle().Render(strings.Repeat("★", tags.Rating)))
+	}
+	if tags.Label != "" {
+		parts = append(parts, m.Styler.LabelStyle(tags.Label).Render("●"))
+	}
+	if len(tags.Keywords) > 0 {
+		parts = append(parts, m.Styler.MutedStyle().Render("#"))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+const (
+	zoomStep = 1.25
+	// maxZoom is the most screen pixels one image pixel is blown up to
+	maxZoom = 32.0
+)
+
+// ImageView is the zoom and pan of the image preview. The decoded image is
+// kept so zooming only re-renders it.
+type ImageView struct {
+	Source image.Image
+	// Reduced means Source is smaller than the file could give, like an
+	// embedded preview, so zooming in past fit needs the real thing
+	Reduced bool
+	// Loading is set while the full image is read in the background
+	Loading bool
+	// Zoom is screen pixels per image pixel, 0 meaning fit to the window
+	Zoom float64
+	// CX and CY are the centre of the view as a fraction of the image
+	CX, CY float64
+}
+
+// FullImageMsg delivers the full image behind a reduced preview. Actual
+// and Zoom record that = was pressed and at what zoom, so 1:1 still means
+// 1:1 once the bigger image arrives.
+type FullImageMsg struct {
+	Path   string
+	Image  image.Image
+	Err    error
+	Actual bool
+	Zoom   float64
+}
+
+// NewImageView shows img fitted and centred
+func NewImageView(img image.Image, reduced bool) ImageView {
+	return ImageView{Source: img, Reduced: reduced, CX: 0.5, CY: 0.5}
+}
+
+// FitScale is the zoom that fits the whole image into pw x ph pixels
+func (v ImageView) FitScale(pw, ph int) float64 {
+	b := v.Source.Bounds()
+	return math.Min(float64(pw)/float64(b.Dx()), float64(ph)/float64(b.Dy()))
+}
+
+// Scale is the zoom in effect for a pw x ph pixel pane
+func (v ImageView) Scale(pw, ph int) float64 {
+	if v.Zoom == 0 {
+		return v.FitScale(pw, ph)
+	}
+	return v.Zoom
+}
+
+// ZoomBy multiplies the zoom, going back to fit when it gets there from
+// above
+func (v *ImageView) ZoomBy(steps int, pw, ph int) {
+	fit := v.FitScale(pw, ph)
+	if fit >= maxZoom {
+		return
+	}
+	s := v.Scale(pw, ph) * math.Pow(zoomStep, float64(steps))
+	if s <= fit {
+		v.Zoom = 0
+		return
+	}
+	v.Zoom = math.Min(s, maxZoom)
+}
+
+// Region is the part of the image that's visible in a pw x ph pixel pane,
+// and the scale it's shown at. The centre is pulled in so the view never
+// runs past the image's edges.
+func (v *ImageView) Region(pw, ph int) (image.Rectangle, float64) {
+	b := v.Source.Bounds()
+	s := v.Scale(pw, ph)
+	iw, ih := float64(b.Dx()), float64(b.Dy())
+	vw, vh := math.Min(iw, float64(pw)/s), math.Min(ih, float64(ph)/s)
+
+	v.CX = clampCentre(v.CX, vw/iw)
+	v.CY = clampCentre(v.CY, vh/ih)
+
+	x0 := int(math.Round(v.CX*iw - vw/2))
+	y0 := int(math.Round(v.CY*ih - vh/2))
+	r := image.Rect(x0, y0, x0+max(int(math.Round(vw)), 1), y0+max(int(math.Round(vh)), 1))
+	return r.Add(b.Min).Intersect(b), s
+}
+
+// Pan moves the view by a fraction of what's visible
+func (v *ImageView) Pan(dx, dy float64, pw, ph int) {
+	b := v.Source.Bounds()
+	s := v.Scale(pw, ph)
+	v.CX += dx * float64(pw) / s / float64(b.Dx())
+	v.CY += dy * float64(ph) / s / float64(b.Dy())
+}
+
+// Rescale keeps the view where it is when Source is swapped for a version
+// of the same image at another size
+func (v *ImageView) Rescale(img image.Image) {
+	if v.Zoom != 0 {
+		v.Zoom *= float64(v.Source.Bounds().Dx()) / float64(img.Bounds().Dx())
+	}
+	v.Source = img
+}
+
+// Label describes the zoom for the preview title
+func (v ImageView) Label() string {
+	if v.Zoom == 0 {
+		return ""
+	}
+	if v.Reduced {
+		return fmt.Sprintf("×%.2g", v.Zoom)
+	}
+	return fmt.Sprintf("%.0f%%", v.Zoom*100)
+}
+
+func clampCentre(c, visible float64) float64 {
+	return math.Min(math.Max(c, visible/2), 1-visible/2)
+}
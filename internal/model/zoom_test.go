@@ -0,0 +1,94 @@
+package model
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func view(w, h int) ImageView {
+	return NewImageView(image.NewGray(image.Rect(0, 0, w, h)), false)
+}
+
+func TestRegionFit(t *testing.T) {
+	v := view(400, 200)
+	r, s := v.Region(100, 100)
+	if s != 0.25 {
+		t.Errorf("got scale %v, want 0.25", s)
+	}
+	if r != image.Rect(0, 0, 400, 200) {
+		t.Errorf("got %v, want the whole image", r)
+	}
+}
+
+func TestRegionZoomed(t *testing.T) {
+	tests := []struct {
+		name   string
+		zoom   float64
+		cx, cy float64
+		want   image.Rectangle
+	}{
+		{"centred 1:1", 1, 0.5, 0.5, image.Rect(150, 50, 250, 150)},
+		{"2x", 2, 0.5, 0.5, image.Rect(175, 75, 225, 125)},
+		{"clamped to the top left", 1, 0, 0, image.Rect(0, 0, 100, 100)},
+		{"clamped to the bottom right", 1, 1, 1, image.Rect(300, 100, 400, 200)},
+		{"zoomed out past the height", 0.5, 0.5, 0, image.Rect(100, 0, 300, 200)},
+	}
+	for _, tt := range tests {
+		v := view(400, 200)
+		v.Zoom, v.CX, v.CY = tt.zoom, tt.cx, tt.cy
+		r, s := v.Region(100, 100)
+		if r != tt.want || s != tt.zoom {
+			t.Errorf("%s: got %v at %v, want %v at %v", tt.name, r, s, tt.want, tt.zoom)
+		}
+	}
+}
+
+func TestRegionOffsetBounds(t *testing.T) {
+	v := ImageView{Source: image.NewGray(image.Rect(10, 20, 410, 220)), Zoom: 1, CX: 0, CY: 0}
+	if r, _ := v.Region(100, 100); r != image.Rect(10, 20, 110, 120) {
+		t.Errorf("got %v, want the top left of the image's own bounds", r)
+	}
+}
+
+func TestRegionClampsCentre(t *testing.T) {
+	v := view(400, 200)
+	v.Zoom, v.CX, v.CY = 1, -3, 5
+	v.Region(100, 100)
+	if v.CX != 0.125 || v.CY != 0.75 {
+		t.Errorf("centre pulled in to %v,%v, want 0.125,0.75", v.CX, v.CY)
+	}
+
+	// Panning past the edge and back only has to undo what was visible
+	v.Pan(-1, 0, 100, 100)
+	v.Region(100, 100)
+	v.Pan(1, 0, 100, 100)
+	if r, _ := v.Region(100, 100); r != image.Rect(100, 100, 200, 200) {
+		t.Errorf("after panning back got %v", r)
+	}
+}
+
+func TestZoomBy(t *testing.T) {
+	v := view(400, 200)
+	v.ZoomBy(1, 100, 100)
+	if want := 0.25 * zoomStep; math.Abs(v.Zoom-want) > 1e-9 {
+		t.Errorf("zooming in from fit gave %v, want %v", v.Zoom, want)
+	}
+	v.ZoomBy(-1, 100, 100)
+	if v.Zoom != 0 {
+		t.Errorf("zooming back out gave %v, want fit", v.Zoom)
+	}
+	v.ZoomBy(100, 100, 100)
+	if v.Zoom != maxZoom {
+		t.Errorf("zooming far in gave %v, want %v", v.Zoom, maxZoom)
+	}
+}
+
+func TestRescaleKeepsZoom(t *testing.T) {
+	v := view(100, 50)
+	v.Zoom, v.CX, v.CY = 4, 0.3, 0.6
+	v.Rescale(image.NewGray(image.Rect(0, 0, 400, 200)))
+	if v.Zoom != 1 || v.CX != 0.3 || v.CY != 0.6 {
+		t.Errorf("got zoom %v at %v,%v, want 1 at 0.3,0.6", v.Zoom, v.CX, v.CY)
+	}
+}
@@ -0,0 +1,138 @@
+// Package phash computes perceptual hashes: 64 bit fingerprints that stay
+// close when an image is resized, recompressed or lightly edited, so near
+// duplicates can be found by counting differing bits.
+package phash
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+)
+
+// Hashes holds all three kinds for one image
+type Hashes struct {
+	A, D, P uint64
+}
+
+// Compute hashes img all three ways
+func Compute(img image.Image) Hashes {
+	return Hashes{A: AHash(img), D: DHash(img), P: PHash(img)}
+}
+
+// Get returns the hash of the named kind: "ahash", "dhash" or "phash"
+func (h Hashes) Get(kind string) uint64 {
+	switch kind {
+	case "ahash":
+		return h.A
+	case "dhash":
+		return h.D
+	}
+	return h.P
+}
+
+// Distance is the number of bits two hashes differ in
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// AHash sets a bit for each pixel of an 8x8 thumbnail that's brighter than
+// the average. Fast, but thrown by changes in contrast.
+func AHash(img image.Image) uint64 {
+	px := gray(img, 8, 8)
+	var sum float64
+	for _, v := range px {
+		sum += v
+	}
+	mean := sum / float64(len(px))
+
+	var h uint64
+	for i, v := range px {
+		if v > mean {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// DHash compares each pixel of a 9x8 thumbnail with its right neighbour,
+// which follows gradients rather than absolute brightness
+func DHash(img image.Image) uint64 {
+	px := gray(img, 9, 8)
+	var h uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if px[y*9+x] < px[y*9+x+1] {
+				h |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	return h
+}
+
+const dctSize = 32
+
+// dctCos[u][x] is the DCT-II basis for a 32 point transform
+var dctCos = func() [dctSize][dctSize]float64 {
+	var t [dctSize][dctSize]float64
+	for u := 0; u < dctSize; u++ {
+		for x := 0; x < dctSize; x++ {
+			t[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * dctSize))
+		}
+	}
+	return t
+}()
+
+// PHash takes the lowest 8x8 frequencies of a 32x32 thumbnail's DCT and
+// sets a bit for each above their median. It's the most robust of the
+// three to edits like gamma and cropping borders.
+func PHash(img image.Image) uint64 {
+	px := gray(img, dctSize, dctSize)
+
+	// Rows, then columns, only as far as the frequencies we keep
+	var rows [dctSize][8]float64
+	for y := 0; y < dctSize; y++ {
+		for u := 0; u < 8; u++ {
+			var s float64
+			for x := 0; x < dctSize; x++ {
+				s += px[y*dctSize+x] * dctCos[u][x]
+			}
+			rows[y][u] = s
+		}
+	}
+	var low [64]float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var s float64
+			for y := 0; y < dctSize; y++ {
+				s += rows[y][u] * dctCos[v][y]
+			}
+			low[v*8+u] = s
+		}
+	}
+
+	sorted := low
+	sort.Float64s(sorted[:])
+	median := (sorted[31] + sorted[32]) / 2
+
+	var h uint64
+	for i, v := range low {
+		if v > median {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// gray scales img to w x h and returns its luminance, row by row
+func gray(img image.Image, w, h int) []float64 {
+	small := imaging.Resize(img, w, h)
+	out := make([]float64, w*h)
+	for i := range out {
+		p := small.Pix[i*4 : i*4+4]
+		out[i] = 0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package phash
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xF0, 0x0F, 8},
+		{0, ^uint64(0), 64},
+		{0xAAAAAAAAAAAAAAAA, 0x5555555555555555, 64},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%#x, %#x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// scene draws the same picture at any size, so it can be hashed as if it
+// had been resized. gain and offset change its contrast and brightness.
+func scene(w, h int, pattern func(x, y float64) float64, gain, offset float64) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := pattern((float64(x)+0.5)/float64(w), (float64(y)+0.5)/float64(h))
+			img.SetGray(x, y, color.Gray{uint8(math.Max(0, math.Min(255, v*gain+offset)))})
+		}
+	}
+	return img
+}
+
+func blobs(x, y float64) float64 {
+	return 128 + 100*math.Sin(5*x)*math.Cos(3*y+1) + 20*math.Sin(11*x*y)
+}
+
+func stripes(x, y float64) float64 {
+	return 128 + 120*math.Sin(17*y-9*x)
+}
+
+func TestHashesSurviveResizing(t *testing.T) {
+	original := Compute(scene(640, 480, blobs, 1, 0))
+	resized := Compute(scene(200, 150, blobs, 1, 0))
+	brighter := Compute(scene(640, 480, blobs, 0.9, 20))
+	other := Compute(scene(640, 480, stripes, 1, 0))
+
+	for _, kind := range []string{"ahash", "dhash", "phash"} {
+		a := original.Get(kind)
+		if d := Distance(a, resized.Get(kind)); d > 4 {
+			t.Errorf("%s: resized copy is %d bits away", kind, d)
+		}
+		if d := Distance(a, brighter.Get(kind)); d > 6 {
+			t.Errorf("%s: brightened copy is %d bits away", kind, d)
+		}
+		if d := Distance(a, other.Get(kind)); d < 16 {
+			t.Errorf("%s: a different image is only %d bits away", kind, d)
+		}
+	}
+}
+
+func TestGetDefaultsToPHash(t *testing.T) {
+	h := Hashes{A: 1, D: 2, P: 3}
+	for kind, want := range map[string]uint64{"ahash": 1, "dhash": 2, "phash": 3, "": 3} {
+		if got := h.Get(kind); got != want {
+			t.Errorf("Get(%q) = %d, want %d", kind, got, want)
+		}
+	}
+}
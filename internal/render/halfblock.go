@@ -0,0 +1,154 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+)
+
+// CellAspect is the height/width ratio of a terminal cell. Most fonts are
+// close to 2:1, which makes each half of a half-block cell roughly square.
+const CellAspect = 2.0
+
+// HalfBlock draws two pixels per cell with "▀": the top pixel is the
+// foreground color and the bottom pixel the background color.
+type HalfBlock struct {
+	Profile termenv.Profile
+}
+
+func NewHalfBlock(profile termenv.Profile) HalfBlock {
+	return HalfBlock{Profile: profile}
+}
+
+// PixelSize returns the pixel grid an image of w x h is scaled to so that it
+// fits in cols x rows cells with its aspect ratio intact.
+func (h HalfBlock) PixelSize(w, hgt, cols, rows int) (int, int) {
+	return imaging.Fit(w, hgt, cols, rows*2, CellAspect/2)
+}
+
+func (h HalfBlock) Name() string {
+	return "halfblock"
+}
+
+// Supported is always true: without colors it falls back to shading
+func (h HalfBlock) Supported(caps Capabilities) bool {
+	return true
+}
+
+func (h HalfBlock) Render(img image.Image, cols, rows int) (Image, error) {
+	b := img.Bounds()
+	pw, ph := h.PixelSize(b.Dx(), b.Dy(), cols, rows)
+	if pw == 0 || ph == 0 {
+		return Image{}, nil
+	}
+	return Image{Text: h.RenderPixels(imaging.Resize(img, pw, ph))}, nil
+}
+
+// RenderPixels renders img without scaling, one pixel per half cell
+func (h HalfBlock) RenderPixels(img *image.RGBA) string {
+	if h.Profile == termenv.Ascii {
+		return renderShades(img)
+	}
+
+	conv := newColorCache(h.Profile)
+	b := img.Bounds()
+
+	var sb strings.Builder
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		last := ""
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top := img.RGBAAt(x, y)
+			bottom := color.RGBA{}
+			if y+1 < b.Max.Y {
+				bottom = img.RGBAAt(x, y+1)
+			}
+
+			var sgr, glyph string
+			topOpaque, bottomOpaque := top.A >= 128, bottom.A >= 128
+			switch {
+			case topOpaque && bottomOpaque:
+				sgr = conv.sequence(top, false) + ";" + conv.sequence(bottom, true)
+				glyph = "▀"
+			case topOpaque:
+				sgr = conv.sequence(top, false)
+				glyph = "▀"
+			case bottomOpaque:
+				sgr = conv.sequence(bottom, false)
+				glyph = "▄"
+			default:
+				glyph = " "
+			}
+
+			// Only emit escapes when the colors actually change
+			if sgr != last {
+				sb.WriteString("\x1b[0m")
+				if sgr != "" {
+					sb.WriteString("\x1b[" + sgr + "m")
+				}
+				last = sgr
+			}
+			sb.WriteString(glyph)
+		}
+		sb.WriteString("\x1b[0m\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// colorCache memoizes profile conversion, which is expensive for the 256 and
+// 16 color profiles since it searches for the nearest palette entry.
+type colorCache struct {
+	profile termenv.Profile
+	fg, bg  map[uint32]string
+}
+
+func newColorCache(profile termenv.Profile) *colorCache {
+	return &colorCache{
+		profile: profile,
+		fg:      make(map[uint32]string),
+		bg:      make(map[uint32]string),
+	}
+}
+
+func (c *colorCache) sequence(px color.RGBA, bg bool) string {
+	key := uint32(px.R)<<16 | uint32(px.G)<<8 | uint32(px.B)
+	cache := c.fg
+	if bg {
+		cache = c.bg
+	}
+	if seq, ok := cache[key]; ok {
+		return seq
+	}
+
+	hex := fmt.Sprintf("#%02x%02x%02x", px.R, px.G, px.B)
+	seq := c.profile.Color(hex).Sequence(bg)
+	cache[key] = seq
+	return seq
+}
+
+// renderShades is the monochrome fallback, approximating brightness with
+// block shading characters
+func renderShades(img *image.RGBA) string {
+	shades := []rune(" ░▒▓█")
+	b := img.Bounds()
+
+	var sb strings.Builder
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			lum := luminance(img.RGBAAt(x, y))
+			if y+1 < b.Max.Y {
+				lum = (lum + luminance(img.RGBAAt(x, y+1))) / 2
+			}
+			sb.WriteRune(shades[int(lum*float64(len(shades)-1)+0.5)])
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func luminance(c color.RGBA) float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) * float64(c.A) / (255 * 255)
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+)
+
+// ITerm2 uses the OSC 1337 inline image protocol understood by iTerm2 and
+// WezTerm. The terminal does the scaling; we only tell it how many cells the
+// image may occupy.
+type ITerm2 struct{}
+
+func NewITerm2() ITerm2 {
+	return ITerm2{}
+}
+
+func (i ITerm2) Name() string {
+	return "iterm2"
+}
+
+func (i ITerm2) Supported(caps Capabilities) bool {
+	return caps.ITerm2
+}
+
+func (i ITerm2) Render(img image.Image, cols, rows int) (Image, error) {
+	b := img.Bounds()
+	c, r := imaging.Fit(b.Dx(), b.Dy(), cols, rows, CellAspect)
+	if c == 0 || r == 0 {
+		return Image{}, nil
+	}
+
+	if b.Dx() > c*CellPixelWidth || b.Dy() > r*CellPixelHeight {
+		pw, ph := imaging.Fit(b.Dx(), b.Dy(), c*CellPixelWidth, r*CellPixelHeight, 1)
+		img = imaging.Resize(img, pw, ph)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		Text:    blankCells(c, r),
+		Setup:   ITerm2Inline(buf.Bytes(), c, r),
+		Overlay: true,
+	}, nil
+}
+
+// ITerm2Inline returns the OSC 1337 File= sequence that displays data inline
+// in a box of cols x rows cells
+func ITerm2Inline(data []byte, cols, rows int) string {
+	return fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1:%s\a",
+		len(data), cols, rows, base64.StdEncoding.EncodeToString(data))
+}
@@ -0,0 +1,119 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKittyTransmit(t *testing.T) {
+	full := strings.Repeat("A", 4096)
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "empty",
+			data: nil,
+			want: "\x1b_Ga=T,U=1,f=100,t=d,i=7,c=3,r=2,q=2,m=0;\x1b\\",
+		},
+		{
+			name: "one chunk",
+			data: []byte("png"),
+			want: "\x1b_Ga=T,U=1,f=100,t=d,i=7,c=3,r=2,q=2,m=0;cG5n\x1b\\",
+		},
+		{
+			name: "exactly one full chunk",
+			data: make([]byte, 3072),
+			want: "\x1b_Ga=T,U=1,f=100,t=d,i=7,c=3,r=2,q=2,m=0;" + full + "\x1b\\",
+		},
+		{
+			name: "spills into a second chunk",
+			data: make([]byte, 3073),
+			want: "\x1b_Ga=T,U=1,f=100,t=d,i=7,c=3,r=2,q=2,m=1;" + full + "\x1b\\" +
+				"\x1b_Gm=0,q=2;AA==\x1b\\",
+		},
+		{
+			name: "three chunks",
+			data: make([]byte, 3072*2+1),
+			want: "\x1b_Ga=T,U=1,f=100,t=d,i=7,c=3,r=2,q=2,m=1;" + full + "\x1b\\" +
+				"\x1b_Gm=1,q=2;" + full + "\x1b\\" +
+				"\x1b_Gm=0,q=2;AA==\x1b\\",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KittyTransmit(7, tt.data, 3, 2); got != tt.want {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKittyPlaceholders(t *testing.T) {
+	const cell = "\U0010EEEE"
+	tests := []struct {
+		name       string
+		id         uint32
+		cols, rows int
+		want       string
+	}{
+		{
+			name: "one cell",
+			id:   1,
+			cols: 1, rows: 1,
+			want: "\x1b[38;2;0;0;1m" + cell + "\u0305\u0305" + "\x1b[39m",
+		},
+		{
+			name: "rows and columns",
+			id:   0x010203,
+			cols: 3, rows: 2,
+			want: "\x1b[38;2;1;2;3m" +
+				cell + "\u0305\u0305" + cell + "\u0305\u030d" + cell + "\u0305\u030e" + "\x1b[39m\n" +
+				"\x1b[38;2;1;2;3m" +
+				cell + "\u030d\u0305" + cell + "\u030d\u030d" + cell + "\u030d\u030e" + "\x1b[39m",
+		},
+		{
+			name: "largest id",
+			id:   0xFFFFFF,
+			cols: 1, rows: 1,
+			want: "\x1b[38;2;255;255;255m" + cell + "\u0305\u0305" + "\x1b[39m",
+		},
+		{
+			name: "nothing",
+			id:   1,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KittyPlaceholders(tt.id, tt.cols, tt.rows); got != tt.want {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKittyDelete(t *testing.T) {
+	tests := []struct {
+		id   uint32
+		want string
+	}{
+		{1, "\x1b_Ga=d,d=I,i=1,q=2\x1b\\"},
+		{0xFFFFFF, "\x1b_Ga=d,d=I,i=16777215,q=2\x1b\\"},
+	}
+	for _, tt := range tests {
+		if got := KittyDelete(tt.id); got != tt.want {
+			t.Errorf("KittyDelete(%d) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestKittyIDFitsInAColor(t *testing.T) {
+	kittyNextID.Store(0xFFFFFF)
+	for i := 0; i < 3; i++ {
+		if id := kittyID(); id == 0 || id > 0xFFFFFF {
+			t.Errorf("got id %#x", id)
+		}
+	}
+}
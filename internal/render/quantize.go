@@ -0,0 +1,171 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// maxQuantizeSamples caps how many pixels feed the median cut; the palette
+// barely changes past this and big photos would otherwise be slow
+const maxQuantizeSamples = 16 * 1024
+
+// MedianCut builds a palette of up to n colors from img by repeatedly
+// splitting the box with the widest channel range at its median.
+func MedianCut(img *image.RGBA, n int) color.Palette {
+	b := img.Bounds()
+	total := b.Dx() * b.Dy()
+	if total == 0 || n <= 0 {
+		return color.Palette{color.Black}
+	}
+
+	step := 1
+	if total > maxQuantizeSamples {
+		step = total / maxQuantizeSamples
+	}
+
+	pixels := make([][3]uint8, 0, total/step+1)
+	for i := 0; i < total; i += step {
+		off := img.PixOffset(b.Min.X+i%b.Dx(), b.Min.Y+i/b.Dx())
+		pixels = append(pixels, [3]uint8{img.Pix[off], img.Pix[off+1], img.Pix[off+2]})
+	}
+
+	boxes := [][][3]uint8{pixels}
+	for len(boxes) < n {
+		// Pick the box with the largest spread that can still be split
+		best, bestRange, bestChannel := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			channel, spread := widestChannel(box)
+			if spread > bestRange {
+				best, bestRange, bestChannel = i, spread, channel
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		box := boxes[best]
+		sort.Slice(box, func(i, j int) bool { return box[i][bestChannel] < box[j][bestChannel] })
+		mid := len(box) / 2
+		boxes[best] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var r, g, bl int
+		for _, p := range box {
+			r += int(p[0])
+			g += int(p[1])
+			bl += int(p[2])
+		}
+		count := len(box)
+		palette = append(palette, color.RGBA{uint8(r / count), uint8(g / count), uint8(bl / count), 0xff})
+	}
+	return palette
+}
+
+func widestChannel(box [][3]uint8) (int, int) {
+	lo := [3]uint8{255, 255, 255}
+	hi := [3]uint8{}
+	for _, p := range box {
+		for c := 0; c < 3; c++ {
+			lo[c] = min(lo[c], p[c])
+			hi[c] = max(hi[c], p[c])
+		}
+	}
+
+	channel, spread := 0, 0
+	for c := 0; c < 3; c++ {
+		if r := int(hi[c]) - int(lo[c]); r > spread {
+			channel, spread = c, r
+		}
+	}
+	return channel, spread
+}
+
+// Dither maps img onto palette with Floyd-Steinberg error diffusion. Nearest
+// color lookups are cached on a 15-bit color cube, which is far quicker than
+// color.Palette.Index for 256 entry palettes.
+func Dither(img *image.RGBA, palette color.Palette, diffuse bool) *image.Paletted {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+	lookup := newNearestCache(palette)
+
+	// Error carried to the current and next row, per channel
+	cur := make([][3]int32, w+2)
+	next := make([][3]int32, w+2)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			off := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+			var px [3]int32
+			for c := 0; c < 3; c++ {
+				v := int32(img.Pix[off+c])
+				if diffuse {
+					v += cur[x+1][c] / 16
+				}
+				px[c] = min(max(v, 0), 255)
+			}
+
+			idx := lookup.index(px)
+			dst.Pix[dst.PixOffset(x, y)] = idx
+			if !diffuse {
+				continue
+			}
+
+			chosen := lookup.rgb[idx]
+			for c := 0; c < 3; c++ {
+				e := px[c] - chosen[c]
+				cur[x+2][c] += e * 7
+				next[x][c] += e * 3
+				next[x+1][c] += e * 5
+				next[x+2][c] += e
+			}
+		}
+		cur, next = next, cur
+		clear(next)
+	}
+	return dst
+}
+
+type nearestCache struct {
+	rgb   [][3]int32
+	cache []int16 // 32*32*32 cube, -1 when not yet resolved
+}
+
+func newNearestCache(palette color.Palette) *nearestCache {
+	n := &nearestCache{
+		rgb:   make([][3]int32, len(palette)),
+		cache: make([]int16, 1<<15),
+	}
+	for i, c := range palette {
+		r, g, b, _ := c.RGBA()
+		n.rgb[i] = [3]int32{int32(r >> 8), int32(g >> 8), int32(b >> 8)}
+	}
+	for i := range n.cache {
+		n.cache[i] = -1
+	}
+	return n
+}
+
+func (n *nearestCache) index(px [3]int32) uint8 {
+	key := px[0]>>3<<10 | px[1]>>3<<5 | px[2]>>3
+	if idx := n.cache[key]; idx >= 0 {
+		return uint8(idx)
+	}
+
+	best, bestDist := 0, int32(1<<30)
+	for i, c := range n.rgb {
+		dr, dg, db := px[0]-c[0], px[1]-c[1], px[2]-c[2]
+		if d := dr*dr + dg*dg + db*db; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	n.cache[key] = int16(best)
+	return uint8(best)
+}
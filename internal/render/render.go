@@ -0,0 +1,175 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// Assumed pixel size of a terminal cell when the terminal doesn't report one
+var (
+	CellPixelWidth  = 10
+	CellPixelHeight = 20
+)
+
+// Image is an image prepared for display. Text is what goes into the view;
+// backends that draw through terminal graphics also need Setup written to
+// the terminal before the view shows Text, and Teardown once it's gone.
+// Overlay images draw at the cursor, so Setup has to be written at the
+// screen position of Text, after the view has been painted.
+type Image struct {
+	Text     string
+	Setup    string
+	Teardown string
+	Overlay  bool
+}
+
+// Capabilities describes what the terminal can display
+type Capabilities struct {
+	Profile termenv.Profile
+	Kitty   bool
+	Sixel   bool
+	ITerm2  bool
+	// SixelColors and SixelMaxSize are the color registers and largest
+	// image in pixels the terminal reported for sixel, zero when unknown
+	SixelColors  int
+	SixelMaxSize image.Point
+}
+
+// Assume marks backend name as supported, for when the user picked it
+// explicitly and we take their word for it
+func (c *Capabilities) Assume(name string) {
+	switch name {
+	case "kitty":
+		c.Kitty = true
+	case "sixel":
+		c.Sixel = true
+	case "iterm2":
+		c.ITerm2 = true
+	}
+}
+
+type Renderer interface {
+	Name() string
+	// Supported reports whether the terminal described by caps can show
+	// this renderer's output
+	Supported(caps Capabilities) bool
+	Render(img image.Image, cols, rows int) (Image, error)
+}
+
+// Backends lists the renderers by config name, best first
+var Backends = []string{"kitty", "sixel", "iterm2", "halfblock"}
+
+// ForName returns the renderer configured by name, defaulting to half
+// blocks, set up for what caps says the terminal can take
+func ForName(name string, caps Capabilities) Renderer {
+	switch name {
+	case "kitty":
+		return NewKitty()
+	case "sixel":
+		s := NewSixel()
+		if caps.SixelColors > 0 {
+			s.Colors = caps.SixelColors
+		}
+		s.MaxSize = caps.SixelMaxSize
+		return s
+	case "iterm2":
+		return NewITerm2()
+	}
+	return NewHalfBlock(caps.Profile)
+}
+
+// Chain tries its renderers in order, skipping the ones the terminal doesn't
+// support and falling through to the next one when rendering fails. Half
+// blocks work everywhere, so they make a good last link.
+type Chain struct {
+	Caps      Capabilities
+	Renderers []Renderer
+}
+
+// NewChain builds a chain from backend names. "auto" expands to every
+// backend, best first.
+func NewChain(caps Capabilities, names ...string) Chain {
+	chain := Chain{Caps: caps}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		expanded := []string{name}
+		if name == "auto" || name == "" {
+			expanded = Backends
+		}
+		for _, n := range expanded {
+			if !seen[n] {
+				seen[n] = true
+				chain.Renderers = append(chain.Renderers, ForName(n, caps))
+			}
+		}
+	}
+	if !seen["halfblock"] {
+		chain.Renderers = append(chain.Renderers, NewHalfBlock(caps.Profile))
+	}
+	return chain
+}
+
+// Name reports the renderer that will be tried first
+func (c Chain) Name() string {
+	if r := c.first(); r != nil {
+		return r.Name()
+	}
+	return "none"
+}
+
+// PixelBudget is roughly how many pixels r can show in cols x rows cells,
+// so callers know how much image is worth decoding
+func PixelBudget(r Renderer, cols, rows int) (int, int) {
+	if r.Name() == "halfblock" {
+		return cols, rows * 2
+	}
+	return cols * CellPixelWidth, rows * CellPixelHeight
+}
+
+// CellsFor is PixelBudget the other way round: how many cells r needs to
+// show pw x ph pixels without scaling them
+func CellsFor(r Renderer, pw, ph int) (int, int) {
+	if r.Name() == "halfblock" {
+		return pw, (ph + 1) / 2
+	}
+	return (pw + CellPixelWidth - 1) / CellPixelWidth, (ph + CellPixelHeight - 1) / CellPixelHeight
+}
+
+func (c Chain) Supported(caps Capabilities) bool {
+	for _, r := range c.Renderers {
+		if r.Supported(caps) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Chain) first() Renderer {
+	for _, r := range c.Renderers {
+		if r.Supported(c.Caps) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (c Chain) Render(img image.Image, cols, rows int) (Image, error) {
+	var errs []string
+	for _, r := range c.Renderers {
+		if !r.Supported(c.Caps) {
+			continue
+		}
+		out, err := r.Render(img, cols, rows)
+		if err == nil {
+			return out, nil
+		}
+		errs = append(errs, r.Name()+": "+err.Error())
+	}
+	if len(errs) == 0 {
+		return Image{}, errors.New("no supported image renderer")
+	}
+	return Image{}, errors.New(strings.Join(errs, "; "))
+}
@@ -0,0 +1,149 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+)
+
+// Sixel encodes images as DEC sixel graphics. Sixel output is drawn at the
+// cursor, so the image is positioned over a blank area reserved in the view
+// rather than living inside the text.
+type Sixel struct {
+	Colors int
+	Dither bool
+	// MaxSize is the largest image the terminal will draw, in pixels.
+	// Zero means no limit is known.
+	MaxSize image.Point
+}
+
+func NewSixel() Sixel {
+	return Sixel{Colors: 256, Dither: true}
+}
+
+func (s Sixel) Name() string {
+	return "sixel"
+}
+
+func (s Sixel) Supported(caps Capabilities) bool {
+	return caps.Sixel
+}
+
+func (s Sixel) Render(img image.Image, cols, rows int) (Image, error) {
+	b := img.Bounds()
+	c, r := imaging.Fit(b.Dx(), b.Dy(), cols, rows, CellAspect)
+	if c == 0 || r == 0 {
+		return Image{}, nil
+	}
+
+	// Scale to the pixel size of the cells we reserve, keeping the aspect
+	mw, mh := c*CellPixelWidth, r*CellPixelHeight
+	if s.MaxSize.X > 0 && s.MaxSize.Y > 0 {
+		mw, mh = min(mw, s.MaxSize.X), min(mh, s.MaxSize.Y)
+	}
+	pw, ph := imaging.Fit(b.Dx(), b.Dy(), mw, mh, 1)
+	scaled := imaging.Resize(img, pw, ph)
+
+	return Image{
+		Text:    blankCells(c, r),
+		Setup:   s.Encode(scaled),
+		Overlay: true,
+	}, nil
+}
+
+// Encode returns the complete DCS sixel sequence for img
+func (s Sixel) Encode(img *image.RGBA) string {
+	// Terminals with more registers still get 256, the most the palette
+	// format here can index
+	colors := s.Colors
+	if colors <= 0 || colors > 256 {
+		colors = 256
+	}
+	colors = max(colors, 2)
+
+	palette := MedianCut(img, colors)
+	return EncodeSixel(Dither(img, palette, s.Dither))
+}
+
+// EncodeSixel writes an already quantized image as sixel data. Each band
+// covers six pixel rows; within a band every color used is drawn as its own
+// pass, returning to the band start with "$" between passes.
+func EncodeSixel(img *image.Paletted) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	var sb strings.Builder
+	// P2=1 leaves pixels that aren't drawn transparent
+	sb.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&sb, "\"1;1;%d;%d", w, h)
+
+	for i, c := range img.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	// One row of sixel bits per palette entry, reused across bands
+	planes := make([][]byte, len(img.Palette))
+	for band := 0; band < h; band += 6 {
+		var order []uint8
+		var seen [256]bool
+		for y := band; y < band+6 && y < h; y++ {
+			row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+			for x := 0; x < w; x++ {
+				idx := row[x]
+				if planes[idx] == nil {
+					planes[idx] = make([]byte, w)
+				}
+				if !seen[idx] {
+					seen[idx] = true
+					clear(planes[idx])
+					order = append(order, idx)
+				}
+				planes[idx][x] |= 1 << (y - band)
+			}
+		}
+
+		for n, idx := range order {
+			fmt.Fprintf(&sb, "#%d", idx)
+			writeSixelRuns(&sb, planes[idx])
+			if n < len(order)-1 {
+				sb.WriteByte('$')
+			}
+		}
+		sb.WriteByte('-')
+	}
+
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+// writeSixelRuns emits sixel characters, compressing repeats with "!count"
+func writeSixelRuns(sb *strings.Builder, bits []byte) {
+	for i := 0; i < len(bits); {
+		j := i + 1
+		for j < len(bits) && bits[j] == bits[i] {
+			j++
+		}
+
+		ch := byte('?' + bits[i])
+		if run := j - i; run > 3 {
+			fmt.Fprintf(sb, "!%d%c", run, ch)
+		} else {
+			for k := 0; k < run; k++ {
+				sb.WriteByte(ch)
+			}
+		}
+		i = j
+	}
+}
+
+func blankCells(cols, rows int) string {
+	line := strings.Repeat(" ", cols)
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,36 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSixelUsesProbedLimits(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+
+	caps := Capabilities{Sixel: true, SixelColors: 16, SixelMaxSize: image.Pt(120, 300)}
+	out, err := ForName("sixel", caps).Render(img, 40, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := regexp.MustCompile(`"1;1;(\d+);(\d+)`).FindStringSubmatch(out.Setup)
+	if m == nil {
+		t.Fatalf("no raster attributes in %q", out.Setup[:20])
+	}
+	if w, _ := strconv.Atoi(m[1]); w > 120 {
+		t.Errorf("image is %s pixels wide, the terminal takes 120", m[1])
+	}
+	if registers := strings.Count(out.Setup, ";2;"); registers > 16 {
+		t.Errorf("defined %d color registers, the terminal has 16", registers)
+	}
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Terminal is the one writer everything on screen goes through. bubbletea
+// renders into it (pass it to tea.WithOutput) and Emit writes graphics
+// into it, so a frame can never land halfway through a megabyte of image
+// data. Overlay images are kept and drawn again after every write the
+// renderer makes, since that write may have painted over them.
+type Terminal struct {
+	file     *os.File
+	mu       sync.Mutex
+	overlays map[image.Point]string
+}
+
+// Output is the terminal bubbletea and the graphics share
+var Output = NewTerminal(os.Stdout)
+
+func NewTerminal(file *os.File) *Terminal {
+	return &Terminal{file: file, overlays: make(map[image.Point]string)}
+}
+
+// Read, Close and Fd let bubbletea treat the Terminal as the tty it wraps
+func (t *Terminal) Read(p []byte) (int, error) { return t.file.Read(p) }
+func (t *Terminal) Close() error               { return t.file.Close() }
+func (t *Terminal) Fd() uintptr                { return t.file.Fd() }
+
+// Write is where bubbletea's frames come in
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n, err := t.file.Write(p)
+	if err != nil {
+		return n, err
+	}
+	for at, seq := range t.overlays {
+		t.drawAt(seq, at)
+	}
+	return n, nil
+}
+
+// WriteString keeps io.WriteString from going around Write
+func (t *Terminal) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// Emit writes seq between frames
+func (t *Terminal) Emit(seq string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.file, seq)
+}
+
+// SetOverlay draws seq with its top-left corner at cell x, y (zero based)
+// and keeps it there, replacing what was at that spot
+func (t *Terminal) SetOverlay(seq string, x, y int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at := image.Pt(x, y)
+	t.overlays[at] = seq
+	t.drawAt(seq, at)
+}
+
+// ClearOverlays stops drawing overlays. What's already on screen stays
+// until the text under it is repainted.
+func (t *Terminal) ClearOverlays() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clear(t.overlays)
+}
+
+func (t *Terminal) drawAt(seq string, at image.Point) {
+	fmt.Fprintf(t.file, "\x1b7\x1b[%d;%dH%s\x1b8", at.Y+1, at.X+1, seq)
+}
+
+// Emit writes seq to the terminal between frames. Graphics sequences don't
+// move the cursor, so this doesn't upset the renderer's idea of the screen.
+func Emit(seq string) tea.Cmd {
+	if seq == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		Output.Emit(seq)
+		return nil
+	}
+}
+
+// EmitAt draws an overlay image with its top-left corner at cell x, y (zero
+// based). It's drawn again after every frame until ClearOverlays, so it
+// ends up on top of the blank cells the view leaves for it whenever those
+// get painted.
+func EmitAt(seq string, x, y int) tea.Cmd {
+	if seq == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		Output.SetOverlay(seq, x, y)
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package render
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTerminalRedrawsOverlaysAfterFrames(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "tty"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	term := NewTerminal(f)
+
+	term.SetOverlay("IMG", 2, 1)
+	io.WriteString(term, "frame1")
+	term.Emit("SETUP")
+	term.ClearOverlays()
+	io.WriteString(term, "frame2")
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlay := "\x1b7\x1b[2;3HIMG\x1b8"
+	want := overlay + "frame1" + overlay + "SETUP" + "frame2"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,114 @@
+package sidecar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+)
+
+// Filter narrows a file list down by tags. The zero Filter lets everything
+// through.
+type Filter struct {
+	MinRating int
+	Label     string
+	Flag      defs.Flag // only files with this flag
+	NoRejects bool
+	Keyword   string
+}
+
+// ParseFilter reads a filter written as space separated terms: "4" or
+// "4+" for a minimum rating, a color label, "pick", "reject", "-reject" to
+// hide rejects, and anything else as a keyword, optionally with a leading #
+func ParseFilter(s string) (Filter, error) {
+	var f Filter
+	for _, term := range strings.Fields(s) {
+		lower := strings.ToLower(term)
+		if n, err := strconv.Atoi(strings.TrimSuffix(lower, "+")); err == nil {
+			if n < 0 || n > 5 {
+				return Filter{}, fmt.Errorf("rating %d isn't 0 to 5", n)
+			}
+			f.MinRating = n
+			continue
+		}
+		if label, ok := labelNamed(lower); ok {
+			f.Label = label
+			continue
+		}
+		switch lower {
+		case "pick", "picks", "picked":
+			f.Flag = defs.FlagPick
+		case "reject", "rejects", "rejected":
+			f.Flag = defs.FlagReject
+		case "-reject", "-rejects", "!reject", "!rejects":
+			f.NoRejects = true
+		default:
+			f.Keyword = strings.TrimPrefix(term, "#")
+		}
+	}
+	return f, nil
+}
+
+// Active reports whether the filter hides anything
+func (f Filter) Active() bool {
+	return f != Filter{}
+}
+
+// Match reports whether a file with tags gets through
+func (f Filter) Match(tags defs.Tags) bool {
+	if tags.Rating < f.MinRating {
+		return false
+	}
+	if f.Label != "" && !strings.EqualFold(tags.Label, f.Label) {
+		return false
+	}
+	if f.Flag != defs.FlagNone && tags.Flag != f.Flag {
+		return false
+	}
+	if f.NoRejects && tags.Flag == defs.FlagReject {
+		return false
+	}
+	if f.Keyword != "" {
+		for _, k := range tags.Keywords {
+			if strings.EqualFold(k, f.Keyword) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// String writes the filter back in the form ParseFilter reads
+func (f Filter) String() string {
+	var terms []string
+	if f.MinRating > 0 {
+		terms = append(terms, strconv.Itoa(f.MinRating)+"+")
+	}
+	if f.Label != "" {
+		terms = append(terms, strings.ToLower(f.Label))
+	}
+	switch f.Flag {
+	case defs.FlagPick:
+		terms = append(terms, "pick")
+	case defs.FlagReject:
+		terms = append(terms, "reject")
+	}
+	if f.NoRejects {
+		terms = append(terms, "-reject")
+	}
+	if f.Keyword != "" {
+		terms = append(terms, "#"+f.Keyword)
+	}
+	return strings.Join(terms, " ")
+}
+
+func labelNamed(name string) (string, bool) {
+	for _, label := range Labels {
+		if strings.EqualFold(label, name) {
+			return label, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+// Package sidecar keeps ratings, color labels, pick/reject flags and
+// keywords in .xmp files next to images, where Lightroom, darktable and
+// friends look for them.
+package sidecar
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/xmp"
+)
+
+// Labels are the color labels Lightroom and darktable agree on, in the
+// order number keys 6 and up pick them
+var Labels = []string{"Red", "Yellow", "Green", "Blue", "Purple"}
+
+// Names are the sidecar file names image could have: darktable's
+// IMG_1234.CR2.xmp first, then Adobe's IMG_1234.xmp
+func Names(image string) []string {
+	base := filepath.Base(image)
+	return []string{base + ".xmp", strings.TrimSuffix(base, filepath.Ext(base)) + ".xmp"}
+}
+
+// Path is the sidecar for image: whichever of Names exists, or the Adobe
+// one for a new sidecar. A raw and its JPEG share that one, the same as in
+// Lightroom.
+func Path(image string) string {
+	dir := filepath.Dir(image)
+	names := Names(image)
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return filepath.Join(dir, names[1])
+}
+
+// ReadProperties parses the sidecar of image. A missing sidecar is
+// fs.ErrNotExist.
+func ReadProperties(image string) (xmp.Properties, error) {
+	data, err := os.ReadFile(Path(image))
+	if err != nil {
+		return nil, err
+	}
+	return xmp.Parse(data)
+}
+
+// Read returns the tags in image's sidecar, which are empty when there's
+// no sidecar yet
+func Read(image string) (defs.Tags, error) {
+	props, err := ReadProperties(image)
+	if errors.Is(err, fs.ErrNotExist) {
+		return defs.Tags{}, nil
+	}
+	if err != nil {
+		return defs.Tags{}, err
+	}
+	return FromXMP(props), nil
+}
+
+// FromXMP picks the tags out of XMP properties. A rating of -1 is how
+// Bridge and darktable mark a reject, so the stars it replaced are kept in
+// photoboard:Rating for when it's taken back. Picks are Bridge's "good"
+// flag.
+func FromXMP(props xmp.Properties) defs.Tags {
+	tags := defs.Tags{
+		Label:    props.Get("xmp:Label"),
+		Keywords: props["dc:subject"],
+	}
+	rating, _ := strconv.Atoi(props.Get("xmp:Rating"))
+	switch {
+	case rating < 0:
+		tags.Flag = defs.FlagReject
+		rating, _ = strconv.Atoi(props.Get("photoboard:Rating"))
+	case strings.EqualFold(props.Get("xmpDM:good"), "true"):
+		tags.Flag = defs.FlagPick
+	}
+	tags.Rating = min(max(rating, 0), 5)
+	return tags
+}
+
+// Write stores tags in image's sidecar, creating it if need be. Anything
+// else in an existing sidecar, like another editor's develop settings, is
+// kept.
+func Write(image string, tags defs.Tags) error {
+	path := Path(image)
+	mode := fs.FileMode(0o644)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		data = xmp.NewPacket()
+	} else if err != nil {
+		return err
+	}
+
+	var rating, stars, label, good []string
+	if tags.Rating > 0 {
+		rating = []string{strconv.Itoa(tags.Rating)}
+	}
+	if tags.Flag == defs.FlagReject {
+		rating, stars = []string{"-1"}, rating
+	}
+	if tags.Label != "" {
+		label = []string{tags.Label}
+	}
+	if tags.Flag == defs.FlagPick {
+		good = []string{"True"}
+	}
+
+	data, err = xmp.Update(data,
+		xmp.Property{Key: "xmp:Rating", Values: rating},
+		xmp.Property{Key: "photoboard:Rating", Values: stars},
+		xmp.Property{Key: "xmp:Label", Values: label},
+		xmp.Property{Key: "xmpDM:good", Values: good},
+		xmp.Property{Key: "dc:subject", Values: tags.Keywords, Bag: true},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Write next to it and rename, so a crash can't leave half a sidecar
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".photoboard-*.xmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,81 @@
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nooooaaaaah/photoboard/internal/defs"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "IMG_0001.jpg")
+	tests := []defs.Tags{
+		{Rating: 3, Label: "Red", Keywords: []string{"beach", "sunset"}},
+		{Rating: 5, Flag: defs.FlagPick},
+		{Rating: 4, Flag: defs.FlagReject},
+		{Flag: defs.FlagReject},
+		{},
+	}
+	for _, want := range tests {
+		if err := Write(image, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Read(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("wrote %+v, read back %+v", want, got)
+		}
+	}
+}
+
+func TestRejectKeepsStars(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "IMG_0001.jpg")
+	if err := Write(image, defs.Tags{Rating: 4, Flag: defs.FlagReject}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(Path(image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Other tools still see a reject
+	if !strings.Contains(string(data), `xmp:Rating="-1"`) && !strings.Contains(string(data), "<xmp:Rating>-1</xmp:Rating>") {
+		t.Errorf("reject isn't stored as rating -1:\n%s", data)
+	}
+
+	tags, err := Read(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags.Flag = defs.FlagNone
+	if err := Write(image, tags); err != nil {
+		t.Fatal(err)
+	}
+	if tags, _ := Read(image); tags.Rating != 4 || tags.Flag != defs.FlagNone {
+		t.Errorf("after taking the reject back got %+v, want 4 stars", tags)
+	}
+}
+
+func TestReadBridgeReject(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "IMG_0001.jpg")
+	sidecar := `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:Rating="-1"/>
+ </rdf:RDF>
+</x:xmpmeta>`
+	if err := os.WriteFile(filepath.Join(dir, "IMG_0001.xmp"), []byte(sidecar), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := Read(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.Flag != defs.FlagReject || tags.Rating != 0 {
+		t.Errorf("got %+v, want an unrated reject", tags)
+	}
+}
@@ -0,0 +1,556 @@
+package svg
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+type point struct{ X, Y float64 }
+
+// segment is a line (one point), quadratic (two) or cubic (three) Bézier
+// from the previous segment's end
+type segment []point
+
+type subpath struct {
+	start    point
+	segments []segment
+	closed   bool
+}
+
+type path []subpath
+
+// matrix is an affine transform [a c e; b d f]
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m matrix) apply(p point) point {
+	return point{m[0]*p.X + m[2]*p.Y + m[4], m[1]*p.X + m[3]*p.Y + m[5]}
+}
+
+// scale is how much the transform grows lengths on average, used for
+// stroke widths
+func (m matrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+func (p path) transform(m matrix) path {
+	out := make(path, len(p))
+	for i, sp := range p {
+		t := subpath{start: m.apply(sp.start), closed: sp.closed}
+		for _, seg := range sp.segments {
+			ts := make(segment, len(seg))
+			for j, pt := range seg {
+				ts[j] = m.apply(pt)
+			}
+			t.segments = append(t.segments, ts)
+		}
+		out[i] = t
+	}
+	return out
+}
+
+// parseTransform reads a transform attribute
+func parseTransform(s string) matrix {
+	m := identity
+	for {
+		open := strings.IndexByte(s, '(')
+		end := strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return m
+		}
+		name := strings.TrimSpace(strings.Trim(s[:open], ", \t\n"))
+		args := numbers(s[open+1 : end])
+		s = s[end+1:]
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+
+		var t matrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			copy(t[:], args)
+		case "translate":
+			t = matrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = matrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = matrix{1, 0, 0, 1, cx, cy}.
+				mul(matrix{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}).
+				mul(matrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = matrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = matrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		m = m.mul(t)
+	}
+}
+
+// shapePath builds the outline of a drawing element
+func shapePath(name string, attrs map[string]string, doc *Document) (path, bool) {
+	switch name {
+	case "path":
+		p := parsePath(attrs["d"])
+		return p, len(p) > 0
+	case "rect":
+		x, y := attrNumber(attrs, "x"), attrNumber(attrs, "y")
+		w, h := attrNumber(attrs, "width"), attrNumber(attrs, "height")
+		if w <= 0 || h <= 0 {
+			return nil, false
+		}
+		rx, hasRX := attrs["rx"]
+		ry, hasRY := attrs["ry"]
+		r := [2]float64{length(rx, 0), length(ry, 0)}
+		if !hasRX {
+			r[0] = r[1]
+		}
+		if !hasRY {
+			r[1] = r[0]
+		}
+		return roundedRect(x, y, w, h, math.Min(r[0], w/2), math.Min(r[1], h/2)), true
+	case "circle":
+		r := attrNumber(attrs, "r")
+		return ellipse(attrNumber(attrs, "cx"), attrNumber(attrs, "cy"), r, r), r > 0
+	case "ellipse":
+		rx, ry := attrNumber(attrs, "rx"), attrNumber(attrs, "ry")
+		return ellipse(attrNumber(attrs, "cx"), attrNumber(attrs, "cy"), rx, ry), rx > 0 && ry > 0
+	case "line":
+		sp := subpath{start: point{attrNumber(attrs, "x1"), attrNumber(attrs, "y1")}}
+		sp.segments = []segment{{point{attrNumber(attrs, "x2"), attrNumber(attrs, "y2")}}}
+		return path{sp}, true
+	case "polyline", "polygon":
+		n := numbers(attrs["points"])
+		if len(n) < 4 {
+			return nil, false
+		}
+		sp := subpath{start: point{n[0], n[1]}, closed: name == "polygon"}
+		for i := 2; i+1 < len(n); i += 2 {
+			sp.segments = append(sp.segments, segment{{n[i], n[i+1]}})
+		}
+		return path{sp}, true
+	}
+	return nil, false
+}
+
+func roundedRect(x, y, w, h, rx, ry float64) path {
+	if rx <= 0 || ry <= 0 {
+		return path{{
+			start:    point{x, y},
+			segments: []segment{{{x + w, y}}, {{x + w, y + h}}, {{x, y + h}}},
+			closed:   true,
+		}}
+	}
+
+	b := newBuilder()
+	b.moveTo(point{x + rx, y})
+	b.lineTo(point{x + w - rx, y})
+	b.arcTo(rx, ry, 0, false, true, point{x + w, y + ry})
+	b.lineTo(point{x + w, y + h - ry})
+	b.arcTo(rx, ry, 0, false, true, point{x + w - rx, y + h})
+	b.lineTo(point{x + rx, y + h})
+	b.arcTo(rx, ry, 0, false, true, point{x, y + h - ry})
+	b.lineTo(point{x, y + ry})
+	b.arcTo(rx, ry, 0, false, true, point{x + rx, y})
+	b.close()
+	return b.path
+}
+
+func ellipse(cx, cy, rx, ry float64) path {
+	b := newBuilder()
+	b.moveTo(point{cx + rx, cy})
+	b.arcTo(rx, ry, 0, false, true, point{cx, cy + ry})
+	b.arcTo(rx, ry, 0, false, true, point{cx - rx, cy})
+	b.arcTo(rx, ry, 0, false, true, point{cx, cy - ry})
+	b.arcTo(rx, ry, 0, false, true, point{cx + rx, cy})
+	b.close()
+	return b.path
+}
+
+// builder accumulates subpaths the way path data describes them
+type builder struct {
+	path path
+	cur  point
+}
+
+func newBuilder() *builder {
+	return &builder{}
+}
+
+func (b *builder) current() *subpath {
+	if len(b.path) == 0 {
+		b.moveTo(b.cur)
+	}
+	return &b.path[len(b.path)-1]
+}
+
+func (b *builder) moveTo(p point) {
+	b.path = append(b.path, subpath{start: p})
+	b.cur = p
+}
+
+func (b *builder) lineTo(p point) {
+	sp := b.current()
+	sp.segments = append(sp.segments, segment{p})
+	b.cur = p
+}
+
+func (b *builder) quadTo(c, p point) {
+	sp := b.current()
+	sp.segments = append(sp.segments, segment{c, p})
+	b.cur = p
+}
+
+func (b *builder) cubicTo(c1, c2, p point) {
+	sp := b.current()
+	sp.segments = append(sp.segments, segment{c1, c2, p})
+	b.cur = p
+}
+
+func (b *builder) close() {
+	if len(b.path) == 0 {
+		return
+	}
+	sp := &b.path[len(b.path)-1]
+	sp.closed = true
+	b.cur = sp.start
+}
+
+// arcTo adds an elliptical arc as cubics, following the endpoint to centre
+// conversion in the SVG implementation notes
+func (b *builder) arcTo(rx, ry, rotation float64, large, sweep bool, p point) {
+	p0 := b.cur
+	if p0 == p {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		b.lineTo(p)
+		return
+	}
+
+	phi := rotation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (p0.X-p.X)/2, (p0.Y-p.Y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+
+	// Scale radii up if they can't span the endpoints
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		s := math.Sqrt(l)
+		rx, ry = rx*s, ry*s
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := 0.0
+	if den != 0 && num > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if large == sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+	cx := cos*cx1 - sin*cy1 + (p0.X+p.X)/2
+	cy := sin*cx1 + cos*cy1 + (p0.Y+p.Y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	// At most a quarter turn per cubic
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(n)
+	k := 4.0 / 3 * math.Tan(step/4)
+
+	ellipsePoint := func(t float64) (point, point) {
+		ct, st := math.Cos(t), math.Sin(t)
+		pt := point{cx + rx*ct*cos - ry*st*sin, cy + rx*ct*sin + ry*st*cos}
+		deriv := point{-rx*st*cos - ry*ct*sin, -rx*st*sin + ry*ct*cos}
+		return pt, deriv
+	}
+
+	t := theta
+	from, d0 := ellipsePoint(t)
+	for i := 0; i < n; i++ {
+		to, d1 := ellipsePoint(t + step)
+		if i == n-1 {
+			to = p
+		}
+		b.cubicTo(
+			point{from.X + k*d0.X, from.Y + k*d0.Y},
+			point{to.X - k*d1.X, to.Y - k*d1.Y},
+			to,
+		)
+		t += step
+		from, d0 = to, d1
+	}
+}
+
+// parsePath reads path data. Errors end the path at the last good command,
+// as browsers do.
+func parsePath(d string) path {
+	b := newBuilder()
+	sc := scanner{s: d}
+	var cmd byte
+	var lastCtrl point
+	var lastCmd byte
+
+	for {
+		sc.skipSpace()
+		if sc.done() {
+			break
+		}
+		if c := sc.s[sc.i]; isCommand(c) {
+			cmd = c
+			sc.i++
+		} else if cmd == 0 {
+			break
+		}
+
+		rel := cmd >= 'a'
+		base := point{}
+		if rel {
+			base = b.cur
+		}
+		pt := func(x, y float64) point { return point{base.X + x, base.Y + y} }
+
+		ok := true
+		switch cmd | 0x20 {
+		case 'm':
+			var x, y float64
+			if x, y, ok = sc.pair(); ok {
+				b.moveTo(pt(x, y))
+				// Further pairs are implicit line-tos
+				if rel {
+					cmd = 'l'
+				} else {
+					cmd = 'L'
+				}
+			}
+		case 'l':
+			var x, y float64
+			if x, y, ok = sc.pair(); ok {
+				b.lineTo(pt(x, y))
+			}
+		case 'h':
+			var x float64
+			if x, ok = sc.number(); ok {
+				if rel {
+					b.lineTo(point{b.cur.X + x, b.cur.Y})
+				} else {
+					b.lineTo(point{x, b.cur.Y})
+				}
+			}
+		case 'v':
+			var y float64
+			if y, ok = sc.number(); ok {
+				if rel {
+					b.lineTo(point{b.cur.X, b.cur.Y + y})
+				} else {
+					b.lineTo(point{b.cur.X, y})
+				}
+			}
+		case 'c':
+			n, good := sc.numbers(6)
+			if ok = good; ok {
+				c2 := pt(n[2], n[3])
+				b.cubicTo(pt(n[0], n[1]), c2, pt(n[4], n[5]))
+				lastCtrl = c2
+			}
+		case 's':
+			n, good := sc.numbers(4)
+			if ok = good; ok {
+				c1 := b.cur
+				if lastCmd|0x20 == 'c' || lastCmd|0x20 == 's' {
+					c1 = point{2*b.cur.X - lastCtrl.X, 2*b.cur.Y - lastCtrl.Y}
+				}
+				c2 := pt(n[0], n[1])
+				b.cubicTo(c1, c2, pt(n[2], n[3]))
+				lastCtrl = c2
+			}
+		case 'q':
+			n, good := sc.numbers(4)
+			if ok = good; ok {
+				c := pt(n[0], n[1])
+				b.quadTo(c, pt(n[2], n[3]))
+				lastCtrl = c
+			}
+		case 't':
+			var x, y float64
+			if x, y, ok = sc.pair(); ok {
+				c := b.cur
+				if lastCmd|0x20 == 'q' || lastCmd|0x20 == 't' {
+					c = point{2*b.cur.X - lastCtrl.X, 2*b.cur.Y - lastCtrl.Y}
+				}
+				b.quadTo(c, pt(x, y))
+				lastCtrl = c
+			}
+		case 'a':
+			var rx, ry, rot, x, y float64
+			var large, sweep bool
+			rx, ok = sc.number()
+			if ok {
+				ry, ok = sc.number()
+			}
+			if ok {
+				rot, ok = sc.number()
+			}
+			if ok {
+				large, ok = sc.flag()
+			}
+			if ok {
+				sweep, ok = sc.flag()
+			}
+			if ok {
+				x, y, ok = sc.pair()
+			}
+			if ok {
+				b.arcTo(rx, ry, rot, large, sweep, pt(x, y))
+			}
+		case 'z':
+			b.close()
+		default:
+			ok = false
+		}
+		if !ok {
+			break
+		}
+		lastCmd = cmd
+	}
+	return b.path
+}
+
+func isCommand(c byte) bool {
+	return strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0
+}
+
+// scanner reads the numbers of path data and attribute lists, which may
+// run together: "1-2.5.5" is 1, -2.5 and .5
+type scanner struct {
+	s string
+	i int
+}
+
+func (sc *scanner) done() bool {
+	return sc.i >= len(sc.s)
+}
+
+func (sc *scanner) skipSpace() {
+	for !sc.done() && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+func (sc *scanner) number() (float64, bool) {
+	sc.skipSpace()
+	start := sc.i
+	if !sc.done() && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+		sc.i++
+	}
+	digits, dot := false, false
+	for !sc.done() {
+		c := sc.s[sc.i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '.' && !dot:
+			dot = true
+		case (c == 'e' || c == 'E') && digits:
+			// Only an exponent if a number follows, otherwise it's text
+			j := sc.i + 1
+			if j < len(sc.s) && (sc.s[j] == '+' || sc.s[j] == '-') {
+				j++
+			}
+			if j >= len(sc.s) || sc.s[j] < '0' || sc.s[j] > '9' {
+				goto end
+			}
+			sc.i = j
+			for sc.i < len(sc.s) && sc.s[sc.i] >= '0' && sc.s[sc.i] <= '9' {
+				sc.i++
+			}
+			goto end
+		default:
+			goto end
+		}
+		sc.i++
+	}
+end:
+	if !digits {
+		sc.i = start
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(sc.s[start:sc.i], 64)
+	return f, err == nil
+}
+
+func (sc *scanner) pair() (float64, float64, bool) {
+	x, ok := sc.number()
+	if !ok {
+		return 0, 0, false
+	}
+	y, ok := sc.number()
+	return x, y, ok
+}
+
+func (sc *scanner) numbers(n int) ([]float64, bool) {
+	out := make([]float64, n)
+	for i := range out {
+		f, ok := sc.number()
+		if !ok {
+			return nil, false
+		}
+		out[i] = f
+	}
+	return out, true
+}
+
+// flag reads an arc flag, which is a single 0 or 1 that may not be
+// separated from what follows
+func (sc *scanner) flag() (bool, bool) {
+	sc.skipSpace()
+	if sc.done() {
+		return false, false
+	}
+	switch sc.s[sc.i] {
+	case '0':
+		sc.i++
+		return false, true
+	case '1':
+		sc.i++
+		return true, true
+	}
+	return false, false
+}
@@ -0,0 +1,131 @@
+package svg
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name string
+		d    string
+		want path
+	}{
+		{
+			name: "absolute lines",
+			d:    "M0 0 L10 0 L10 10 Z",
+			want: path{{start: point{0, 0}, segments: []segment{{{10, 0}}, {{10, 10}}}, closed: true}},
+		},
+		{
+			name: "relative with implicit line-tos",
+			d:    "m1 1 2 0 0 2",
+			want: path{{start: point{1, 1}, segments: []segment{{{3, 1}}, {{3, 3}}}}},
+		},
+		{
+			name: "horizontal and vertical",
+			d:    "M5,5H8V9h-3v-4",
+			want: path{{start: point{5, 5}, segments: []segment{{{8, 5}}, {{8, 9}}, {{5, 9}}, {{5, 5}}}}},
+		},
+		{
+			name: "cubic and quadratic",
+			d:    "M0 0C1 2 3 4 5 6Q7 8 9 10",
+			want: path{{start: point{0, 0}, segments: []segment{{{1, 2}, {3, 4}, {5, 6}}, {{7, 8}, {9, 10}}}}},
+		},
+		{
+			name: "compact numbers",
+			d:    "M.5.5l1-1",
+			want: path{{start: point{0.5, 0.5}, segments: []segment{{{1.5, -0.5}}}}},
+		},
+		{
+			name: "stops at bad data",
+			d:    "M0 0 L10 0 L oops 10",
+			want: path{{start: point{0, 0}, segments: []segment{{{10, 0}}}}},
+		},
+		{
+			name: "nothing without a move",
+			d:    "10 10",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePath(tt.d)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePath(%q) = %+v, want %+v", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePathArc(t *testing.T) {
+	// A half circle of radius 5 from the left to the right of the origin
+	p := parsePath("M-5 0 A5 5 0 0 1 5 0")
+	if len(p) != 1 || len(p[0].segments) == 0 {
+		t.Fatalf("got %+v", p)
+	}
+	segs := p[0].segments
+	end := segs[len(segs)-1][len(segs[len(segs)-1])-1]
+	if math.Abs(end.X-5) > 1e-9 || math.Abs(end.Y) > 1e-9 {
+		t.Errorf("arc ends at %v, want 5,0", end)
+	}
+	// The curves join on the circle, sweeping through its upper half
+	for _, seg := range segs {
+		pt := seg[len(seg)-1]
+		if pt.Y > 1e-9 || math.Abs(math.Hypot(pt.X, pt.Y)-5) > 1e-9 {
+			t.Errorf("point %v is off the arc", pt)
+		}
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	tests := []struct {
+		s    string
+		in   point
+		want point
+	}{
+		{"", point{1, 2}, point{1, 2}},
+		{"translate(10 20)", point{1, 2}, point{11, 22}},
+		{"translate(10)", point{1, 2}, point{11, 2}},
+		{"scale(2)", point{1, 2}, point{2, 4}},
+		{"scale(2, 3)", point{1, 2}, point{2, 6}},
+		{"rotate(90)", point{1, 0}, point{0, 1}},
+		{"rotate(180 5 5)", point{0, 0}, point{10, 10}},
+		{"matrix(1 0 0 1 5 6)", point{1, 2}, point{6, 8}},
+		// Applied right to left: scale first, then translate
+		{"translate(10,0) scale(2)", point{1, 1}, point{12, 2}},
+		{"bogus(1) translate(1 1)", point{0, 0}, point{1, 1}},
+	}
+	for _, tt := range tests {
+		got := parseTransform(tt.s).apply(tt.in)
+		if math.Abs(got.X-tt.want.X) > 1e-9 || math.Abs(got.Y-tt.want.Y) > 1e-9 {
+			t.Errorf("parseTransform(%q) maps %v to %v, want %v", tt.s, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShapePath(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		ok    bool
+	}{
+		{"rect", map[string]string{"width": "10", "height": "5"}, true},
+		{"rect", map[string]string{"width": "0", "height": "5"}, false},
+		{"circle", map[string]string{"r": "3"}, true},
+		{"circle", map[string]string{}, false},
+		{"ellipse", map[string]string{"rx": "3", "ry": "2"}, true},
+		{"line", map[string]string{"x2": "4"}, true},
+		{"polygon", map[string]string{"points": "0,0 1,0 1,1"}, true},
+		{"polyline", map[string]string{"points": "0,0"}, false},
+		{"text", map[string]string{}, false},
+	}
+	for _, tt := range tests {
+		if _, ok := shapePath(tt.name, tt.attrs, &Document{}); ok != tt.ok {
+			t.Errorf("shapePath(%s, %v) ok = %v, want %v", tt.name, tt.attrs, ok, tt.ok)
+		}
+	}
+}
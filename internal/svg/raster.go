@@ -0,0 +1,186 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// maxIntrinsic caps the size of a rasterization when the caller didn't ask
+// for one, so a huge width attribute can't eat all memory
+const maxIntrinsic = 2048
+
+// Decode parses r and rasterizes it to fit in w x h. With no size it's
+// drawn at its intrinsic size.
+func Decode(r io.Reader, w, h int) (image.Image, error) {
+	doc, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Rasterize(w, h), nil
+}
+
+// Rasterize draws the document into the largest image with its aspect
+// ratio that fits in w x h
+func (d *Document) Rasterize(w, h int) *image.RGBA {
+	if w <= 0 || h <= 0 {
+		w, h = int(math.Ceil(d.Width)), int(math.Ceil(d.Height))
+		w, h = min(max(w, 1), maxIntrinsic), min(max(h, 1), maxIntrinsic)
+	}
+
+	// Keep the document's aspect ratio
+	if dw := float64(h) * d.Width / d.Height; dw <= float64(w) {
+		w = max(int(math.Round(dw)), 1)
+	} else {
+		h = max(int(math.Round(float64(w)*d.Height/d.Width)), 1)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	// viewBox to pixels, preserveAspectRatio="xMidYMid meet"
+	vb := d.ViewBox
+	s := math.Min(float64(w)/vb[2], float64(h)/vb[3])
+	tx := (float64(w)-vb[2]*s)/2 - vb[0]*s
+	ty := (float64(h)-vb[3]*s)/2 - vb[1]*s
+	view := matrix{s, 0, 0, s, tx, ty}
+
+	for _, sh := range d.shapes {
+		polys := flatten(sh.path.transform(view))
+		if sh.fill.A > 0 {
+			fill(dst, polys, sh.fill)
+		}
+		if sh.stroke.A > 0 && sh.strokeWidth > 0 {
+			// Hairlines still need to show up in a small preview
+			stroke(dst, polys, math.Max(sh.strokeWidth*s, 1), sh.stroke)
+		}
+	}
+	return dst
+}
+
+type polyline struct {
+	points []point
+	closed bool
+}
+
+// flatten turns curves into short lines, finer for bigger curves
+func flatten(p path) []polyline {
+	var out []polyline
+	for _, sp := range p {
+		pl := polyline{points: []point{sp.start}, closed: sp.closed}
+		cur := sp.start
+		for _, seg := range sp.segments {
+			switch len(seg) {
+			case 1:
+				pl.points = append(pl.points, seg[0])
+			case 2:
+				n := steps(cur, seg[0], seg[1])
+				for i := 1; i <= n; i++ {
+					t := float64(i) / float64(n)
+					mt := 1 - t
+					pl.points = append(pl.points, point{
+						mt*mt*cur.X + 2*mt*t*seg[0].X + t*t*seg[1].X,
+						mt*mt*cur.Y + 2*mt*t*seg[0].Y + t*t*seg[1].Y,
+					})
+				}
+			case 3:
+				n := steps(cur, seg[0], seg[1], seg[2])
+				for i := 1; i <= n; i++ {
+					t := float64(i) / float64(n)
+					mt := 1 - t
+					a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+					pl.points = append(pl.points, point{
+						a*cur.X + b*seg[0].X + c*seg[1].X + d*seg[2].X,
+						a*cur.Y + b*seg[0].Y + c*seg[1].Y + d*seg[2].Y,
+					})
+				}
+			}
+			cur = seg[len(seg)-1]
+		}
+		out = append(out, pl)
+	}
+	return out
+}
+
+// steps picks how many lines approximate a curve from the length of its
+// control polygon, roughly one per two pixels
+func steps(pts ...point) int {
+	var l float64
+	for i := 1; i < len(pts); i++ {
+		l += math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+	}
+	return min(max(int(l/2), 2), 128)
+}
+
+func fill(dst *image.RGBA, polys []polyline, c color.NRGBA) {
+	b := dst.Bounds()
+	z := vector.NewRasterizer(b.Dx(), b.Dy())
+	for _, pl := range polys {
+		if len(pl.points) < 3 {
+			continue
+		}
+		z.MoveTo(float32(pl.points[0].X), float32(pl.points[0].Y))
+		for _, p := range pl.points[1:] {
+			z.LineTo(float32(p.X), float32(p.Y))
+		}
+		z.ClosePath()
+	}
+	z.Draw(dst, b, image.NewUniform(c), image.Point{})
+}
+
+// stroke outlines each line as a quad with round joins. Every piece is
+// wound the same way so overlaps don't cancel out in the rasterizer's
+// nonzero accumulation.
+func stroke(dst *image.RGBA, polys []polyline, width float64, c color.NRGBA) {
+	b := dst.Bounds()
+	z := vector.NewRasterizer(b.Dx(), b.Dy())
+	hw := width / 2
+
+	quad := func(a, b point) {
+		dx, dy := b.X-a.X, b.Y-a.Y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			return
+		}
+		nx, ny := -dy/l*hw, dx/l*hw
+		z.MoveTo(float32(a.X+nx), float32(a.Y+ny))
+		z.LineTo(float32(b.X+nx), float32(b.Y+ny))
+		z.LineTo(float32(b.X-nx), float32(b.Y-ny))
+		z.LineTo(float32(a.X-nx), float32(a.Y-ny))
+		z.ClosePath()
+	}
+	join := func(p point) {
+		if hw < 1 {
+			return
+		}
+		const sides = 8
+		for i := 0; i <= sides; i++ {
+			// Clockwise, like the quads
+			a := -float64(i) * 2 * math.Pi / sides
+			x, y := float32(p.X+hw*math.Cos(a)), float32(p.Y+hw*math.Sin(a))
+			if i == 0 {
+				z.MoveTo(x, y)
+			} else {
+				z.LineTo(x, y)
+			}
+		}
+		z.ClosePath()
+	}
+
+	for _, pl := range polys {
+		pts := pl.points
+		if pl.closed && len(pts) > 1 {
+			pts = append(pts[:len(pts):len(pts)], pts[0])
+		}
+		for i := 1; i < len(pts); i++ {
+			quad(pts[i-1], pts[i])
+			join(pts[i])
+		}
+		if !pl.closed && len(pts) > 0 {
+			join(pts[0])
+		}
+	}
+	z.Draw(dst, b, image.NewUniform(c), image.Point{})
+}
@@ -0,0 +1,64 @@
+package svg
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestDecodeFillsShapes(t *testing.T) {
+	img, err := Decode(strings.NewReader(`<svg viewBox="0 0 10 10">
+		<rect x="0" y="0" width="5" height="10" fill="#ff0000"/>
+	</svg>`), 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds(); got != image.Rect(0, 0, 20, 20) {
+		t.Fatalf("got bounds %v, want 20x20", got)
+	}
+	if r, g, b, a := img.At(4, 10).RGBA(); r>>8 != 255 || g != 0 || b != 0 || a>>8 != 255 {
+		t.Errorf("inside the rect got %d,%d,%d,%d, want opaque red", r>>8, g>>8, b>>8, a>>8)
+	}
+	if _, _, _, a := img.At(15, 10).RGBA(); a != 0 {
+		t.Errorf("outside the rect got alpha %d, want transparent", a>>8)
+	}
+}
+
+func TestRasterizeKeepsAspect(t *testing.T) {
+	tests := []struct {
+		svg  string
+		w, h int
+		want image.Point
+	}{
+		{`<svg width="200" height="100"/>`, 100, 100, image.Pt(100, 50)},
+		{`<svg width="100" height="200"/>`, 100, 100, image.Pt(50, 100)},
+		// No size asked for means the intrinsic one
+		{`<svg width="30" height="20"/>`, 0, 0, image.Pt(30, 20)},
+		// which is capped
+		{`<svg width="100000" height="100000"/>`, 0, 0, image.Pt(maxIntrinsic, maxIntrinsic)},
+	}
+	for _, tt := range tests {
+		doc, err := Parse(strings.NewReader(tt.svg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := doc.Rasterize(tt.w, tt.h).Bounds().Size(); got != tt.want {
+			t.Errorf("%s at %dx%d got %v, want %v", tt.svg, tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestStrokeOnly(t *testing.T) {
+	img, err := Decode(strings.NewReader(`<svg viewBox="0 0 10 10">
+		<line x1="0" y1="5" x2="10" y2="5" stroke="black" stroke-width="2"/>
+	</svg>`), 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, a := img.At(5, 5).RGBA(); a == 0 {
+		t.Error("nothing drawn under the line")
+	}
+	if _, _, _, a := img.At(5, 1).RGBA(); a != 0 {
+		t.Error("the line's fill was drawn")
+	}
+}
@@ -0,0 +1,403 @@
+// Package svg rasterizes the common subset of SVG well enough for a
+// preview: shapes and paths with solid fills and strokes, groups,
+// transforms and inline styles. Gradients are drawn with their first stop
+// colour; text, filters, masks and clipping are ignored.
+package svg
+
+import (
+	"encoding/xml"
+	"errors"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrNotSVG = errors.New("not an svg document")
+
+// Document is a parsed SVG, ready to rasterize at any size
+type Document struct {
+	// Width and Height are the intrinsic size in pixels
+	Width, Height float64
+	// ViewBox is the user space rectangle mapped onto the image
+	ViewBox [4]float64
+
+	shapes []shape
+}
+
+type shape struct {
+	path        path
+	fill        color.NRGBA
+	stroke      color.NRGBA
+	strokeWidth float64
+}
+
+// style is the inherited drawing state while walking the tree
+type style struct {
+	fill          string
+	stroke        string
+	color         string
+	strokeWidth   float64
+	opacity       float64
+	fillOpacity   float64
+	strokeOpacity float64
+	transform     matrix
+	hidden        bool
+}
+
+// Elements whose children are never drawn directly
+var containers = map[string]bool{
+	"defs": true, "clipPath": true, "mask": true, "symbol": true,
+	"pattern": true, "marker": true, "filter": true,
+}
+
+// Elements skipped with everything inside them
+var ignored = map[string]bool{
+	"text": true, "style": true, "script": true, "title": true,
+	"desc": true, "metadata": true, "foreignObject": true,
+}
+
+// Parse reads an SVG document
+func Parse(r io.Reader) (*Document, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	doc := &Document{}
+	gradients := map[string]string{}
+	gradient := ""
+
+	stack := []style{{
+		fill:          "black",
+		stroke:        "none",
+		color:         "black",
+		strokeWidth:   1,
+		opacity:       1,
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		transform:     identity,
+	}}
+	skip := 0
+	root := true
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if root {
+				return nil, err
+			}
+			// Keep whatever was drawn before the document broke
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			if skip > 0 || ignored[name] {
+				skip++
+				continue
+			}
+
+			attrs := attributes(t)
+			if root {
+				if name != "svg" {
+					return nil, ErrNotSVG
+				}
+				doc.setViewport(attrs)
+				root = false
+			}
+
+			s := stack[len(stack)-1].apply(attrs)
+			if containers[name] {
+				s.hidden = true
+			}
+			stack = append(stack, s)
+
+			switch name {
+			case "linearGradient", "radialGradient":
+				gradient = attrs["id"]
+			case "stop":
+				if gradient != "" && gradients[gradient] == "" {
+					c := attrs["stop-color"]
+					if c == "" {
+						c = "black"
+					}
+					gradients[gradient] = c
+				}
+			default:
+				if p, ok := shapePath(name, attrs, doc); ok && !s.hidden {
+					doc.shapes = append(doc.shapes, s.shape(p, gradients))
+				}
+			}
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			if t.Name.Local == "linearGradient" || t.Name.Local == "radialGradient" {
+				gradient = ""
+			}
+		}
+	}
+
+	if root {
+		return nil, ErrNotSVG
+	}
+	return doc, nil
+}
+
+// attributes flattens an element's attributes and its style="" declarations,
+// which take precedence
+func attributes(t xml.StartElement) map[string]string {
+	attrs := make(map[string]string, len(t.Attr))
+	for _, a := range t.Attr {
+		attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if k, v, ok := strings.Cut(decl, ":"); ok {
+			attrs[strings.TrimSpace(k)] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important"))
+		}
+	}
+	return attrs
+}
+
+func (d *Document) setViewport(attrs map[string]string) {
+	if vb := numbers(attrs["viewBox"]); len(vb) == 4 && vb[2] > 0 && vb[3] > 0 {
+		copy(d.ViewBox[:], vb)
+	}
+
+	d.Width = length(attrs["width"], 0)
+	d.Height = length(attrs["height"], 0)
+	vw, vh := d.ViewBox[2], d.ViewBox[3]
+
+	switch {
+	case d.Width > 0 && d.Height > 0:
+	case d.Width > 0 && vw > 0:
+		d.Height = d.Width * vh / vw
+	case d.Height > 0 && vw > 0:
+		d.Width = d.Height * vw / vh
+	case vw > 0:
+		d.Width, d.Height = vw, vh
+	default:
+		// The size browsers fall back to
+		d.Width, d.Height = 300, 150
+	}
+	if vw == 0 {
+		d.ViewBox = [4]float64{0, 0, d.Width, d.Height}
+	}
+}
+
+// apply returns the style of a child element with the given attributes
+func (s style) apply(attrs map[string]string) style {
+	if v, ok := attrs["fill"]; ok && v != "inherit" {
+		s.fill = v
+	}
+	if v, ok := attrs["stroke"]; ok && v != "inherit" {
+		s.stroke = v
+	}
+	if v, ok := attrs["color"]; ok && v != "inherit" {
+		s.color = v
+	}
+	if v, ok := attrs["stroke-width"]; ok {
+		s.strokeWidth = length(v, s.strokeWidth)
+	}
+	if v, ok := attrs["fill-opacity"]; ok {
+		s.fillOpacity = opacity(v)
+	}
+	if v, ok := attrs["stroke-opacity"]; ok {
+		s.strokeOpacity = opacity(v)
+	}
+	// Group opacity should composite the group as a whole; multiplying it
+	// through is close enough for a preview
+	if v, ok := attrs["opacity"]; ok {
+		s.opacity *= opacity(v)
+	}
+	if attrs["display"] == "none" || attrs["visibility"] == "hidden" {
+		s.hidden = true
+	}
+	if v, ok := attrs["transform"]; ok {
+		s.transform = s.transform.mul(parseTransform(v))
+	}
+	return s
+}
+
+func (s style) shape(p path, gradients map[string]string) shape {
+	sh := shape{path: p.transform(s.transform)}
+	sh.fill = s.paint(s.fill, s.fillOpacity, gradients)
+	sh.stroke = s.paint(s.stroke, s.strokeOpacity, gradients)
+	sh.strokeWidth = s.strokeWidth * s.transform.scale()
+	return sh
+}
+
+// paint resolves a fill or stroke value to a colour; fully transparent
+// means don't paint
+func (s style) paint(value string, alpha float64, gradients map[string]string) color.NRGBA {
+	if strings.HasPrefix(value, "url(") {
+		id := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(strings.Split(value, ")")[0]), "url(#"), "'")
+		if c, ok := gradients[strings.Trim(id, "'\"")]; ok {
+			value = c
+		} else {
+			// A paint server we don't know, e.g. a pattern
+			value = "gray"
+		}
+	}
+	if value == "currentColor" {
+		value = s.color
+	}
+
+	c, ok := parseColor(value)
+	if !ok {
+		return color.NRGBA{}
+	}
+	c.A = uint8(math.Round(float64(c.A) * alpha * s.opacity))
+	return c
+}
+
+func opacity(v string) float64 {
+	if strings.HasSuffix(v, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		if err != nil {
+			return 1
+		}
+		return math.Max(0, math.Min(1, f/100))
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 1
+	}
+	return math.Max(0, math.Min(1, f))
+}
+
+// length converts an SVG length to user units. Percentages have no
+// viewport to refer to here, so they fall back to def.
+func length(v string, def float64) float64 {
+	v = strings.TrimSpace(v)
+	units := map[string]float64{
+		"px": 1, "pt": 96.0 / 72, "pc": 16, "mm": 96 / 25.4, "cm": 96 / 2.54,
+		"in": 96, "em": 16, "ex": 8,
+	}
+	scale := 1.0
+	for unit, s := range units {
+		if strings.HasSuffix(v, unit) {
+			v, scale = strings.TrimSuffix(v, unit), s
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return f * scale
+}
+
+// numbers splits a list of numbers separated by commas and/or whitespace
+func numbers(s string) []float64 {
+	var out []float64
+	sc := scanner{s: s}
+	for {
+		f, ok := sc.number()
+		if !ok {
+			return out
+		}
+		out = append(out, f)
+	}
+}
+
+// attrNumber reads a numeric attribute as a length
+func attrNumber(attrs map[string]string, key string) float64 {
+	return length(attrs[key], 0)
+}
+
+var namedColors = map[string]color.NRGBA{
+	"black": {0, 0, 0, 255}, "white": {255, 255, 255, 255}, "red": {255, 0, 0, 255},
+	"green": {0, 128, 0, 255}, "blue": {0, 0, 255, 255}, "yellow": {255, 255, 0, 255},
+	"cyan": {0, 255, 255, 255}, "aqua": {0, 255, 255, 255}, "magenta": {255, 0, 255, 255},
+	"fuchsia": {255, 0, 255, 255}, "gray": {128, 128, 128, 255}, "grey": {128, 128, 128, 255},
+	"silver": {192, 192, 192, 255}, "maroon": {128, 0, 0, 255}, "olive": {128, 128, 0, 255},
+	"lime": {0, 255, 0, 255}, "teal": {0, 128, 128, 255}, "navy": {0, 0, 128, 255},
+	"purple": {128, 0, 128, 255}, "orange": {255, 165, 0, 255}, "pink": {255, 192, 203, 255},
+	"brown": {165, 42, 42, 255}, "gold": {255, 215, 0, 255}, "indigo": {75, 0, 130, 255},
+	"violet": {238, 130, 238, 255}, "darkgray": {169, 169, 169, 255}, "darkgrey": {169, 169, 169, 255},
+	"lightgray": {211, 211, 211, 255}, "lightgrey": {211, 211, 211, 255},
+	"darkred": {139, 0, 0, 255}, "darkgreen": {0, 100, 0, 255}, "darkblue": {0, 0, 139, 255},
+	"lightblue": {173, 216, 230, 255}, "lightgreen": {144, 238, 144, 255},
+	"skyblue": {135, 206, 235, 255}, "steelblue": {70, 130, 180, 255},
+	"tomato": {255, 99, 71, 255}, "coral": {255, 127, 80, 255}, "salmon": {250, 128, 114, 255},
+	"crimson": {220, 20, 60, 255}, "tan": {210, 180, 140, 255}, "beige": {245, 245, 220, 255},
+	"whitesmoke": {245, 245, 245, 255}, "gainsboro": {220, 220, 220, 255},
+	"dimgray": {105, 105, 105, 255}, "dimgrey": {105, 105, 105, 255},
+	"slategray": {112, 128, 144, 255}, "slategrey": {112, 128, 144, 255},
+	"transparent": {0, 0, 0, 0},
+}
+
+// parseColor understands names, #rgb, #rgba, #rrggbb, #rrggbbaa, rgb() and
+// rgba()
+func parseColor(v string) (color.NRGBA, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" || v == "none" {
+		return color.NRGBA{}, false
+	}
+	if c, ok := namedColors[v]; ok {
+		return c, true
+	}
+
+	if hex, ok := strings.CutPrefix(v, "#"); ok {
+		if len(hex) == 3 || len(hex) == 4 {
+			var b strings.Builder
+			for _, r := range hex {
+				b.WriteRune(r)
+				b.WriteRune(r)
+			}
+			hex = b.String()
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 8 {
+			return color.NRGBA{}, false
+		}
+		return color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, true
+	}
+
+	if open := strings.IndexByte(v, '('); open > 0 && strings.HasSuffix(v, ")") {
+		fn := v[:open]
+		if fn != "rgb" && fn != "rgba" {
+			return color.NRGBA{}, false
+		}
+		parts := strings.FieldsFunc(v[open+1:len(v)-1], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '/'
+		})
+		if len(parts) < 3 {
+			return color.NRGBA{}, false
+		}
+		var c [4]uint8
+		c[3] = 255
+		for i, part := range parts[:min(len(parts), 4)] {
+			// Values are out of 255, or 1 for alpha, or 100 as percentages
+			scale, of := 1.0, 1.0
+			if i == 3 {
+				scale = 255
+			}
+			if p, ok := strings.CutSuffix(part, "%"); ok {
+				part, scale, of = p, 255, 100
+			}
+			f, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return color.NRGBA{}, false
+			}
+			c[i] = uint8(math.Max(0, math.Min(255, math.Round(f*scale/of))))
+		}
+		return color.NRGBA{c[0], c[1], c[2], c[3]}, true
+	}
+	return color.NRGBA{}, false
+}
@@ -0,0 +1,119 @@
+package svg
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestParseViewport(t *testing.T) {
+	tests := []struct {
+		svg     string
+		w, h    float64
+		viewBox [4]float64
+	}{
+		{`<svg width="200" height="100"/>`, 200, 100, [4]float64{0, 0, 200, 100}},
+		{`<svg viewBox="0 0 50 25"/>`, 50, 25, [4]float64{0, 0, 50, 25}},
+		{`<svg width="100" viewBox="0 0 50 25"/>`, 100, 50, [4]float64{0, 0, 50, 25}},
+		{`<svg height="1in" viewBox="10 10 20 10"/>`, 192, 96, [4]float64{10, 10, 20, 10}},
+		{`<svg/>`, 300, 150, [4]float64{0, 0, 300, 150}},
+		{`<?xml version="1.0"?><!-- logo --><svg width="2pt" height="3pt"/>`, 8.0 / 3, 4, [4]float64{0, 0, 8.0 / 3, 4}},
+	}
+	for _, tt := range tests {
+		doc, err := Parse(strings.NewReader(tt.svg))
+		if err != nil {
+			t.Errorf("Parse(%s): %v", tt.svg, err)
+			continue
+		}
+		if doc.Width != tt.w || doc.Height != tt.h || doc.ViewBox != tt.viewBox {
+			t.Errorf("Parse(%s) = %vx%v %v, want %vx%v %v", tt.svg, doc.Width, doc.Height, doc.ViewBox, tt.w, tt.h, tt.viewBox)
+		}
+	}
+}
+
+func TestParseNotSVG(t *testing.T) {
+	for _, doc := range []string{`<html><svg/></html>`, ``, `hello`} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("Parse(%q) succeeded", doc)
+		}
+	}
+	if _, err := Parse(strings.NewReader(`<html><svg/></html>`)); !errors.Is(err, ErrNotSVG) {
+		t.Errorf("got %v, want ErrNotSVG", err)
+	}
+}
+
+func TestParseShapes(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<svg viewBox="0 0 10 10">
+		<defs><rect width="5" height="5"/></defs>
+		<linearGradient id="g"><stop stop-color="red"/><stop stop-color="blue"/></linearGradient>
+		<g fill="blue" style="stroke: green; stroke-width: 2">
+			<rect width="5" height="5"/>
+			<circle r="2" fill="url(#g)"/>
+		</g>
+		<text>ignored</text>
+	</svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.shapes) != 2 {
+		t.Fatalf("got %d shapes, want the rect and circle outside defs", len(doc.shapes))
+	}
+	rect, circle := doc.shapes[0], doc.shapes[1]
+	if rect.fill != (color.NRGBA{0, 0, 255, 255}) || rect.stroke != (color.NRGBA{0, 128, 0, 255}) || rect.strokeWidth != 2 {
+		t.Errorf("rect didn't inherit the group's style: %+v", rect)
+	}
+	if circle.fill != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("gradient fill is %v, want its first stop", circle.fill)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.NRGBA
+		ok   bool
+	}{
+		{"red", color.NRGBA{255, 0, 0, 255}, true},
+		{" Navy ", color.NRGBA{0, 0, 128, 255}, true},
+		{"#abc", color.NRGBA{0xaa, 0xbb, 0xcc, 0xff}, true},
+		{"#abcd", color.NRGBA{0xaa, 0xbb, 0xcc, 0xdd}, true},
+		{"#102030", color.NRGBA{0x10, 0x20, 0x30, 0xff}, true},
+		{"#10203040", color.NRGBA{0x10, 0x20, 0x30, 0x40}, true},
+		{"rgb(1, 2, 3)", color.NRGBA{1, 2, 3, 255}, true},
+		{"rgba(1 2 3 / 0.5)", color.NRGBA{1, 2, 3, 128}, true},
+		{"rgb(100%, 0%, 50%)", color.NRGBA{255, 0, 128, 255}, true},
+		{"none", color.NRGBA{}, false},
+		{"", color.NRGBA{}, false},
+		{"#12", color.NRGBA{}, false},
+		{"hsl(0, 100%, 50%)", color.NRGBA{}, false},
+		{"notacolor", color.NRGBA{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseColor(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseColor(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"10", 10},
+		{"10px", 10},
+		{"1in", 96},
+		{"72pt", 96},
+		{"2.54cm", 96},
+		{"1em", 16},
+		{"50%", 7},
+		{"", 7},
+	}
+	for _, tt := range tests {
+		if got := length(tt.in, 7); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("length(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
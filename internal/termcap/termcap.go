@@ -0,0 +1,277 @@
+package termcap
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+	"github.com/nooooaaaaah/photoboard/internal/render"
+)
+
+const (
+	// Sent first so the DA1 reply, which every terminal answers, tells us all
+	// other replies have arrived
+	kittyQuery    = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"
+	sixelColors   = "\x1b[?1;1;0S"
+	sixelGeometry = "\x1b[?2;1;0S"
+	cellSizeQuery = "\x1b[16t"
+	da1Query      = "\x1b[c"
+)
+
+var (
+	da1Reply      = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+	kittyReply    = regexp.MustCompile(`\x1b_Gi=31;([^\x1b]*)\x1b\\`)
+	graphicsReply = regexp.MustCompile(`\x1b\[\?([12]);([0-9]+);([0-9;]*)S`)
+	cellSizeReply = regexp.MustCompile(`\x1b\[6;([0-9]+);([0-9]+)t`)
+)
+
+// Report is what we learned about the terminal, from queries and from the
+// environment
+type Report struct {
+	Term        string
+	TermProgram string
+	Multiplexer string
+	Profile     termenv.Profile
+
+	Probed        bool
+	ProbeError    error
+	DA1           []int
+	KittyGraphics bool
+	SixelDA1      bool
+	SixelColors   int
+	SixelMaxSize  [2]int
+	CellWidth     int
+	CellHeight    int
+
+	// Backends the environment suggests even without a query reply
+	Heuristic []string
+}
+
+// Detect gathers environment heuristics and, when stdin is a terminal,
+// queries it. It must run before the bubbletea program owns the terminal.
+func Detect(timeout time.Duration) Report {
+	r := Report{
+		Term:        os.Getenv("TERM"),
+		TermProgram: os.Getenv("TERM_PROGRAM"),
+		Profile:     lipgloss.ColorProfile(),
+	}
+	switch {
+	case os.Getenv("TMUX") != "":
+		r.Multiplexer = "tmux"
+	case strings.HasPrefix(r.Term, "screen"):
+		r.Multiplexer = "screen"
+	}
+	r.Heuristic = heuristics(r)
+
+	if !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()) {
+		r.ProbeError = fmt.Errorf("not a terminal")
+		return r
+	}
+
+	reply, err := query(timeout)
+	r.Probed = err == nil
+	r.ProbeError = err
+	r.parse(reply)
+	return r
+}
+
+func heuristics(r Report) []string {
+	var backends []string
+	if r.Term == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "" || r.TermProgram == "ghostty" {
+		backends = append(backends, "kitty")
+	}
+	switch {
+	case strings.HasPrefix(r.Term, "foot"), strings.HasPrefix(r.Term, "mlterm"), r.Term == "yaft-256color":
+		backends = append(backends, "sixel")
+	}
+	switch r.TermProgram {
+	case "iTerm.app", "WezTerm", "mintty":
+		backends = append(backends, "iterm2")
+	}
+	return backends
+}
+
+func query(timeout time.Duration) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer tty.Close()
+
+	// Without read deadlines a silent terminal would leave a reader blocked
+	// on the tty, stealing input from the program later
+	if err := tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return "", err
+	}
+
+	state, err := term.MakeRaw(tty.Fd())
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(tty.Fd(), state)
+
+	if _, err := io.WriteString(tty, kittyQuery+sixelColors+sixelGeometry+cellSizeQuery+da1Query); err != nil {
+		return "", err
+	}
+
+	var buf []byte
+	chunk := make([]byte, 256)
+	for {
+		n, err := tty.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if da1Reply.Match(buf) {
+			break
+		}
+		if err != nil {
+			drain(tty, chunk)
+			return string(buf), fmt.Errorf("terminal did not answer: %w", err)
+		}
+	}
+	drain(tty, chunk)
+	return string(buf), nil
+}
+
+// drainWindow is how long the tty has to stay quiet before we hand it over
+const drainWindow = 50 * time.Millisecond
+
+// drain reads whatever else the terminal sends until it goes quiet, so
+// replies that turn up late (after DA1, or after we gave up waiting) aren't
+// left in the tty buffer for the program to read as key presses
+func drain(tty *os.File, chunk []byte) {
+	for {
+		if err := tty.SetReadDeadline(time.Now().Add(drainWindow)); err != nil {
+			return
+		}
+		if n, err := tty.Read(chunk); n == 0 || err != nil {
+			return
+		}
+	}
+}
+
+func (r *Report) parse(reply string) {
+	if m := da1Reply.FindStringSubmatch(reply); m != nil {
+		for _, field := range strings.Split(m[1], ";") {
+			if n, err := strconv.Atoi(field); err == nil {
+				r.DA1 = append(r.DA1, n)
+				if n == 4 {
+					r.SixelDA1 = true
+				}
+			}
+		}
+	}
+
+	if m := kittyReply.FindStringSubmatch(reply); m != nil && m[1] == "OK" {
+		r.KittyGraphics = true
+	}
+
+	for _, m := range graphicsReply.FindAllStringSubmatch(reply, -1) {
+		if m[2] != "0" {
+			continue // non-zero status means the item isn't supported
+		}
+		values := strings.Split(m[3], ";")
+		switch m[1] {
+		case "1":
+			r.SixelColors, _ = strconv.Atoi(values[0])
+		case "2":
+			if len(values) == 2 {
+				r.SixelMaxSize[0], _ = strconv.Atoi(values[0])
+				r.SixelMaxSize[1], _ = strconv.Atoi(values[1])
+			}
+		}
+	}
+
+	if m := cellSizeReply.FindStringSubmatch(reply); m != nil {
+		r.CellHeight, _ = strconv.Atoi(m[1])
+		r.CellWidth, _ = strconv.Atoi(m[2])
+	}
+}
+
+// Capabilities turns the report into what the renderers understand. Query
+// replies win; heuristics fill in for terminals that didn't answer.
+func (r Report) Capabilities() render.Capabilities {
+	caps := render.Capabilities{
+		Profile: r.Profile,
+		Kitty:   r.KittyGraphics,
+		Sixel:   r.SixelDA1 || r.SixelColors > 0,
+
+		SixelColors:  r.SixelColors,
+		SixelMaxSize: image.Pt(r.SixelMaxSize[0], r.SixelMaxSize[1]),
+	}
+	for _, name := range r.Heuristic {
+		if name == "iterm2" || !r.Probed {
+			caps.Assume(name)
+		}
+	}
+	return caps
+}
+
+// Best is the backend a chain built from these capabilities would use
+func (r Report) Best() string {
+	return render.NewChain(r.Capabilities(), "auto").Name()
+}
+
+// Apply makes the renderers use the measured cell size
+func (r Report) Apply() {
+	if r.CellWidth > 0 && r.CellHeight > 0 {
+		render.CellPixelWidth = r.CellWidth
+		render.CellPixelHeight = r.CellHeight
+	}
+}
+
+// String formats the report for the --doctor command
+func (r Report) String() string {
+	var sb strings.Builder
+	yes := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	fmt.Fprintf(&sb, "TERM:             %s\n", r.Term)
+	fmt.Fprintf(&sb, "TERM_PROGRAM:     %s\n", r.TermProgram)
+	if r.Multiplexer != "" {
+		fmt.Fprintf(&sb, "Multiplexer:      %s (graphics may need passthrough)\n", r.Multiplexer)
+	}
+	fmt.Fprintf(&sb, "Color profile:    %s\n", profileName(r.Profile))
+	if r.ProbeError != nil {
+		fmt.Fprintf(&sb, "Terminal query:   failed (%v)\n", r.ProbeError)
+	} else {
+		fmt.Fprintf(&sb, "Terminal query:   ok\n")
+	}
+	fmt.Fprintf(&sb, "DA1 attributes:   %v\n", r.DA1)
+	fmt.Fprintf(&sb, "Kitty graphics:   %s\n", yes(r.KittyGraphics))
+	fmt.Fprintf(&sb, "Sixel (DA1):      %s\n", yes(r.SixelDA1))
+	if r.SixelColors > 0 {
+		fmt.Fprintf(&sb, "Sixel colors:     %d\n", r.SixelColors)
+	}
+	if r.SixelMaxSize[0] > 0 {
+		fmt.Fprintf(&sb, "Sixel max size:   %dx%d\n", r.SixelMaxSize[0], r.SixelMaxSize[1])
+	}
+	if r.CellWidth > 0 {
+		fmt.Fprintf(&sb, "Cell size:        %dx%d px\n", r.CellWidth, r.CellHeight)
+	}
+	fmt.Fprintf(&sb, "Heuristics:       %s\n", strings.Join(r.Heuristic, ", "))
+	fmt.Fprintf(&sb, "Image backend:    %s\n", r.Best())
+	return sb.String()
+}
+
+func profileName(p termenv.Profile) string {
+	switch p {
+	case termenv.TrueColor:
+		return "truecolor"
+	case termenv.ANSI256:
+		return "256 colors"
+	case termenv.ANSI:
+		return "16 colors"
+	}
+	return "no color"
+}
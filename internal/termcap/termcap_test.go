@@ -0,0 +1,94 @@
+package termcap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  Report
+	}{
+		{
+			name:  "empty",
+			reply: "",
+			want:  Report{},
+		},
+		{
+			name:  "DA1 with sixel",
+			reply: "\x1b[?62;4;22c",
+			want:  Report{DA1: []int{62, 4, 22}, SixelDA1: true},
+		},
+		{
+			name:  "DA1 without sixel",
+			reply: "\x1b[?62;22c",
+			want:  Report{DA1: []int{62, 22}},
+		},
+		{
+			name:  "DA1 with 4 only as part of another number",
+			reply: "\x1b[?64;42c",
+			want:  Report{DA1: []int{64, 42}},
+		},
+		{
+			name:  "XTSMGRAPHICS colors",
+			reply: "\x1b[?1;0;1024S",
+			want:  Report{SixelColors: 1024},
+		},
+		{
+			name:  "XTSMGRAPHICS geometry",
+			reply: "\x1b[?2;0;1000;800S",
+			want:  Report{SixelMaxSize: [2]int{1000, 800}},
+		},
+		{
+			name:  "XTSMGRAPHICS failures",
+			reply: "\x1b[?1;3;0S\x1b[?2;1;0S",
+			want:  Report{},
+		},
+		{
+			name:  "kitty OK",
+			reply: "\x1b_Gi=31;OK\x1b\\",
+			want:  Report{KittyGraphics: true},
+		},
+		{
+			name:  "kitty ENOENT",
+			reply: "\x1b_Gi=31;ENOENT:file not found\x1b\\",
+			want:  Report{},
+		},
+		{
+			name:  "cell size",
+			reply: "\x1b[6;20;10t",
+			want:  Report{CellHeight: 20, CellWidth: 10},
+		},
+		{
+			name: "everything at once",
+			reply: "\x1b_Gi=31;OK\x1b\\" +
+				"\x1b[?1;0;256S" +
+				"\x1b[?2;0;1920;1080S" +
+				"\x1b[6;18;9t" +
+				"\x1b[?65;1;4;9c",
+			want: Report{
+				DA1:           []int{65, 1, 4, 9},
+				KittyGraphics: true,
+				SixelDA1:      true,
+				SixelColors:   256,
+				SixelMaxSize:  [2]int{1920, 1080},
+				CellWidth:     9,
+				CellHeight:    18,
+			},
+		},
+		{
+			name:  "only some replies",
+			reply: "\x1b_Gi=31;EINVAL:bad\x1b\\\x1b[?2;3;0S\x1b[?1;2c",
+			want:  Report{DA1: []int{1, 2}},
+		},
+	}
+	for _, tt := range tests {
+		var got Report
+		got.parse(tt.reply)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
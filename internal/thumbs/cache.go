@@ -0,0 +1,271 @@
+package thumbs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+)
+
+// Size is a thumbnail size class from the freedesktop thumbnail spec, the
+// value being the maximum edge in pixels
+type Size int
+
+const (
+	Normal Size = 128
+	Large  Size = 256
+)
+
+func (s Size) dir() string {
+	if s == Large {
+		return "large"
+	}
+	return "normal"
+}
+
+var ErrMiss = errors.New("thumbnail not cached")
+
+// Cache reads and writes thumbnails in the shared freedesktop cache, so
+// thumbnails made by file managers are reused and ours are visible to them
+type Cache struct {
+	Root     string
+	MaxBytes int64
+}
+
+// Dir returns $XDG_CACHE_HOME/thumbnails
+func Dir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "thumbnails")
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "thumbnails")
+	}
+	return filepath.Join(dir, "thumbnails")
+}
+
+func NewCache(maxBytes int64) *Cache {
+	return &Cache{Root: Dir(), MaxBytes: maxBytes}
+}
+
+// URI returns the file URI for path, escaped the way GLib's
+// g_filename_to_uri does it since the cache key is the MD5 of this string
+func URI(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("file://")
+	for _, b := range []byte(filepath.ToSlash(abs)) {
+		if isURISafe(b) {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String(), nil
+}
+
+func isURISafe(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("-_.~!$&'()*+,;=:@/", b) >= 0
+}
+
+// Path is where the thumbnail for uri lives
+func (c *Cache) Path(uri string, size Size) string {
+	sum := md5.Sum([]byte(uri))
+	return filepath.Join(c.Root, size.dir(), hex.EncodeToString(sum[:])+".png")
+}
+
+// software tags the thumbnails we write, versioned so ones made by older
+// builds can be told apart
+const software = "photoboard 2"
+
+// Lookup returns the cached thumbnail of path if there is one and its
+// Thumb::MTime still matches the file
+func (c *Cache) Lookup(path string, size Size) (image.Image, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	uri, err := URI(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(c.Path(uri, size))
+	if err != nil {
+		return nil, ErrMiss
+	}
+
+	text := readTextChunks(data)
+	if text["Thumb::URI"] != uri || text["Thumb::MTime"] != strconv.FormatInt(info.ModTime().Unix(), 10) {
+		return nil, ErrMiss
+	}
+	// Our first thumbnails ignored EXIF orientation
+	if text["Software"] == "photoboard" {
+		return nil, ErrMiss
+	}
+
+	return png.Decode(bytes.NewReader(data))
+}
+
+// Store writes img as the thumbnail of path, tagged with the metadata the
+// spec requires. The file is written to a temporary name and renamed so
+// other readers never see a partial PNG.
+func (c *Cache) Store(path string, size Size, img image.Image) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	uri, err := URI(path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	text := [][2]string{
+		{"Thumb::URI", uri},
+		{"Thumb::MTime", strconv.FormatInt(info.ModTime().Unix(), 10)},
+		{"Thumb::Size", strconv.FormatInt(info.Size(), 10)},
+		{"Software", software},
+	}
+	data := insertTextChunks(buf.Bytes(), text)
+
+	dest := c.Path(uri, size)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".photoboard-*.png")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// Thumbnail returns the cached thumbnail of path, generating and storing it
+// first when needed
+func (c *Cache) Thumbnail(path string, size Size) (image.Image, error) {
+	if img, err := c.Lookup(path, size); err == nil {
+		return img, nil
+	}
+
+	src, err := imaging.LoadSize(path, int(size), int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	thumb := Scale(src, size)
+	if err := c.Store(path, size, thumb); err != nil {
+		// Still useful even if the cache isn't writable
+		log.Warn("Failed to cache thumbnail", "path", path, "error", err)
+	}
+	return thumb, nil
+}
+
+// Scale shrinks img so its longest edge is at most size; smaller images are
+// returned as they are
+func Scale(img image.Image, size Size) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= int(size) && b.Dy() <= int(size) {
+		return img
+	}
+	w, h := imaging.Fit(b.Dx(), b.Dy(), int(size), int(size), 1)
+	return imaging.Resize(img, w, h)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// insertTextChunks adds tEXt chunks right after IHDR. image/png has no way
+// to write ancillary chunks itself.
+func insertTextChunks(data []byte, text [][2]string) []byte {
+	// Signature, then IHDR: length, type, 13 bytes of data, CRC
+	ihdrEnd := len(pngSignature) + 4 + 4 + 13 + 4
+	if len(data) < ihdrEnd || !bytes.Equal(data[:len(pngSignature)], pngSignature) {
+		return data
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:ihdrEnd])
+	for _, kv := range text {
+		payload := append([]byte(kv[0]), 0)
+		payload = append(payload, kv[1]...)
+		writeChunk(&out, "tEXt", payload)
+	}
+	out.Write(data[ihdrEnd:])
+	return out.Bytes()
+}
+
+func writeChunk(w io.Writer, typ string, payload []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+	copy(header[4:], typ)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(payload)
+
+	w.Write(header[:])
+	w.Write(payload)
+	binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+// readTextChunks collects tEXt key/value pairs that appear before the image
+// data, which is where the spec's Thumb:: keys are stored
+func readTextChunks(data []byte) map[string]string {
+	text := make(map[string]string)
+	if len(data) < len(pngSignature) || !bytes.Equal(data[:len(pngSignature)], pngSignature) {
+		return text
+	}
+
+	for pos := len(pngSignature); pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		end := pos + 8 + length + 4
+		if length < 0 || end > len(data) || typ == "IDAT" || typ == "IEND" {
+			break
+		}
+
+		if typ == "tEXt" {
+			key, value, _ := bytes.Cut(data[pos+8:pos+8+length], []byte{0})
+			text[string(key)] = string(value)
+		}
+		pos = end
+	}
+	return text
+}
@@ -0,0 +1,90 @@
+package thumbs
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestURI(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/home/jens/photos/me.png", "file:///home/jens/photos/me.png"},
+		{"/tmp/a b/c#1?.jpg", "file:///tmp/a%20b/c%231%3F.jpg"},
+		{"/tmp/50%.png", "file:///tmp/50%25.png"},
+		{"/tmp/(x)+y,z;w=v:u@t~s!$&'*.png", "file:///tmp/(x)+y,z;w=v:u@t~s!$&'*.png"},
+		{"/tmp/café.png", "file:///tmp/caf%C3%A9.png"},
+		{"/tmp/dir/../b.png", "file:///tmp/b.png"},
+	}
+	for _, tt := range tests {
+		got, err := URI(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("URI(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	c := &Cache{Root: "/cache/thumbnails"}
+	// The example from the thumbnail spec
+	uri := "file:///home/jens/photos/me.png"
+	if got, want := c.Path(uri, Normal), "/cache/thumbnails/normal/c6ee772d9e49320e97ec29a7eb5b1697.png"; got != filepath.FromSlash(want) {
+		t.Errorf("normal: got %s, want %s", got, want)
+	}
+	if got, want := c.Path(uri, Large), "/cache/thumbnails/large/c6ee772d9e49320e97ec29a7eb5b1697.png"; got != filepath.FromSlash(want) {
+		t.Errorf("large: got %s, want %s", got, want)
+	}
+}
+
+func TestStoreLookup(t *testing.T) {
+	dir := t.TempDir()
+	c := &Cache{Root: filepath.Join(dir, "thumbnails")}
+	source := filepath.Join(dir, "a.jpg")
+	if err := os.WriteFile(source, []byte("not really a jpeg"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Lookup(source, Normal); err != ErrMiss {
+		t.Fatalf("empty cache gave %v, want ErrMiss", err)
+	}
+	if err := c.Store(source, Normal, image.NewRGBA(image.Rect(0, 0, 128, 96))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := c.Lookup(source, Normal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(128, 96) {
+		t.Errorf("got a %v thumbnail, want 128x96", got)
+	}
+
+	uri, _ := URI(source)
+	f, err := os.Open(c.Path(uri, Normal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Errorf("stored thumbnail isn't a valid PNG: %v", err)
+	}
+	text := thumbnailText(c.Path(uri, Normal))
+	if text["Thumb::URI"] != uri || text["Thumb::Size"] != "17" || text["Software"] != software {
+		t.Errorf("got text chunks %v", text)
+	}
+
+	// Editing the file makes the thumbnail stale
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(source, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Lookup(source, Normal); err != ErrMiss {
+		t.Errorf("after the file changed got %v, want ErrMiss", err)
+	}
+}
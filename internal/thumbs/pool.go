@@ -0,0 +1,214 @@
+package thumbs
+
+import (
+	"container/heap"
+	"context"
+	"image"
+	"sync"
+)
+
+// Result is a finished thumbnail. Image is at most Size pixels on its long
+// edge, so holding a few of them is cheap.
+type Result struct {
+	Dir   string
+	Path  string
+	Image image.Image
+	Err   error
+}
+
+type job struct {
+	dir      string
+	path     string
+	priority int
+	seq      uint64
+	index    int
+}
+
+// jobQueue is a min-heap on priority, FIFO within the same priority
+type jobQueue []*job
+
+func (q jobQueue) Len() int { return len(q) }
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *jobQueue) Push(x any) {
+	j := x.(*job)
+	j.index = len(*q)
+	*q = append(*q, j)
+}
+func (q *jobQueue) Pop() any {
+	old := *q
+	j := old[len(old)-1]
+	*q = old[:len(old)-1]
+	j.index = -1
+	return j
+}
+
+// Pool generates thumbnails on a fixed number of workers. Jobs with a lower
+// priority value run first, and all work for a directory can be dropped at
+// once when the user leaves it. Memory stays bounded by the worker count:
+// each worker decodes one image at a time and results wait in a small
+// channel until the UI takes them.
+type Pool struct {
+	cache *Cache
+	size  Size
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   jobQueue
+	queued  map[string]*job
+	cancels map[string]context.CancelFunc
+	ctxs    map[string]context.Context
+	seq     uint64
+	closed  bool
+
+	results chan Result
+}
+
+func NewPool(cache *Cache, size Size, workers int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &Pool{
+		cache:   cache,
+		size:    size,
+		queued:  make(map[string]*job),
+		cancels: make(map[string]context.CancelFunc),
+		ctxs:    make(map[string]context.Context),
+		results: make(chan Result, workers),
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Results delivers finished thumbnails
+func (p *Pool) Results() <-chan Result {
+	return p.results
+}
+
+// Submit queues path, or moves it up if it's already queued with a lower
+// priority (higher value)
+func (p *Pool) Submit(dir, path string, priority int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	if j, ok := p.queued[path]; ok {
+		if priority < j.priority {
+			j.priority = priority
+			heap.Fix(&p.queue, j.index)
+		}
+		return
+	}
+
+	if _, ok := p.ctxs[dir]; !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.ctxs[dir] = ctx
+		p.cancels[dir] = cancel
+	}
+
+	p.seq++
+	j := &job{dir: dir, path: path, priority: priority, seq: p.seq}
+	heap.Push(&p.queue, j)
+	p.queued[path] = j
+	p.cond.Signal()
+}
+
+// Focus cancels queued and running work for every directory but dir
+func (p *Pool) Focus(dir string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cancelLocked(func(d string) bool { return d != dir })
+}
+
+// Cancel drops all work for dir
+func (p *Pool) Cancel(dir string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cancelLocked(func(d string) bool { return d == dir })
+}
+
+func (p *Pool) cancelLocked(match func(dir string) bool) {
+	for dir, cancel := range p.cancels {
+		if match(dir) {
+			cancel()
+			delete(p.cancels, dir)
+			delete(p.ctxs, dir)
+		}
+	}
+
+	kept := p.queue[:0]
+	for _, j := range p.queue {
+		if match(j.dir) {
+			delete(p.queued, j.path)
+			continue
+		}
+		kept = append(kept, j)
+	}
+	p.queue = kept
+	for i, j := range p.queue {
+		j.index = i
+	}
+	heap.Init(&p.queue)
+}
+
+// Close stops the workers once they finish their current job
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.cancelLocked(func(string) bool { return true })
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+func (p *Pool) next() (*job, context.Context, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.queue) == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if p.closed {
+		return nil, nil, false
+	}
+
+	j := heap.Pop(&p.queue).(*job)
+	delete(p.queued, j.path)
+	return j, p.ctxs[j.dir], true
+}
+
+func (p *Pool) work() {
+	for {
+		j, ctx, ok := p.next()
+		if !ok {
+			return
+		}
+		if ctx == nil || ctx.Err() != nil {
+			continue
+		}
+
+		img, err := p.cache.Thumbnail(j.path, p.size)
+
+		// The directory may have been left while we were decoding
+		select {
+		case p.results <- Result{Dir: j.dir, Path: j.path, Image: img, Err: err}:
+		case <-ctx.Done():
+		}
+	}
+}
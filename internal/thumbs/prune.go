@@ -0,0 +1,128 @@
+package thumbs
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PruneStats struct {
+	Scanned int
+	Stale   int   // thumbnails whose source file no longer exists
+	Evicted int   // thumbnails removed to get under the size limit
+	Freed   int64 // bytes
+	Size    int64 // bytes of our thumbnails left in the cache
+}
+
+type cachedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Prune deletes thumbnails of files that are gone, then removes the least
+// recently written of our own thumbnails until they fit in MaxBytes. The
+// cache is shared with other apps, so their thumbnails neither count
+// towards the limit nor get evicted. A MaxBytes of zero or less disables
+// the size limit.
+func (c *Cache) Prune() (PruneStats, error) {
+	return c.prune(true)
+}
+
+// Trim only enforces the size limit. It leaves stale thumbnails alone, so
+// it's cheap enough to run on startup.
+func (c *Cache) Trim() (PruneStats, error) {
+	return c.prune(false)
+}
+
+func (c *Cache) prune(removeStale bool) (PruneStats, error) {
+	var stats PruneStats
+	var files []cachedFile
+
+	for _, size := range []Size{Normal, Large} {
+		dir := filepath.Join(c.Root, size.dir())
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".png" {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			stats.Scanned++
+
+			path := filepath.Join(dir, entry.Name())
+			text := thumbnailText(path)
+			if removeStale && sourceMissing(text) {
+				if os.Remove(path) == nil {
+					stats.Stale++
+					stats.Freed += info.Size()
+				}
+				continue
+			}
+			if !strings.HasPrefix(text["Software"], "photoboard") {
+				continue
+			}
+			files = append(files, cachedFile{path, info.Size(), info.ModTime()})
+			stats.Size += info.Size()
+		}
+	}
+
+	if c.MaxBytes > 0 && stats.Size > c.MaxBytes {
+		sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+		for _, f := range files {
+			if stats.Size <= c.MaxBytes {
+				break
+			}
+			if os.Remove(f.path) == nil {
+				stats.Evicted++
+				stats.Freed += f.size
+				stats.Size -= f.size
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// thumbnailText reads a thumbnail's text chunks. They sit right after the
+// header, so there's no need to read the pixels.
+func thumbnailText(thumbPath string) map[string]string {
+	f, err := os.Open(thumbPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	head := make([]byte, 4096)
+	n, _ := io.ReadFull(f, head)
+	return readTextChunks(head[:n])
+}
+
+// sourceMissing reports whether the local file a thumbnail was made from has
+// been deleted. Thumbnails of remote URIs are left alone.
+func sourceMissing(text map[string]string) bool {
+	uri := text["Thumb::URI"]
+	if !strings.HasPrefix(uri, "file://") {
+		return false
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(u.Path)
+	return os.IsNotExist(err)
+}
@@ -0,0 +1,80 @@
+package thumbs
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeThumb(t *testing.T, c *Cache, source, software string) string {
+	t.Helper()
+	uri, err := URI(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	data := insertTextChunks(buf.Bytes(), [][2]string{{"Thumb::URI", uri}, {"Software", software}})
+
+	path := c.Path(uri, Normal)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTrimOnlyEvictsOurThumbnails(t *testing.T) {
+	dir := t.TempDir()
+	c := &Cache{Root: filepath.Join(dir, "thumbnails"), MaxBytes: 1}
+
+	ours := writeThumb(t, c, filepath.Join(dir, "a.jpg"), software)
+	theirs := writeThumb(t, c, filepath.Join(dir, "b.jpg"), "GNOME::ThumbnailFactory")
+
+	stats, err := c.Trim()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Evicted != 1 || stats.Size != 0 {
+		t.Errorf("got %d evicted, %d bytes left, want 1 and 0", stats.Evicted, stats.Size)
+	}
+	if _, err := os.Stat(ours); !os.IsNotExist(err) {
+		t.Errorf("our thumbnail is still there")
+	}
+	if _, err := os.Stat(theirs); err != nil {
+		t.Errorf("another app's thumbnail was removed: %v", err)
+	}
+}
+
+func TestPruneRemovesStaleThumbnails(t *testing.T) {
+	dir := t.TempDir()
+	c := &Cache{Root: filepath.Join(dir, "thumbnails")}
+
+	source := filepath.Join(dir, "kept.jpg")
+	if err := os.WriteFile(source, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kept := writeThumb(t, c, source, "GNOME::ThumbnailFactory")
+	stale := writeThumb(t, c, filepath.Join(dir, "gone.jpg"), "GNOME::ThumbnailFactory")
+
+	stats, err := c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stale != 1 {
+		t.Errorf("got %d stale, want 1", stats.Stale)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale thumbnail is still there")
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("thumbnail of an existing file was removed: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package trash
+
+// device can't tell filesystems apart here, so everything goes to the home
+// trash
+func device(path string) (uint64, bool) {
+	return 0, false
+}
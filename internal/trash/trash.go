@@ -0,0 +1,186 @@
+// Package trash moves files to the desktop's trash, where they can still
+// be restored from the file manager
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nooooaaaaah/photoboard/internal/utils"
+)
+
+var ErrUnsupported = errors.New("no trash on " + runtime.GOOS)
+
+// deviceOf tells which filesystem a path is on, swapped out by tests
+var deviceOf = device
+
+// Move puts path in the trash
+func Move(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		return utils.MoveFile(abs, utils.FreeName(filepath.Join(home, ".Trash", filepath.Base(abs))))
+	case "windows", "plan9", "js", "wasip1":
+		return ErrUnsupported
+	}
+	return moveFreedesktop(abs)
+}
+
+// moveFreedesktop follows the freedesktop.org trash spec. The .trashinfo
+// file is claimed first, so two moves can't pick the same name.
+func moveFreedesktop(path string) error {
+	dir, top, err := trashFor(path)
+	if err != nil {
+		return err
+	}
+	files, info := filepath.Join(dir, "files"), filepath.Join(dir, "info")
+
+	// Trashes at the top of a mount record paths relative to it, so they
+	// still work when it's mounted somewhere else
+	original := path
+	if top != "" {
+		if rel, err := filepath.Rel(top, path); err == nil {
+			original = rel
+		}
+	}
+
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s.%d%s", stem, i, ext)
+		}
+
+		infoPath := filepath.Join(info, name+".trashinfo")
+		f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(f, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			(&url.URL{Path: original}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = utils.MoveFile(path, filepath.Join(files, name))
+		}
+		if err != nil {
+			os.Remove(infoPath)
+		}
+		return err
+	}
+}
+
+// trashFor picks the trash for path, ready to use. That's the home trash
+// when path is on the same filesystem, otherwise one at the top of path's
+// own mount so trashing stays a rename instead of a copy: an admin's shared
+// $topdir/.Trash/$uid first, then $topdir/.Trash-$uid. top is the mount's
+// top directory when one of those is used. Anything that goes wrong there
+// falls back to the home trash.
+func trashFor(path string) (dir, top string, err error) {
+	home, err := homeTrash()
+	if err != nil {
+		return "", "", err
+	}
+	if dev, ok := deviceOf(filepath.Dir(path)); ok {
+		if homeDev, ok := deviceOf(existingParent(home)); !ok || homeDev != dev {
+			top = mountTop(filepath.Dir(path), dev)
+			for _, dir := range topTrashes(top) {
+				if makeTrash(dir) == nil {
+					return dir, top, nil
+				}
+			}
+		}
+	}
+	return home, "", makeTrash(home)
+}
+
+// topTrashes lists the trashes the spec allows at the top of a mount. The
+// shared .Trash only counts when it's a real directory with the sticky bit
+// set, so other users can't remove our files.
+func topTrashes(top string) []string {
+	uid := strconv.Itoa(os.Getuid())
+	var dirs []string
+	shared := filepath.Join(top, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dirs = append(dirs, filepath.Join(shared, uid))
+	}
+	return append(dirs, filepath.Join(top, ".Trash-"+uid))
+}
+
+// makeTrash creates a trash's directories, refusing a trash that's a
+// symlink
+func makeTrash(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s isn't a directory", dir)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0o700); err != nil {
+		return err
+	}
+	return os.MkdirAll(filepath.Join(dir, "info"), 0o700)
+}
+
+// mountTop walks up from dir, which is on device dev, to the top directory
+// of its mount
+func mountTop(dir string, dev uint64) string {
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		if d, ok := deviceOf(parent); !ok || d != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// existingParent is path or its nearest ancestor that exists, since the
+// home trash may not have been created yet
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+func homeTrash() (string, error) {
+	if data := os.Getenv("XDG_DATA_HOME"); data != "" {
+		return filepath.Join(data, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
@@ -0,0 +1,106 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readInfo(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestMoveToHomeTrash(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	for i := 0; i < 2; i++ {
+		writeFile(t, filepath.Join(dir, "a b.jpg"))
+		if err := moveFreedesktop(filepath.Join(dir, "a b.jpg")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trash := filepath.Join(dir, "data", "Trash")
+	for _, name := range []string{"a b.jpg", "a b.2.jpg"} {
+		if _, err := os.Stat(filepath.Join(trash, "files", name)); err != nil {
+			t.Errorf("%s isn't in the trash: %v", name, err)
+		}
+		info := readInfo(t, filepath.Join(trash, "info", name+".trashinfo"))
+		if want := "Path=" + filepath.ToSlash(dir) + "/a%20b.jpg\n"; !strings.Contains(info, want) {
+			t.Errorf("%s.trashinfo is %q, want it to contain %q", name, info, want)
+		}
+	}
+}
+
+func TestMoveToMountTrash(t *testing.T) {
+	dir := t.TempDir()
+	home, mount := filepath.Join(dir, "home"), filepath.Join(dir, "mnt")
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+
+	// Pretend everything under mnt is another filesystem
+	deviceOf = func(path string) (uint64, bool) {
+		if path == mount || strings.HasPrefix(path, mount+string(filepath.Separator)) {
+			return 2, true
+		}
+		return 1, true
+	}
+	t.Cleanup(func() { deviceOf = device })
+
+	path := filepath.Join(mount, "photos", "a.jpg")
+	writeFile(t, path)
+	if err := os.MkdirAll(home, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := moveFreedesktop(path); err != nil {
+		t.Fatal(err)
+	}
+
+	trash := filepath.Join(mount, ".Trash-"+strconv.Itoa(os.Getuid()))
+	if _, err := os.Stat(filepath.Join(trash, "files", "a.jpg")); err != nil {
+		t.Fatalf("a.jpg isn't in the mount's trash: %v", err)
+	}
+	info := readInfo(t, filepath.Join(trash, "info", "a.jpg.trashinfo"))
+	if !strings.Contains(info, "\nPath=photos/a.jpg\n") {
+		t.Errorf("trashinfo is %q, want a path relative to the mount", info)
+	}
+	if _, err := os.Stat(filepath.Join(home, "data", "Trash")); !os.IsNotExist(err) {
+		t.Errorf("the home trash was used too")
+	}
+}
+
+func TestTopTrashesNeedsSticky(t *testing.T) {
+	top := t.TempDir()
+	uid := strconv.Itoa(os.Getuid())
+	if err := os.Mkdir(filepath.Join(top, ".Trash"), 0o777); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := topTrashes(top); len(got) != 1 || got[0] != filepath.Join(top, ".Trash-"+uid) {
+		t.Errorf("without the sticky bit got %v, want only .Trash-%s", got, uid)
+	}
+
+	if err := os.Chmod(filepath.Join(top, ".Trash"), 0o777|os.ModeSticky); err != nil {
+		t.Fatal(err)
+	}
+	if got := topTrashes(top); len(got) != 2 || got[0] != filepath.Join(top, ".Trash", uid) {
+		t.Errorf("with the sticky bit got %v, want .Trash/%s first", got, uid)
+	}
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	BackgroundAuto  = "auto"
+	BackgroundDark  = "dark"
+	BackgroundLight = "light"
+)
+
+// Chroma styles used when a theme has no variant for the detected background
+var defaultChroma = map[string]string{
+	BackgroundDark:  "monokai",
+	BackgroundLight: "github",
+}
+
+// DetectBackground returns "dark" or "light". In auto mode the terminal is
+// queried (OSC 11 through termenv), which has to happen before the program
+// takes over stdin.
+func DetectBackground(mode string) string {
+	switch mode {
+	case BackgroundDark, BackgroundLight:
+		return mode
+	}
+	if lipgloss.HasDarkBackground() {
+		return BackgroundDark
+	}
+	return BackgroundLight
+}
+
+// ResolveTheme loads the named theme and swaps to its light or dark variant
+// to match background. Themes without a matching variant keep their colors,
+// and unless they chose a chroma style get one readable on that background.
+func ResolveTheme(name, background string) (Theme, error) {
+	theme, err := LoadTheme(name)
+	if theme.Background != background {
+		variant := theme.DarkVariant
+		if background == BackgroundLight {
+			variant = theme.LightVariant
+		}
+		if variant != "" {
+			if v, verr := LoadTheme(variant); verr == nil {
+				return v, err
+			}
+		}
+	}
+
+	// User themes that don't pick a chroma style of their own
+	if theme.Chroma == "" {
+		theme.Chroma = defaultChroma[background]
+	}
+	return theme, err
+}
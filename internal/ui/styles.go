@@ -1,8 +1,11 @@
 package ui
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/icons"
 )
 
 var DocStyle = lipgloss.NewStyle().Margin(1, 2)
@@ -11,40 +14,103 @@ var FileViewStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding
 
 // DefaultStyler implements the Styler interface
 type DefaultStyler struct {
+	theme             Theme
 	docStyle          lipgloss.Style
 	filePreview       lipgloss.Style
 	imagePreview      lipgloss.Style
-	hoverStyle        lipgloss.Style
+	metadataPanel     lipgloss.Style
+	previewTitle      lipgloss.Style
 	columnStyle       lipgloss.Style
 	activeColumnStyle lipgloss.Style
+	headerStyle       lipgloss.Style
+	itemStyle         lipgloss.Style
+	selectedItemStyle lipgloss.Style
+	statusBarStyle    lipgloss.Style
+	mutedStyle        lipgloss.Style
+	gitStyles         map[defs.GitStatus]lipgloss.Style
+	ratingStyle       lipgloss.Style
+	flagStyles        map[defs.Flag]lipgloss.Style
+	icons             icons.Set
+	lsColors          *icons.LSColors
 }
 
 var _ defs.Styler = (*DefaultStyler)(nil) // Ensure interface implementation
 
-func NewDefaultStyler() *DefaultStyler {
+func NewDefaultStyler(theme Theme, iconSet string) *DefaultStyler {
+	set, ok := icons.Sets[iconSet]
+	if !ok {
+		set = icons.None
+	}
+
+	color := func(c string) lipgloss.Color { return lipgloss.Color(c) }
+
 	return &DefaultStyler{
+		theme:    theme,
+		icons:    set,
+		lsColors: icons.LoadLSColors(),
 		docStyle: lipgloss.NewStyle().Margin(0, 0),
 		filePreview: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
+			BorderForeground(color(theme.Border)).
 			Padding(1),
 		imagePreview: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
+			BorderForeground(color(theme.Border)).
 			Padding(1),
+		metadataPanel: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(color(theme.Border)).
+			Padding(0, 1),
+		previewTitle: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(color(theme.PreviewTitle)),
 		columnStyle: lipgloss.NewStyle().
 			BorderRight(true).
 			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")).
+			BorderForeground(color(theme.Border)).
 			Margin(0, 0).
 			Padding(0, 0),
 		activeColumnStyle: lipgloss.NewStyle().
 			BorderRight(true).
 			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("205")).
+			BorderForeground(color(theme.ActiveBorder)).
 			Margin(0, 0).
 			Padding(0, 0),
+		headerStyle: lipgloss.NewStyle().
+			Bold(true).
+			Padding(0, 1).
+			Foreground(color(theme.HeaderFg)).
+			Background(color(theme.HeaderBg)),
+		itemStyle: lipgloss.NewStyle().
+			Padding(0, 1),
+		selectedItemStyle: lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(color(theme.SelectedFg)).
+			Background(color(theme.SelectedBg)),
+		statusBarStyle: lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(color(theme.StatusFg)).
+			Background(color(theme.StatusBg)),
+		mutedStyle: lipgloss.NewStyle().
+			Foreground(color(theme.Muted)),
+		gitStyles: map[defs.GitStatus]lipgloss.Style{
+			defs.GitModified:  lipgloss.NewStyle().Foreground(color(theme.GitModified)),
+			defs.GitStaged:    lipgloss.NewStyle().Foreground(color(theme.GitStaged)),
+			defs.GitUntracked: lipgloss.NewStyle().Foreground(color(theme.GitUntracked)),
+			defs.GitIgnored:   lipgloss.NewStyle().Foreground(color(theme.GitIgnored)),
+		},
+		ratingStyle: lipgloss.NewStyle().Foreground(color(theme.Rating)),
+		flagStyles: map[defs.Flag]lipgloss.Style{
+			defs.FlagPick:   lipgloss.NewStyle().Foreground(color(theme.Pick)),
+			defs.FlagReject: lipgloss.NewStyle().Foreground(color(theme.Reject)),
+		},
 	}
 }
 
+func (s *DefaultStyler) Theme() Theme {
+	return s.theme
+}
+
 func (s *DefaultStyler) ListStyle() lipgloss.Style {
 	return s.docStyle
 }
@@ -57,6 +123,14 @@ func (s *DefaultStyler) ImagePreviewStyle() lipgloss.Style {
 	return s.imagePreview
 }
 
+func (s *DefaultStyler) MetadataPanelStyle() lipgloss.Style {
+	return s.metadataPanel
+}
+
+func (s *DefaultStyler) PreviewTitleStyle() lipgloss.Style {
+	return s.previewTitle
+}
+
 func (s *DefaultStyler) GetFrameSize() (width, height int) {
 	return s.docStyle.GetFrameSize()
 }
@@ -68,3 +142,69 @@ func (s *DefaultStyler) ColumnStyle() lipgloss.Style {
 func (s *DefaultStyler) ActiveColumnStyle() lipgloss.Style {
 	return s.activeColumnStyle
 }
+
+func (s *DefaultStyler) HeaderStyle() lipgloss.Style {
+	return s.headerStyle
+}
+
+func (s *DefaultStyler) ItemStyle(item defs.FileItem) lipgloss.Style {
+	return s.itemStyle
+}
+
+func (s *DefaultStyler) SelectedItemStyle() lipgloss.Style {
+	return s.selectedItemStyle
+}
+
+func (s *DefaultStyler) StatusBarStyle() lipgloss.Style {
+	return s.statusBarStyle
+}
+
+func (s *DefaultStyler) MutedStyle() lipgloss.Style {
+	return s.mutedStyle
+}
+
+func (s *DefaultStyler) GitStatusStyle(status defs.GitStatus) lipgloss.Style {
+	return s.gitStyles[status]
+}
+
+func (s *DefaultStyler) RatingStyle() lipgloss.Style {
+	return s.ratingStyle
+}
+
+func (s *DefaultStyler) FlagStyle(flag defs.Flag) lipgloss.Style {
+	return s.flagStyles[flag]
+}
+
+// labelColors are the ANSI colors matching the color label names, so they
+// look the same in every theme
+var labelColors = map[string]string{
+	"red":    "1",
+	"yellow": "3",
+	"green":  "2",
+	"blue":   "4",
+	"purple": "5",
+}
+
+func (s *DefaultStyler) LabelStyle(label string) lipgloss.Style {
+	if c, ok := labelColors[strings.ToLower(label)]; ok {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+	}
+	return s.mutedStyle
+}
+
+func (s *DefaultStyler) ItemIcon(item defs.FileItem) string {
+	return s.icons.Icon(item)
+}
+
+// NameStyle colors item names from LS_COLORS, falling back to the theme's
+// foreground
+func (s *DefaultStyler) NameStyle(item defs.FileItem) lipgloss.Style {
+	if style, ok := s.lsColors.Style(item); ok {
+		return style
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(s.theme.Foreground))
+}
+
+func (s *DefaultStyler) ChromaStyle() string {
+	return s.theme.Chroma
+}
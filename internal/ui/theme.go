@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nooooaaaaah/photoboard/internal/config"
+)
+
+// Theme is the set of colors a Styler is built from. Values are anything
+// lipgloss.Color accepts: ANSI numbers ("205") or hex ("#ff87d7").
+type Theme struct {
+	Name       string `json:"name"`
+	Extends    string `json:"extends,omitempty"`
+	Background string `json:"background"` // "dark" or "light"
+
+	// Themes to switch to when the terminal background doesn't match
+	LightVariant string `json:"light_variant,omitempty"`
+	DarkVariant  string `json:"dark_variant,omitempty"`
+
+	Foreground   string `json:"foreground"`
+	Border       string `json:"border"`
+	ActiveBorder string `json:"active_border"`
+	HeaderFg     string `json:"header_fg"`
+	HeaderBg     string `json:"header_bg"`
+	SelectedFg   string `json:"selected_fg"`
+	SelectedBg   string `json:"selected_bg"`
+	StatusFg     string `json:"status_fg"`
+	StatusBg     string `json:"status_bg"`
+	PreviewTitle string `json:"preview_title"`
+	Muted        string `json:"muted"`
+
+	GitModified  string `json:"git_modified"`
+	GitStaged    string `json:"git_staged"`
+	GitUntracked string `json:"git_untracked"`
+	GitIgnored   string `json:"git_ignored"`
+
+	Rating string `json:"rating"`
+	Pick   string `json:"pick"`
+	Reject string `json:"reject"`
+
+	// Chroma is the syntax highlighting style used by file previews
+	Chroma string `json:"chroma"`
+}
+
+var DarkTheme = Theme{
+	Name:         "dark",
+	Background:   "dark",
+	LightVariant: "light",
+	Foreground:   "252",
+	Border:       "240",
+	ActiveBorder: "205",
+	HeaderFg:     "255",
+	HeaderBg:     "240",
+	SelectedFg:   "0",
+	SelectedBg:   "205",
+	StatusFg:     "252",
+	StatusBg:     "236",
+	PreviewTitle: "205",
+	Muted:        "244",
+	GitModified:  "3",
+	GitStaged:    "2",
+	GitUntracked: "1",
+	GitIgnored:   "240",
+	Rating:       "220",
+	Pick:         "2",
+	Reject:       "1",
+	Chroma:       "monokai",
+}
+
+var LightTheme = Theme{
+	Name:         "light",
+	Background:   "light",
+	DarkVariant:  "dark",
+	Foreground:   "235",
+	Border:       "250",
+	ActiveBorder: "162",
+	HeaderFg:     "235",
+	HeaderBg:     "253",
+	SelectedFg:   "255",
+	SelectedBg:   "162",
+	StatusFg:     "235",
+	StatusBg:     "254",
+	PreviewTitle: "162",
+	Muted:        "242",
+	GitModified:  "130",
+	GitStaged:    "28",
+	GitUntracked: "124",
+	GitIgnored:   "248",
+	Rating:       "136",
+	Pick:         "28",
+	Reject:       "124",
+	Chroma:       "github",
+}
+
+var HighContrastTheme = Theme{
+	Name:         "high-contrast",
+	Background:   "dark",
+	LightVariant: "high-contrast-light",
+	Foreground:   "15",
+	Border:       "15",
+	ActiveBorder: "11",
+	HeaderFg:     "0",
+	HeaderBg:     "15",
+	SelectedFg:   "0",
+	SelectedBg:   "11",
+	StatusFg:     "0",
+	StatusBg:     "15",
+	PreviewTitle: "11",
+	Muted:        "7",
+	GitModified:  "11",
+	GitStaged:    "10",
+	GitUntracked: "9",
+	GitIgnored:   "8",
+	Rating:       "11",
+	Pick:         "10",
+	Reject:       "9",
+	Chroma:       "native",
+}
+
+var HighContrastLightTheme = Theme{
+	Name:         "high-contrast-light",
+	Background:   "light",
+	DarkVariant:  "high-contrast",
+	Foreground:   "0",
+	Border:       "0",
+	ActiveBorder: "4",
+	HeaderFg:     "15",
+	HeaderBg:     "0",
+	SelectedFg:   "15",
+	SelectedBg:   "4",
+	StatusFg:     "15",
+	StatusBg:     "0",
+	PreviewTitle: "4",
+	Muted:        "8",
+	GitModified:  "94",
+	GitStaged:    "22",
+	GitUntracked: "88",
+	GitIgnored:   "8",
+	Rating:       "94",
+	Pick:         "22",
+	Reject:       "88",
+	Chroma:       "bw",
+}
+
+var BuiltinThemes = map[string]Theme{
+	DarkTheme.Name:              DarkTheme,
+	LightTheme.Name:             LightTheme,
+	HighContrastTheme.Name:      HighContrastTheme,
+	HighContrastLightTheme.Name: HighContrastLightTheme,
+}
+
+// ThemesDir is where user themes live, one JSON file per theme
+func ThemesDir() string {
+	return filepath.Join(config.Dir(), "themes")
+}
+
+// LoadTheme resolves name against the user themes directory first and the
+// builtin themes second. User themes only need to set the colors they change;
+// everything else comes from the theme named in "extends" (dark by default).
+func LoadTheme(name string) (Theme, error) {
+	if name == "" {
+		name = DarkTheme.Name
+	}
+
+	data, err := os.ReadFile(filepath.Join(ThemesDir(), name+".json"))
+	if err != nil {
+		if theme, ok := BuiltinThemes[name]; ok {
+			return theme, nil
+		}
+		return DarkTheme, fmt.Errorf("unknown theme %q, have %s", name, strings.Join(ThemeNames(), ", "))
+	}
+
+	var header struct {
+		Extends string `json:"extends"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return DarkTheme, fmt.Errorf("theme %q: %w", name, err)
+	}
+
+	base, ok := BuiltinThemes[header.Extends]
+	if !ok {
+		base = DarkTheme
+	}
+	// The base's variants are other builtins; inheriting them would swap
+	// the user's theme out on the wrong background. Its chroma style suits
+	// its own background, which the user's may not share, so that's left
+	// for ResolveTheme unless the theme sets one.
+	base.Background, base.LightVariant, base.DarkVariant = "", "", ""
+	base.Chroma = ""
+	if err := json.Unmarshal(data, &base); err != nil {
+		return DarkTheme, fmt.Errorf("theme %q: %w", name, err)
+	}
+	base.Name = name
+	return base, nil
+}
+
+// ThemeNames lists builtin and user themes
+func ThemeNames() []string {
+	seen := make(map[string]bool)
+	for name := range BuiltinThemes {
+		seen[name] = true
+	}
+	if entries, err := os.ReadDir(ThemesDir()); err == nil {
+		for _, entry := range entries {
+			if filepath.Ext(entry.Name()) == ".json" {
+				seen[entry.Name()[:len(entry.Name())-len(".json")]] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
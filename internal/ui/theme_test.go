@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUserThemeDoesNotInheritVariants(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(ThemesDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"extends": "dark", "border": "#ff00ff"}`)
+	if err := os.WriteFile(filepath.Join(ThemesDir(), "mine.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bg := range []string{BackgroundDark, BackgroundLight} {
+		theme, err := ResolveTheme("mine", bg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if theme.Name != "mine" {
+			t.Errorf("on a %s background got theme %q, want mine", bg, theme.Name)
+		}
+	}
+}
+
+func TestThemeNamesIncludesUserThemes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(ThemesDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ThemesDir(), "mine.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	names := ThemeNames()
+	if !slices.Contains(names, "mine") || !slices.Contains(names, DarkTheme.Name) {
+		t.Errorf("got %v, want the builtins and mine", names)
+	}
+
+	_, err := LoadTheme("nope")
+	if err == nil || !strings.Contains(err.Error(), "mine") {
+		t.Errorf("unknown theme error %v doesn't list the themes", err)
+	}
+}
+
+func TestUserThemeChroma(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(ThemesDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	themes := map[string]string{
+		"own":     `{"extends": "dark", "chroma": "dracula"}`,
+		"default": `{"extends": "dark"}`,
+	}
+	for name, data := range themes {
+		if err := os.WriteFile(filepath.Join(ThemesDir(), name+".json"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, bg := range []string{BackgroundDark, BackgroundLight} {
+		theme, err := ResolveTheme("own", bg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if theme.Chroma != "dracula" {
+			t.Errorf("on a %s background the theme's own chroma became %q", bg, theme.Chroma)
+		}
+
+		theme, err = ResolveTheme("default", bg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if theme.Chroma != defaultChroma[bg] {
+			t.Errorf("on a %s background got chroma %q, want %q", bg, theme.Chroma, defaultChroma[bg])
+		}
+	}
+}
@@ -1,26 +1,55 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
+	"syscall"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/nooooaaaaah/photoboard/internal/defs"
+	"github.com/nooooaaaaah/photoboard/internal/sidecar"
 	// To fix circular imports:
 	// 1. Move FileItem struct definition to a separate package (e.g. "types")
 	// 2. Update imports in both packages to use "types" package instead
 	// 3. Remove direct dependencies between utils and model packages
 )
 
-func GetFiles(dir string) ([]list.Item, error) {
+type ListOptions struct {
+	ShowHidden         bool
+	IgnorePatterns     []string
+	RespectIgnoreFiles bool
+	Filter             sidecar.Filter
+}
+
+// GetFiles lists dir and returns the visible items along with the number of
+// entries that were hidden (dotfiles, ignored paths and files the tag filter
+// left out).
+func GetFiles(dir string, opts ListOptions) ([]list.Item, int, error) {
 	var items []list.Item
+	hidden := 0
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	var matcher *IgnoreMatcher
+	if !opts.ShowHidden {
+		matcher = NewIgnoreMatcher(dir, opts.IgnorePatterns, opts.RespectIgnoreFiles)
+	}
+
+	// Only files with a sidecar next to them need reading for tags
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
 	}
 
 	if filepath.Dir(dir) != dir {
@@ -34,6 +63,12 @@ func GetFiles(dir string) ([]list.Item, error) {
 	}
 
 	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if matcher != nil && (strings.HasPrefix(entry.Name(), ".") || matcher.Match(path, entry.IsDir())) {
+			hidden++
+			continue
+		}
+
 		info, err := entry.Info()
 		if err != nil {
 			continue
@@ -41,9 +76,26 @@ func GetFiles(dir string) ([]list.Item, error) {
 
 		file := defs.FileItem{
 			Filename: info.Name(),
-			Path:     filepath.Join(dir, info.Name()),
+			Path:     path,
 			Modified: info.ModTime().Format("2006-01-02 15:04"),
 			IsDir:    entry.IsDir(),
+			Mode:     info.Mode(),
+		}
+		if file.Mode&fs.ModeSymlink != 0 {
+			_, err := os.Stat(path)
+			file.Orphan = err != nil
+		}
+		if !file.IsDir {
+			for _, name := range sidecar.Names(path) {
+				if names[name] && name != file.Filename {
+					file.Tags, _ = sidecar.Read(path)
+					break
+				}
+			}
+			if !opts.Filter.Match(file.Tags) {
+				hidden++
+				continue
+			}
 		}
 		items = append(items, file)
 	}
@@ -69,7 +121,7 @@ func GetFiles(dir string) ([]list.Item, error) {
 		return itemI.Filename < itemJ.Filename
 	})
 
-	return items, nil
+	return items, hidden, nil
 }
 
 func OpenFile(path string) error {
@@ -85,6 +137,55 @@ func OpenFile(path string) error {
 	return cmd.Run()
 }
 
+// MoveFile renames src to dst, copying and deleting when they're on
+// different filesystems. It won't overwrite dst.
+func MoveFile(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return &os.LinkError{Op: "move", Old: src, New: dst, Err: fs.ErrExist}
+	}
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// FreeName returns path, or path with " (2)", " (3)"... before the
+// extension if something's already there
+func FreeName(path string) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+			return path
+		}
+		path = fmt.Sprintf("%s (%d)%s", stem, i, ext)
+	}
+}
+
 func GetRootPath() string {
 	dir, err := os.Getwd()
 	if err != nil {
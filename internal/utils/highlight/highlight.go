@@ -7,9 +7,9 @@ import (
 	"github.com/alecthomas/chroma/quick"
 )
 
-func GetSyntaxHighlightedContent(content []byte, filename string) string {
+func GetSyntaxHighlightedContent(content []byte, filename string, style string) string {
 	var buf strings.Builder
-	err := quick.Highlight(&buf, string(content), filepath.Ext(filename)[1:], "terminal", "monokai")
+	err := quick.Highlight(&buf, string(content), strings.TrimPrefix(filepath.Ext(filename), "."), "terminal", style)
 	if err != nil {
 		return string(content)
 	}
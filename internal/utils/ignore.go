@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+type ignoreRule struct {
+	base     string // directory the rule is relative to
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// IgnoreMatcher evaluates gitignore-style rules. Rules added later take
+// precedence over earlier ones, like deeper .gitignore files do in git.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+	// dirs remembers which directories are ignored, since every entry of
+	// a listing asks about the same parents
+	dirs map[string]bool
+}
+
+// NewIgnoreMatcher builds a matcher for entries of dir. User patterns always
+// apply; .gitignore/.ignore files are collected from dir up to the enclosing
+// repository root (or the filesystem root) when respectFiles is set.
+func NewIgnoreMatcher(dir string, patterns []string, respectFiles bool) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+
+	if respectFiles {
+		var dirs []string
+		for d := dir; ; d = filepath.Dir(d) {
+			dirs = append(dirs, d)
+			if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+				break
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+
+		// Walk from the outermost directory inwards so deeper files win
+		for i := len(dirs) - 1; i >= 0; i-- {
+			for _, name := range ignoreFileNames {
+				m.addFile(dirs[i], filepath.Join(dirs[i], name))
+			}
+		}
+	}
+
+	for _, p := range patterns {
+		m.addPattern(dir, p)
+	}
+
+	return m
+}
+
+func (m *IgnoreMatcher) addFile(base, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.addPattern(base, scanner.Text())
+	}
+}
+
+func (m *IgnoreMatcher) addPattern(base, line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	// A slash anywhere but the end anchors the pattern to its base directory
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	re, err := regexp.Compile(globToRegexp(line))
+	if err != nil {
+		return
+	}
+	rule.re = re
+	m.rules = append(m.rules, rule)
+}
+
+// Match reports whether path should be ignored. As in git, everything
+// inside an ignored directory is ignored too, and can't be brought back
+// with a negated pattern.
+func (m *IgnoreMatcher) Match(path string, isDir bool) bool {
+	if m.dirIgnored(filepath.Dir(path)) {
+		return true
+	}
+	return m.match(path, isDir)
+}
+
+// dirIgnored reports whether dir or any directory above it is ignored
+func (m *IgnoreMatcher) dirIgnored(dir string) bool {
+	if filepath.Dir(dir) == dir {
+		return false
+	}
+	if ignored, ok := m.dirs[dir]; ok {
+		return ignored
+	}
+	ignored := m.dirIgnored(filepath.Dir(dir)) || m.match(dir, true)
+	if m.dirs == nil {
+		m.dirs = make(map[string]bool)
+	}
+	m.dirs[dir] = ignored
+	return ignored
+}
+
+// match checks path against the rules without looking at its parents
+func (m *IgnoreMatcher) match(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		// A rule never applies to the directory it was written for
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		subject := rel
+		if !rule.anchored {
+			subject = filepath.Base(path)
+		}
+
+		if rule.re.MatchString(subject) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+	return sb.String()
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob, want string
+	}{
+		{"*.jpg", `^[^/]*\.jpg$`},
+		{"a?c", `^a[^/]c$`},
+		{"**/cache", `^(?:.*/)?cache$`},
+		{"logs/**", `^logs/.*$`},
+		{"[!a-c]x", `^[^a-c]x$`},
+		{"[oops", `^\[oops$`},
+		{`\*lit`, `^\*lit$`},
+	}
+	for _, tt := range tests {
+		if got := globToRegexp(tt.glob); got != tt.want {
+			t.Errorf("globToRegexp(%q) = %q, want %q", tt.glob, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	base := filepath.FromSlash("/photos")
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"star", []string{"*.xmp"}, "a.xmp", false, true},
+		{"star in a subdirectory", []string{"*.xmp"}, "2024/a.xmp", false, true},
+		{"star doesn't cross extensions", []string{"*.xmp"}, "a.xmpx", false, false},
+		{"double star at the top", []string{"**/cache"}, "cache", true, true},
+		{"double star deep", []string{"**/cache"}, "a/b/cache", true, true},
+		{"double star trailing", []string{"raw/**"}, "raw/a/b.cr2", false, true},
+		{"trailing slash matches directories", []string{"tmp/"}, "tmp", true, true},
+		{"trailing slash skips files", []string{"tmp/"}, "tmp", false, false},
+		{"negation", []string{"*.jpg", "!keep.jpg"}, "keep.jpg", false, false},
+		{"negation only undoes its own match", []string{"*.jpg", "!keep.jpg"}, "drop.jpg", false, true},
+		{"later rules win", []string{"!keep.jpg", "*.jpg"}, "keep.jpg", false, true},
+		{"leading slash anchors", []string{"/only"}, "only", false, true},
+		{"leading slash anchors to the base", []string{"/only"}, "sub/only", false, false},
+		{"middle slash anchors", []string{"doc/*.txt"}, "doc/a.txt", false, true},
+		{"middle slash star stays in one directory", []string{"doc/*.txt"}, "doc/x/a.txt", false, false},
+		{"inside an ignored directory", []string{"build/"}, "build/out/a.o", false, true},
+		{"inside an anchored ignored directory", []string{"/export"}, "export/a.jpg", false, true},
+		{"can't re-include inside an ignored directory", []string{"build/", "!build/keep"}, "build/keep", false, true},
+		{"comments and blanks", []string{"# *.jpg", ""}, "a.jpg", false, false},
+		{"escaped hash", []string{`\#tmp`}, "#tmp", false, true},
+	}
+	for _, tt := range tests {
+		m := NewIgnoreMatcher(base, tt.patterns, false)
+		if got := m.Match(filepath.Join(base, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+			t.Errorf("%s: Match(%q) with %q = %v, want %v", tt.name, tt.path, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "trip")
+	for _, dir := range []string{filepath.Join(repo, ".git"), sub} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(repo, ".gitignore"): "*.tmp\ntrip/\n",
+		filepath.Join(sub, ".ignore"):     "!a.tmp\n",
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Listing trip itself, which the repository ignores, hides its entries
+	m := NewIgnoreMatcher(sub, nil, true)
+	if !m.Match(filepath.Join(sub, "a.jpg"), false) {
+		t.Error("file inside an ignored directory isn't ignored")
+	}
+
+	m = NewIgnoreMatcher(repo, nil, true)
+	if !m.Match(filepath.Join(repo, "b.tmp"), false) || m.Match(filepath.Join(repo, "b.jpg"), false) {
+		t.Error(".gitignore in the repository root isn't applied")
+	}
+}
@@ -1,23 +1,27 @@
 package utils
 
 import (
-	"path/filepath"
-	"strings"
-
-	"github.com/qeesung/image2ascii/convert"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/nooooaaaaah/photoboard/internal/imaging"
+	"github.com/nooooaaaaah/photoboard/internal/render"
 )
 
+// IsImageFile reports whether filename is an image photoboard knows about,
+// including ones it can only show metadata for
 func IsImageFile(filename string) bool {
-	ext := strings.ToLower(filepath.Ext(filename))
-	return ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif"
+	return imaging.IsImage(filename)
 }
 
-func ImageToAscii(path string) string {
-	converter := convert.NewImageConverter()
-	options := convert.DefaultOptions
-	options.FixedWidth = 80
-	options.FixedHeight = 40
+// ImageToAscii renders the image at path as truecolor half blocks fitting in
+// width x height cells, degrading to the terminal's color profile
+func ImageToAscii(path string, width, height int) string {
+	img, err := imaging.LoadSize(path, width, height*2)
+	if err != nil {
+		log.Error("Failed to decode image", "path", path, "error", err)
+		return ""
+	}
 
-	res := converter.ImageFile2ASCIIString(path, &options)
-	return res
+	rendered, _ := render.NewHalfBlock(lipgloss.ColorProfile()).Render(img, width, height)
+	return rendered.Text
 }
@@ -0,0 +1,272 @@
+package xmp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Property is a value for Update to write: a simple property, or an
+// unordered array when Bag is set. No values removes it.
+type Property struct {
+	Key    string // "prefix:Name" with a prefix from Prefixes
+	Values []string
+	Bag    bool
+}
+
+// NewPacket is an empty sidecar to Update into
+func NewPacket() []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="photoboard">
+ <rdf:RDF xmlns:rdf="` + rdfNS + `">
+  <rdf:Description rdf:about="">
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+`)
+}
+
+type splice struct {
+	start, end int
+	text       string
+}
+
+// Update rewrites props in doc, which can be a sidecar or a bare packet.
+// Only those properties are touched: everything else, including what other
+// tools wrote and how they formatted it, is left byte for byte.
+func Update(doc []byte, props ...Property) ([]byte, error) {
+	type name struct{ space, local string }
+	namespaces := make(map[string]string)
+	for uri, prefix := range Prefixes {
+		namespaces[prefix] = uri
+	}
+	replace := make(map[name]bool)
+	for _, p := range props {
+		prefix, local, ok := strings.Cut(p.Key, ":")
+		if !ok || namespaces[prefix] == "" {
+			return nil, fmt.Errorf("xmp: unknown property %q", p.Key)
+		}
+		replace[name{namespaces[prefix], local}] = true
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	var (
+		edits  []splice
+		scopes []map[string]string // prefix to namespace, innermost last
+		depth  int
+		// Description elements we're in, by depth
+		descriptions []int
+		// Property being cut out, and where it started
+		removing    = -1
+		removeStart int
+		// The first Description, which gets the new values
+		first struct {
+			found, selfClosing bool
+			depth, start, end  int
+			tag, rdf           string
+			scope              map[string]string
+			insertAt           int
+		}
+	)
+
+	resolve := func(prefix string) string {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			if uri, ok := scopes[i][prefix]; ok {
+				return uri
+			}
+		}
+		return ""
+	}
+
+	for {
+		off := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := int(dec.InputOffset())
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			scope := make(map[string]string)
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					scope[attr.Name.Local] = attr.Value
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					scope[""] = attr.Value
+				}
+			}
+			scopes = append(scopes, scope)
+			uri := resolve(t.Name.Space)
+
+			switch {
+			case removing >= 0:
+			case uri == rdfNS && t.Name.Local == "Description":
+				tag := string(doc[off:end])
+				for _, attr := range t.Attr {
+					if attr.Name.Space != "" && attr.Name.Space != "xmlns" && replace[name{resolve(attr.Name.Space), attr.Name.Local}] {
+						tag = removeAttr(tag, attr.Name.Space+":"+attr.Name.Local)
+					}
+				}
+				if !first.found {
+					first.found = true
+					first.depth, first.start, first.end = depth, off, end
+					first.tag, first.rdf = tag, t.Name.Space
+					first.selfClosing = strings.HasSuffix(strings.TrimSpace(tag), "/>")
+					first.scope = make(map[string]string)
+					for _, s := range scopes {
+						for prefix, uri := range s {
+							first.scope[prefix] = uri
+						}
+					}
+				} else if tag != string(doc[off:end]) {
+					edits = append(edits, splice{off, end, tag})
+				}
+				descriptions = append(descriptions, depth)
+			case len(descriptions) > 0 && descriptions[len(descriptions)-1] == depth-1 && replace[name{uri, t.Name.Local}]:
+				removing, removeStart = depth, off
+			}
+			depth++
+
+		case xml.EndElement:
+			depth--
+			scopes = scopes[:len(scopes)-1]
+			switch {
+			case removing == depth:
+				edits = append(edits, splice{trimIndent(doc, removeStart), end, ""})
+				removing = -1
+			case len(descriptions) > 0 && descriptions[len(descriptions)-1] == depth:
+				descriptions = descriptions[:len(descriptions)-1]
+				if first.depth == depth && first.insertAt == 0 && !first.selfClosing {
+					first.insertAt = off
+				}
+			}
+		}
+	}
+
+	if !first.found {
+		return nil, ErrNoXMP
+	}
+
+	// Write the new values with whatever prefixes the file already uses,
+	// declaring the ones it doesn't have
+	prefixFor := make(map[string]string)
+	for prefix, uri := range first.scope {
+		prefixFor[uri] = prefix
+	}
+	var declare []string
+	var body strings.Builder
+	indent := "\n   "
+	if !first.selfClosing {
+		indent = "\n" + lineIndent(doc, first.insertAt) + " "
+	}
+	for _, p := range props {
+		if len(p.Values) == 0 {
+			continue
+		}
+		canonical, local, _ := strings.Cut(p.Key, ":")
+		uri := namespaces[canonical]
+		prefix, ok := prefixFor[uri]
+		if !ok {
+			prefix = canonical
+			prefixFor[uri] = prefix
+			declare = append(declare, fmt.Sprintf(` xmlns:%s="%s"`, prefix, uri))
+		}
+		qname := prefix + ":" + local
+		if prefix == "" {
+			qname = local
+		}
+
+		if !p.Bag {
+			fmt.Fprintf(&body, "%s<%s>%s</%s>", indent, qname, escape(p.Values[0]), qname)
+			continue
+		}
+		bag, li := first.rdf+":Bag", first.rdf+":li"
+		fmt.Fprintf(&body, "%s<%s>%s <%s>", indent, qname, indent, bag)
+		for _, v := range p.Values {
+			fmt.Fprintf(&body, "%s  <%s>%s</%s>", indent, li, escape(v), li)
+		}
+		fmt.Fprintf(&body, "%s </%s>%s</%s>", indent, bag, indent, qname)
+	}
+	sort.Strings(declare)
+
+	tag := first.tag
+	if len(declare) > 0 {
+		cut := strings.LastIndex(tag, ">")
+		if first.selfClosing {
+			cut = strings.LastIndex(tag, "/>")
+		}
+		tag = strings.TrimRight(tag[:cut], " \t\n") + strings.Join(declare, "") + tag[cut:]
+	}
+
+	if first.selfClosing {
+		if body.Len() > 0 {
+			tag = strings.TrimRight(strings.TrimSuffix(strings.TrimSpace(tag), "/>"), " \t\n") + ">" +
+				body.String() + "\n" + lineIndent(doc, first.start) + "</" + first.rdf + ":Description>"
+		}
+		edits = append(edits, splice{first.start, first.end, tag})
+	} else {
+		edits = append(edits, splice{first.start, first.end, tag})
+		if body.Len() > 0 {
+			at := first.insertAt
+			for at > first.end && isSpace(doc[at-1]) {
+				at--
+			}
+			edits = append(edits, splice{at, at, body.String()})
+		}
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), doc...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out, nil
+}
+
+// removeAttr cuts the attribute called qname out of a start tag
+func removeAttr(tag, qname string) string {
+	re := regexp.MustCompile(`\s+` + regexp.QuoteMeta(qname) + `\s*=\s*("[^"]*"|'[^']*')`)
+	return re.ReplaceAllString(tag, "")
+}
+
+// trimIndent backs start up over the line break and indentation before
+// it, so removing an element doesn't leave a blank line
+func trimIndent(doc []byte, start int) int {
+	i := start
+	for i > 0 && (doc[i-1] == ' ' || doc[i-1] == '\t') {
+		i--
+	}
+	if i > 0 && doc[i-1] == '\n' {
+		return i - 1
+	}
+	return start
+}
+
+// lineIndent is the whitespace at the start of the line pos is on
+func lineIndent(doc []byte, pos int) string {
+	start := bytes.LastIndexByte(doc[:pos], '\n') + 1
+	end := start
+	for end < pos && (doc[end] == ' ' || doc[end] == '\t') {
+		end++
+	}
+	return string(doc[start:end])
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package xmp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpdateRoundTrip(t *testing.T) {
+	doc, err := Update(NewPacket(),
+		Property{Key: "xmp:Rating", Values: []string{"4"}},
+		Property{Key: "xmp:Label", Values: []string{"Red & Blue"}},
+		Property{Key: "dc:subject", Values: []string{"beach", "<sunset>"}, Bag: true},
+		Property{Key: "photoboard:Rating", Values: []string{"3"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, err := Parse(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Properties{
+		"xmp:Rating":        {"4"},
+		"xmp:Label":         {"Red & Blue"},
+		"dc:subject":        {"beach", "<sunset>"},
+		"photoboard:Rating": {"3"},
+	}
+	if !reflect.DeepEqual(props, want) {
+		t.Errorf("got %v, want %v\n%s", props, want, doc)
+	}
+
+	// Removing and rewriting leaves only the new values
+	doc, err = Update(doc,
+		Property{Key: "xmp:Rating", Values: []string{"2"}},
+		Property{Key: "xmp:Label"},
+		Property{Key: "dc:subject", Values: []string{"dunes"}, Bag: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, err = Parse(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Properties{
+		"xmp:Rating":        {"2"},
+		"dc:subject":        {"dunes"},
+		"photoboard:Rating": {"3"},
+	}
+	if !reflect.DeepEqual(props, want) {
+		t.Errorf("after the rewrite got %v, want %v\n%s", props, want, doc)
+	}
+}
+
+const foreign = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:xap="http://ns.adobe.com/xap/1.0/"
+    xmlns:crs="http://ns.adobe.com/camera-raw-settings/1.0/"
+    xap:Rating="1"
+    crs:Exposure2012="+0.50">
+   <crs:ToneCurveName2012>Linear</crs:ToneCurveName2012>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+func TestUpdateKeepsOtherTools(t *testing.T) {
+	doc, err := Update([]byte(foreign), Property{Key: "xmp:Rating", Values: []string{"5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(doc)
+
+	// The file's own prefix is reused rather than declaring xmp again
+	if strings.Contains(out, "xmlns:xmp=") || !strings.Contains(out, "<xap:Rating>5</xap:Rating>") {
+		t.Errorf("rating not written with the file's prefix:\n%s", out)
+	}
+	if strings.Contains(out, `xap:Rating="1"`) {
+		t.Errorf("old rating attribute is still there:\n%s", out)
+	}
+	for _, kept := range []string{`crs:Exposure2012="+0.50"`, "   <crs:ToneCurveName2012>Linear</crs:ToneCurveName2012>\n"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("lost %q:\n%s", kept, out)
+		}
+	}
+
+	props, err := Parse(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Get("xmp:Rating") != "5" || props.Get("crs:ToneCurveName2012") != "Linear" {
+		t.Errorf("got %v", props)
+	}
+}
+
+func TestUpdateSelfClosingDescription(t *testing.T) {
+	doc := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:Rating="-1"/>
+ </rdf:RDF>
+</x:xmpmeta>`)
+
+	out, err := Update(doc, Property{Key: "dc:subject", Values: []string{"a"}, Bag: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, err := Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Get("xmp:Rating") != "-1" || props.Get("dc:subject") != "a" {
+		t.Errorf("got %v\n%s", props, out)
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	if _, err := Update(NewPacket(), Property{Key: "nope:Rating", Values: []string{"1"}}); err == nil {
+		t.Error("unknown prefix didn't fail")
+	}
+	if _, err := Update([]byte("<a/>"), Property{Key: "xmp:Rating", Values: []string{"1"}}); err != ErrNoXMP {
+		t.Errorf("got %v for a document without a Description, want ErrNoXMP", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package xmp reads the RDF/XML metadata packets editors and cameras embed
+// in images or write next to them as .xmp sidecars.
+package xmp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrNoXMP = errors.New("no xmp packet")
+
+// HeadSize is how much of a file is searched for an embedded packet.
+// Writers put it near the front, before the image data.
+const HeadSize = 1 << 20
+
+const rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// Prefixes maps the namespaces photoboard cares about to the prefixes
+// they're conventionally written with, whatever the file itself used
+var Prefixes = map[string]string{
+	"http://ns.adobe.com/xap/1.0/":                 "xmp",
+	"http://ns.adobe.com/xap/1.0/mm/":              "xmpMM",
+	"http://ns.adobe.com/tiff/1.0/":                "tiff",
+	"http://ns.adobe.com/exif/1.0/":                "exif",
+	"http://ns.adobe.com/exif/1.0/aux/":            "aux",
+	"http://cipa.jp/exif/1.0/":                     "exifEX",
+	"http://purl.org/dc/elements/1.1/":             "dc",
+	"http://ns.adobe.com/photoshop/1.0/":           "photoshop",
+	"http://ns.adobe.com/lightroom/1.0/":           "lr",
+	"http://ns.adobe.com/camera-raw-settings/1.0/": "crs",
+	"http://ns.microsoft.com/photo/1.0/":           "MicrosoftPhoto",
+	"http://ns.adobe.com/xmp/1.0/DynamicMedia/":    "xmpDM",
+	// Our own, for what the standard properties have no room for
+	"https://github.com/nooooaaaaah/photoboard/xmp/1.0/": "photoboard",
+}
+
+// Properties holds every simple property as "prefix:Name". Arrays keep all
+// their items, and fields of structs are keyed "prefix:Struct/prefix:Field".
+type Properties map[string][]string
+
+// Get returns the first value of key, which for a language alternative is
+// the default language
+func (p Properties) Get(key string) string {
+	if v := p[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Find returns the XMP packet inside data, which can be a whole file or
+// just its head
+func Find(data []byte) ([]byte, error) {
+	start := bytes.Index(data, []byte("<x:xmpmeta"))
+	end := bytes.Index(data, []byte("</x:xmpmeta>"))
+	if start >= 0 && end > start {
+		return data[start : end+len("</x:xmpmeta>")], nil
+	}
+
+	// Some writers skip the xmpmeta wrapper
+	start = bytes.Index(data, []byte("<rdf:RDF"))
+	end = bytes.Index(data, []byte("</rdf:RDF>"))
+	if start >= 0 && end > start {
+		return data[start : end+len("</rdf:RDF>")], nil
+	}
+	return nil, ErrNoXMP
+}
+
+// ReadHead finds and parses the packet embedded in the head of r
+func ReadHead(r io.ReaderAt) (Properties, error) {
+	head := make([]byte, HeadSize)
+	n, _ := r.ReadAt(head, 0)
+	packet, err := Find(head[:n])
+	if err != nil {
+		return nil, err
+	}
+	return Parse(packet)
+}
+
+// Parse reads the properties of an XMP packet
+func Parse(packet []byte) (Properties, error) {
+	props := make(Properties)
+	dec := xml.NewDecoder(bytes.NewReader(packet))
+	dec.Strict = false
+
+	// Names of the property elements we're inside, and the text of the
+	// innermost one
+	var stack []string
+	var text strings.Builder
+	var sawRDF bool
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			if t.Name.Space == rdfNS {
+				sawRDF = true
+				if t.Name.Local == "Description" {
+					parent := ""
+					if len(stack) > 0 {
+						parent = stack[len(stack)-1] + "/"
+					}
+					for _, attr := range t.Attr {
+						if key, ok := propertyKey(attr.Name); ok {
+							props[parent+key] = append(props[parent+key], attr.Value)
+						}
+					}
+				}
+				continue
+			}
+
+			key, ok := propertyKey(t.Name)
+			if !ok {
+				continue
+			}
+			if len(stack) > 0 {
+				key = stack[len(stack)-1] + "/" + key
+			}
+			stack = append(stack, key)
+			for _, attr := range t.Attr {
+				if attr.Name.Space == rdfNS && attr.Name.Local == "resource" {
+					props[key] = append(props[key], attr.Value)
+				} else if sub, ok := propertyKey(attr.Name); ok {
+					// Struct fields written as attributes with parseType
+					props[key+"/"+sub] = append(props[key+"/"+sub], attr.Value)
+				}
+			}
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			text.Reset()
+			if t.Name.Space == rdfNS {
+				if t.Name.Local == "li" && len(stack) > 0 && value != "" {
+					key := stack[len(stack)-1]
+					props[key] = append(props[key], value)
+				}
+				continue
+			}
+			if _, ok := propertyKey(t.Name); !ok || len(stack) == 0 {
+				continue
+			}
+			key := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if value != "" && len(props[key]) == 0 {
+				props[key] = []string{value}
+			}
+		}
+	}
+
+	if !sawRDF {
+		return nil, ErrNoXMP
+	}
+	return props, nil
+}
+
+// propertyKey names a property element or attribute, skipping the XML and
+// RDF plumbing
+func propertyKey(name xml.Name) (string, bool) {
+	switch name.Space {
+	case "", rdfNS, "xmlns", "xml", "adobe:ns:meta/":
+		return "", false
+	}
+	if prefix, ok := Prefixes[name.Space]; ok {
+		return prefix + ":" + name.Local, true
+	}
+	return name.Space + ":" + name.Local, true
+}